@@ -2,11 +2,13 @@ package app
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/Komilov31/sales-tracker/internal/config"
 	"github.com/Komilov31/sales-tracker/internal/handler"
@@ -20,6 +22,13 @@ import (
 	"github.com/wb-go/wbf/zlog"
 )
 
+const (
+	// defaultTokenTTL is used when token TTL is not configured.
+	defaultTokenTTL = 24 * time.Hour
+	// trashPurgeInterval is how often items expired in trash are purged.
+	trashPurgeInterval = time.Hour
+)
+
 func Run() error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -49,9 +58,27 @@ func Run() error {
 		log.Fatal("could not init db: " + err.Error())
 	}
 
+	cursorSecret, err := secretOrRandom(config.Cfg.Pagination.CursorSecret, "CURSOR_SECRET", "cursors")
+	if err != nil {
+		return err
+	}
+
+	tokenSecret, err := secretOrRandom(config.Cfg.Auth.TokenSecret, "TOKEN_SECRET", "sessions")
+	if err != nil {
+		return err
+	}
+	tokenTTL := time.Duration(config.Cfg.Auth.TokenTTLHours) * time.Hour
+	if tokenTTL <= 0 {
+		tokenTTL = defaultTokenTTL
+	}
+
 	repository := repository.New(db)
-	service := service.New(repository)
-	handler := handler.New(ctx, service)
+	service := service.New(repository, cursorSecret, tokenSecret, tokenTTL)
+	handler := handler.New(ctx, handler.WithAccessControl(service))
+
+	if days := config.Cfg.Trash.RetentionDays; days > 0 {
+		go purgeTrash(ctx, service, time.Duration(days)*24*time.Hour)
+	}
 
 	router := ginext.New()
 	registerRoutes(router, handler)
@@ -65,20 +92,109 @@ func registerRoutes(engine *ginext.Engine, handler *handler.Handler) {
 	engine.LoadHTMLFiles("static/index.html")
 	engine.Static("/static", "static")
 
+	// Public routes
+	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	engine.GET("/", handler.GetMainPage)
+	engine.POST("/auth/login", handler.Login)
+	engine.POST("/auth/register", handler.Register)
+
+	// Routes below require authentication
+	api := engine.Group("/", handler.Authenticate)
+	api.GET("/auth/me", handler.GetCurrentUser)
+	api.POST("/auth/keys", handler.CreateAPIKey)
+	api.GET("/auth/keys", handler.GetAPIKeys)
+	api.DELETE("/auth/keys/:id", handler.RevokeAPIKey)
+	api.GET("/ledgers", handler.GetLedgers)
+	api.POST("/ledgers", handler.CreateLedger)
+
+	// Routes below work with ledger selected by X-Ledger-ID header and are
+	// subject to role of user in it
+	ledger := engine.Group("/", handler.Authenticate, handler.SelectLedger)
+
 	// POST requests
-	engine.POST("/items", handler.CreateItem)
+	ledger.POST("/items", handler.CreateItem)
+	ledger.POST("/items/import", handler.ImportItems)
+	ledger.POST("/items/batch", handler.ExecuteBatch)
+	ledger.POST("/items/:id/restore", handler.RestoreItem)
 
 	// GET requests
-	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	engine.GET("/", handler.GetMainPage)
-	engine.GET("/items", handler.GetAllItems)
-	engine.GET("/analytics", handler.GetAggregated)
-	engine.GET("/analytics/csv", handler.GetAggregatedCSV)
-	engine.GET("/items/csv", handler.GetFilteredCSV)
+	ledger.GET("/items", handler.GetAllItems)
+	ledger.GET("/items/trash", handler.GetTrash)
+	ledger.GET("/analytics", handler.GetAggregated)
+	ledger.GET("/analytics/grouped", handler.GetGroupedAggregated)
+	ledger.GET("/analytics/csv", handler.GetAggregatedCSV)
+	ledger.GET("/items/csv", handler.GetFilteredCSV)
+	ledger.GET("/analytics/xlsx", handler.GetAggregatedXLSX)
+	ledger.GET("/items/xlsx", handler.GetFilteredXLSX)
+	ledger.GET("/reports/statement", handler.GetStatement)
+	ledger.GET("/items/:id", handler.GetItem)
+	ledger.GET("/items/:id/history", handler.GetItemHistory)
+	ledger.GET("/audit", handler.GetAudit)
 
 	// PUT request
-	engine.PUT("/items/:id", handler.UpdateItem)
+	ledger.PUT("/items/:id", handler.UpdateItem)
 
 	// DELETE request
-	engine.DELETE("/items/:id", handler.DeleteItem)
+	ledger.DELETE("/items/:id", handler.DeleteItem)
+
+	// Categories of ledger
+	ledger.GET("/categories", handler.GetCategories)
+	ledger.POST("/categories", handler.CreateCategory)
+	ledger.PUT("/categories/:id", handler.UpdateCategory)
+	ledger.DELETE("/categories/:id", handler.DeleteCategory)
+	ledger.GET("/rules", handler.GetRules)
+	ledger.POST("/rules", handler.CreateRule)
+	ledger.POST("/rules/test", handler.TestRules)
+	ledger.POST("/rules/apply", handler.ApplyRules)
+	ledger.PUT("/rules/:id", handler.UpdateRule)
+	ledger.DELETE("/rules/:id", handler.DeleteRule)
+
+	// Exchange rates of ledger
+	ledger.POST("/rates", handler.CreateExchangeRate)
+	ledger.GET("/rates", handler.GetExchangeRates)
+	ledger.DELETE("/rates/:id", handler.DeleteExchangeRate)
+
+	// Members of ledger given in path
+	ledger.GET("/ledgers/:ledger_id/members", handler.GetMembers)
+	ledger.POST("/ledgers/:ledger_id/members", handler.AddMember)
+	ledger.PUT("/ledgers/:ledger_id/members/:user_id", handler.UpdateMember)
+	ledger.DELETE("/ledgers/:ledger_id/members/:user_id", handler.RemoveMember)
+}
+
+// purgeTrash deletes items which are in trash longer than retention, right
+// away and then every trashPurgeInterval until ctx is canceled.
+func purgeTrash(ctx context.Context, service *service.Service, retention time.Duration) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := service.PurgeTrash(ctx, retention)
+		if err != nil {
+			zlog.Logger.Error().Msg("could not purge trash: " + err.Error())
+		} else if purged > 0 {
+			zlog.Logger.Info().Msgf("purged %d items from trash", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// secretOrRandom returns configured secret or, if it is empty, random one
+// with warning that what it signs will not survive restart.
+func secretOrRandom(secret, env, signed string) ([]byte, error) {
+	if secret != "" {
+		return []byte(secret), nil
+	}
+
+	zlog.Logger.Warn().Msgf("%s is not set, using random secret: %s will not survive restart", env, signed)
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("could not generate secret of %s: %w", signed, err)
+	}
+
+	return random, nil
 }
@@ -21,6 +21,11 @@ import (
 
 // @host localhost:8080
 // @BasePath /
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Token from POST /auth/login prefixed with "Bearer "
 func main() {
 	if err := app.Run(); err != nil {
 		log.Fatal("could not start server: ", err)
@@ -49,9 +49,17 @@ const docTemplate = `{
         },
         "/analytics": {
             "get": {
-                "description": "Retrieve aggregated statistics for items within a date range",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve aggregated statistics for items within a date range. By default every item is returned with statistics attached, view=summary returns statistics once. Items view can be exported as a file in a format requested by format parameter or Accept header.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
                 ],
                 "tags": [
                     "analytics"
@@ -69,34 +77,113 @@ const docTemplate = `{
                         "description": "End date (YYYY-MM-DD)",
                         "name": "to",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response shape: items (default) or summary",
+                        "name": "view",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include items into summary view",
+                        "name": "include_items",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reporting currency (ISO 4217), amounts are converted into it",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response format: json (default), csv, ndjson or xlsx; overrides Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV delimiter: , (default), ;, | or tab",
+                        "name": "delimiter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Prepend UTF-8 BOM to CSV",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV decimal separator: . (default) or ,",
+                        "name": "decimal_separator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV dates: iso (default) or ru (DD.MM.YYYY)",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV header language: en (default) or ru",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "CSV columns in output order (e.g., date,amount,category)",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Aggregated items",
+                        "description": "Summary (view=summary)",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Item"
-                            }
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.AnalyticsSummary"
                         }
                     },
                     "400": {
-                        "description": "Invalid date parameters",
+                        "description": "Invalid query parameters",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "None of accepted media types is supported",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No exchange rate to convert amounts",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
                         }
                     }
                 }
@@ -104,6 +191,11 @@ const docTemplate = `{
         },
         "/analytics/csv": {
             "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "description": "Download CSV file with aggregated statistics for a date range",
                 "tags": [
                     "analytics"
@@ -121,6 +213,58 @@ const docTemplate = `{
                         "description": "End date (YYYY-MM-DD)",
                         "name": "to",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reporting currency (ISO 4217), amounts are converted into it",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV delimiter: , (default), ;, | or tab",
+                        "name": "delimiter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Prepend UTF-8 BOM to CSV",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV decimal separator: . (default) or ,",
+                        "name": "decimal_separator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV dates: iso (default) or ru (DD.MM.YYYY)",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV header language: en (default) or ru",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "CSV columns in output order (e.g., date,amount,category)",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -133,147 +277,157 @@ const docTemplate = `{
                     "400": {
                         "description": "Invalid date parameters",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No exchange rate to convert amounts",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/items": {
+        "/analytics/grouped": {
             "get": {
-                "description": "Retrieve a list of all items, optionally sorted by specified fields",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve statistics computed separately for every group of items within a date range",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "items"
+                    "analytics"
                 ],
-                "summary": "Get all items",
+                "summary": "Get grouped analytics",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
                     {
                         "type": "array",
                         "items": {
                             "type": "string"
                         },
                         "collectionFormat": "csv",
-                        "description": "Sort fields (e.g., date,amount)",
-                        "name": "sort_by",
+                        "description": "Grouping (category, root_category, type, day, week, month, quarter, year)",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reporting currency (ISO 4217), amounts are converted into it",
+                        "name": "currency",
                         "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of items",
+                        "description": "Statistics per group",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ItemWithoutAggregated"
+                                "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.GroupedAggregated"
                             }
                         }
                     },
                     "400": {
                         "description": "Invalid query parameters",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    }
-                }
-            },
-            "post": {
-                "description": "Creates a new expense or income entry in the sales tracker",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "items"
-                ],
-                "summary": "Create a new item",
-                "parameters": [
-                    {
-                        "description": "Item to create",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.CreateItem"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Created item",
+                    "403": {
+                        "description": "Role does not allow the action",
                         "schema": {
-                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ItemWithoutAggregated"
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
                         }
                     },
-                    "400": {
-                        "description": "Invalid payload",
+                    "422": {
+                        "description": "No exchange rate to convert amounts",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/items/csv": {
+        "/analytics/xlsx": {
             "get": {
-                "description": "Download CSV file with filtered and sorted items",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download Excel workbook with items within a date range, statistics of the whole range and of every category on the Totals sheet and a sheet of items for every category",
                 "produces": [
-                    "application/octet-stream"
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
                 ],
                 "tags": [
-                    "items"
+                    "analytics"
                 ],
-                "summary": "Export filtered items as CSV",
+                "summary": "Export aggregated analytics as XLSX",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Sort fields (e.g., date,amount)",
-                        "name": "sort_by",
+                        "type": "string",
+                        "description": "Start date (YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (YYYY-MM-DD)",
+                        "name": "to",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reporting currency (ISO 4217), amounts are converted into it",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -284,55 +438,260 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid query parameters",
+                        "description": "Invalid date parameters",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No exchange rate to convert amounts",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/items/{id}": {
-            "put": {
-                "description": "Partially update an existing item by ID",
-                "consumes": [
-                    "application/json"
+        "/audit": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Returns changes of items of ledger, the newest first, page by page. Pass next_cursor of response as cursor to get the next page",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "items"
+                    "audit"
                 ],
-                "summary": "Update an item",
+                "summary": "Get audit log",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Item ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Only changes of item",
+                        "name": "item_id",
+                        "in": "query"
                     },
                     {
-                        "description": "Fields to update",
+                        "type": "integer",
+                        "description": "Only changes made by user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only operation: create, update, delete or restore",
+                        "name": "operation",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Changes made at or after, YYYY-MM-DD or RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Changes made at or before, YYYY-MM-DD or RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size, 50 by default, at most 500",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of audit log",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.AuditPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/keys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns API keys of current user with their scopes, expiry and time of last use. Keys themselves are not returned",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Get API keys",
+                "responses": {
+                    "200": {
+                        "description": "API keys of user",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.APIKey"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key can not list keys",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issues long-lived key for scripts and integrations, sent in Authorization header as \"Bearer \u003ckey\u003e\". Scopes are items:read, items:write, analytics:read and export. The key is returned only once, only its hash is stored",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Create an API key",
+                "parameters": [
+                    {
+                        "description": "Name, scopes and optional expiry",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.UpdateItem"
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.CreateAPIKey"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created key",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.CreatedAPIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key can not create keys",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes API key of current user, requests with it are rejected from now on",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -346,190 +705,3789 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Invalid ID or payload",
+                        "description": "Invalid ID",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key can not revoke keys",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Checks login and password and issues signed token to be sent in Authorization header as \"Bearer \u003ctoken\u003e\"",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log in",
+                "parameters": [
+                    {
+                        "description": "Login and password",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.Credentials"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Issued token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Token"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid login or password",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns user the token was issued to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Get current user",
+                "responses": {
+                    "200": {
+                        "description": "Current user",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates user account. Without token only the first account can be created, it becomes admin. Afterwards users are registered by admins",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Register a user",
+                "parameters": [
+                    {
+                        "description": "Login and password",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.Credentials"
                         }
                     }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created user",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Registration requires admin",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Login is taken",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/categories": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns categories of ledger with their parents, types and aliases, sorted by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Get categories",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Categories of ledger",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Category"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates category of ledger, a subcategory if parent_id is given. Once ledger has categories, items may only use them or their aliases",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Create a category",
+                "parameters": [
+                    {
+                        "description": "Category",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.CreateCategory"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created category",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Category"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Name or alias is taken",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload, parent or type",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/categories/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces name, parent, type and aliases of category. Items of renamed category are renamed too",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Update a category",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Category",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.UpdateCategory"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated category",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Category"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or malformed payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Category not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Name or alias is taken, or items do not fit the type",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload, parent or type",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes category without subcategories. With replace_with its items are moved to the replacement, which takes name and aliases of deleted category as aliases; otherwise category must have no items",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "categories"
+                ],
+                "summary": "Delete a category",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Category ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of category to move items to",
+                        "name": "replace_with",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or replacement",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Category or replacement not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Category has items or subcategories, or items do not fit replacement",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/items": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a page of items, optionally filtered and sorted by specified fields. With a non-JSON format requested by format parameter or Accept header all matching items are exported as a file, limit and cursor are ignored then.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Get items",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Sort fields (e.g., date,amount)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Item type (доход or расход)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive category substring",
+                        "name": "category_contains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Currency (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (YYYY-MM-DD)",
+                        "name": "date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (YYYY-MM-DD)",
+                        "name": "date_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimal amount",
+                        "name": "amount_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximal amount",
+                        "name": "amount_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at lower bound (YYYY-MM-DD or RFC 3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at upper bound (YYYY-MM-DD or RFC 3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page returned in next_cursor or prev_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response format: json (default), csv, ndjson or xlsx; overrides Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV delimiter: , (default), ;, | or tab",
+                        "name": "delimiter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Prepend UTF-8 BOM to CSV",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV decimal separator: . (default) or ,",
+                        "name": "decimal_separator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV dates: iso (default) or ru (DD.MM.YYYY)",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV header language: en (default) or ru",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "CSV columns in output order (e.g., date,amount,category)",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of items",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ItemsPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "None of accepted media types is supported",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates a new expense or income entry in the sales tracker. Item without category gets the one of the first rule matching it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Create a new item",
+                "parameters": [
+                    {
+                        "description": "Item to create",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.CreateItem"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Created item",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ItemWithoutAggregated"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload, amount precision or no rule matches item without category",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/items/batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Runs create, update and delete operations in a single transaction: either all of them are applied or none. Results are reported per operation in request order.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Execute batch of item operations",
+                "parameters": [
+                    {
+                        "description": "Operations",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.Batch"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Results of all operations",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Item of operation not found, nothing applied",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.BatchResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Item was changed since version of operation, nothing applied",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.BatchResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid operation, nothing applied",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.BatchResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/items/csv": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download CSV file with filtered and sorted items",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Export filtered items as CSV",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Sort fields (e.g., date,amount)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Item type (доход or расход)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive category substring",
+                        "name": "category_contains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Currency (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (YYYY-MM-DD)",
+                        "name": "date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (YYYY-MM-DD)",
+                        "name": "date_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimal amount",
+                        "name": "amount_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximal amount",
+                        "name": "amount_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at lower bound (YYYY-MM-DD or RFC 3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at upper bound (YYYY-MM-DD or RFC 3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV delimiter: , (default), ;, | or tab",
+                        "name": "delimiter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Prepend UTF-8 BOM to CSV",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV decimal separator: . (default) or ,",
+                        "name": "decimal_separator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV dates: iso (default) or ru (DD.MM.YYYY)",
+                        "name": "date_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV header language: en (default) or ru",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "CSV columns in output order (e.g., date,amount,category)",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/items/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates items from CSV in the export layout (header row with type, amount and date columns, optional category and currency, and description and counterparty of bank statements) in a single transaction. Files of every export dialect are accepted: headers in English or Russian, delimiter detected from the header row unless given, amounts with decimal comma and dates in Russian format. Rows without category are categorised by rules of ledger. Nothing is imported if any row is invalid, in which case the import report is returned with status 422.",
+                "consumes": [
+                    "multipart/form-data",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Import items from CSV",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV file, unless sent as text/csv body",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only validate rows",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "CSV delimiter: , ; | or tab, detected by default",
+                        "name": "delimiter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Column mapping as columns[field]=header, e.g. columns[amount]=Сумма",
+                        "name": "columns",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import report",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid file or parameters",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid rows, nothing imported",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.ImportReport"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/items/trash": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve a page of items in trash, filtered and sorted as GET /items. Items stay in trash until restored or purged by retention",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Get deleted items",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Sort fields (e.g., date,amount)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Item type (доход or расход)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive category substring",
+                        "name": "category_contains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Currency (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (YYYY-MM-DD)",
+                        "name": "date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (YYYY-MM-DD)",
+                        "name": "date_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor of the page returned in next_cursor or prev_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of deleted items",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ItemsPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/items/xlsx": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download Excel workbook with filtered and sorted items, statistics for every currency on the Totals sheet and a sheet of items for every category",
+                "produces": [
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Export filtered items as XLSX",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Sort fields (e.g., date,amount)",
+                        "name": "sort_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Item type (доход or расход)",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact category",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive category substring",
+                        "name": "category_contains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Currency (ISO 4217)",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start date (YYYY-MM-DD)",
+                        "name": "date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date (YYYY-MM-DD)",
+                        "name": "date_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimal amount",
+                        "name": "amount_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximal amount",
+                        "name": "amount_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at lower bound (YYYY-MM-DD or RFC 3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Created at upper bound (YYYY-MM-DD or RFC 3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/items/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve an item by its ID, ETag header carries its version to be sent in If-Match header of changes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Get an item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Item",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ItemWithoutAggregated"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of item"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Item not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Partially update an existing item by ID provided it was not changed since its ETag was read",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Update an item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of item, * to update any version",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.UpdateItem"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New version of item"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID, malformed payload or If-Match header",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Item not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Item was changed since its ETag was read",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload, amount precision or no field to update",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move an item to trash by its ID provided it was not changed since its ETag was read, it can be restored until retention purges it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Delete an item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of item, * to delete any version",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or malformed If-Match header",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Item not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Item was changed since its ETag was read",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "If-Match header is missing",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/items/{id}/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns every recorded create, update, delete and restore of item with actor, time and item before and after the change, the oldest first. History is kept after item is deleted",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "Get history of an item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changes of item",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.AuditEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/items/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Takes item out of trash, it is included in items and analytics again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "items"
+                ],
+                "summary": "Restore a deleted item",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Item ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Restored item",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ItemWithoutAggregated"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Item is not in trash",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ledgers": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns ledgers current user is a member of, the oldest first. The first one is used when X-Ledger-ID header is omitted",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ledgers"
+                ],
+                "summary": "Get ledgers",
+                "responses": {
+                    "200": {
+                        "description": "Ledgers of user",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Ledger"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates ledger with current user as its member",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ledgers"
+                ],
+                "summary": "Create a ledger",
+                "parameters": [
+                    {
+                        "description": "Ledger",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.CreateLedger"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created ledger",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Ledger"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "API key can not create ledgers",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ledgers/{ledger_id}/members": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns users with access to ledger along with their roles. Available to every member",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ledgers"
+                ],
+                "summary": "Get ledger members",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID",
+                        "name": "ledger_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Members of ledger",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Member"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Ledger not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Gives registered user access to ledger with role viewer, editor or admin. Requires admin role",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ledgers"
+                ],
+                "summary": "Invite a member",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID",
+                        "name": "ledger_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Login of user and role",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.AddMember"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Added member",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Member"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Ledger or user not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User is already a member",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/ledgers/{ledger_id}/members/{user_id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Changes role of ledger member. Requires admin role. The last admin of ledger can not be demoted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ledgers"
+                ],
+                "summary": "Change role of a member",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID",
+                        "name": "ledger_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New role",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.UpdateMember"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed payload or invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Ledger or member not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Member is the last admin",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Takes access to ledger away from user. Requires admin role. The last admin of ledger can not be removed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ledgers"
+                ],
+                "summary": "Remove a member",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID",
+                        "name": "ledger_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Ledger or member not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Member is the last admin",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/rates": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieve exchange rates of ledger, optionally filtered by currencies",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rates"
+                ],
+                "summary": "Get exchange rates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Base currency (ISO 4217)",
+                        "name": "base",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Quote currency (ISO 4217)",
+                        "name": "quote",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of exchange rates",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.ExchangeRate"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sets how many units of quote currency one unit of base currency costs starting from date in ledger",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rates"
+                ],
+                "summary": "Create or replace an exchange rate",
+                "parameters": [
+                    {
+                        "description": "Exchange rate",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.CreateExchangeRate"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Created exchange rate",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.ExchangeRate"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only admins can create exchange rates, not with API key",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/rates/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove an exchange rate of ledger by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rates"
+                ],
+                "summary": "Delete an exchange rate",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Exchange rate ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only admins can delete exchange rates, not with API key",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Exchange rate not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/statement": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download printable statement for a month: opening and closing balance, income and expense tables by category with count, sum, average, median and 90th percentile",
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Get monthly statement as PDF",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Month (YYYY-MM)",
+                        "name": "month",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reporting currency (ISO 4217), amounts are converted into it",
+                        "name": "currency",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No exchange rate to convert amounts",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/rules": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns rules of ledger in the order they are tried: the highest priority first, the older first among equal priorities",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Get categorisation rules",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rules of ledger",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Rule"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates rule assigning category to items created without one. Description and counterparty are matched case-insensitively as substrings, or as regular expressions if regex is set",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Create a categorisation rule",
+                "parameters": [
+                    {
+                        "description": "Rule",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.CreateRule"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created rule",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Rule"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload or unknown category",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/rules/apply": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Moves every item matched by a rule to category of the first such rule, items no rule matches keep their category. All items are changed at once or none, each change is recorded in audit log",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Re-apply categorisation rules",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date of items (YYYY-MM-DD)",
+                        "name": "date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date of items (YYYY-MM-DD)",
+                        "name": "date_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only report changes",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changed items",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.RulesReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Item was changed while rules were applied",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Category of rule does not fit item",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/rules/test": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Tells which rule would fire for the given item and which category item would get, rule is null if none matches. Nothing is changed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Test categorisation rules",
+                "parameters": [
+                    {
+                        "description": "Item to test rules against",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.RuleSample"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Matching rule",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.RuleMatch"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/rules/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replaces conditions, priority and category of rule",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Update a categorisation rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rule",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.UpdateRule"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated rule",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Rule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID or malformed payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid payload or unknown category",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes rule, items it has categorised keep their category",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rules"
+                ],
+                "summary": "Delete a categorisation rule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ledger ID, the oldest ledger of user by default",
+                        "name": "X-Ledger-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Role does not allow the action",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Rule not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "github_com_Komilov31_sales-tracker_internal_dto.AddMember": {
+            "type": "object",
+            "required": [
+                "login",
+                "role"
+            ],
+            "properties": {
+                "login": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "viewer",
+                        "editor",
+                        "admin"
+                    ]
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.AnalyticsSummary": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ItemWithoutAggregated"
+                    }
+                },
+                "summary": {
+                    "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Aggregated"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.Batch": {
+            "type": "object",
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.BatchOperation"
+                    }
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.BatchOperation": {
+            "type": "object",
+            "required": [
+                "op"
+            ],
+            "properties": {
+                "changes": {
+                    "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.UpdateItem"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "item": {
+                    "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.CreateItem"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ]
+                },
+                "version": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.BatchResult"
+                    }
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.CreateAPIKey": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.CreateCategory": {
+            "type": "object",
+            "required": [
+                "aliases",
+                "name"
+            ],
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "доход",
+                        "расход"
+                    ]
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.CreateExchangeRate": {
+            "type": "object",
+            "required": [
+                "base",
+                "date",
+                "quote",
+                "rate"
+            ],
+            "properties": {
+                "base": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "quote": {
+                    "type": "string"
+                },
+                "rate": {
+                    "type": "string",
+                    "example": "89.69"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.CreateItem": {
+            "type": "object",
+            "required": [
+                "amount",
+                "date",
+                "type"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "149.90"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "counterparty": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "доход",
+                        "расход"
+                    ]
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.CreateLedger": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.CreateRule": {
+            "type": "object",
+            "required": [
+                "category"
+            ],
+            "properties": {
+                "amount_max": {
+                    "type": "string",
+                    "minLength": 0,
+                    "example": "1000"
+                },
+                "amount_min": {
+                    "type": "string",
+                    "minLength": 0,
+                    "example": "0"
+                },
+                "category": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "counterparty": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "regex": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "доход",
+                        "расход"
+                    ]
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.Credentials": {
+            "type": "object",
+            "required": [
+                "login",
+                "password"
+            ],
+            "properties": {
+                "login": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 3
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 72,
+                    "minLength": 8
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.FieldError"
+                    }
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.ItemWithoutAggregated": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "149.90"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "counterparty": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.ItemsPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_dto.ItemWithoutAggregated"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.RuleSample": {
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "minLength": 0,
+                    "example": "149.90"
+                },
+                "counterparty": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "доход",
+                        "расход"
+                    ]
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.UpdateCategory": {
+            "type": "object",
+            "required": [
+                "aliases",
+                "name"
+            ],
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "доход",
+                        "расход"
+                    ]
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.UpdateItem": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "string",
+                    "example": "149.90"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "counterparty": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "currency": {
+                    "type": "string"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "доход",
+                        "расход"
+                    ]
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.UpdateMember": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "viewer",
+                        "editor",
+                        "admin"
+                    ]
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_dto.UpdateRule": {
+            "type": "object",
+            "required": [
+                "category"
+            ],
+            "properties": {
+                "amount_max": {
+                    "type": "string",
+                    "minLength": 0,
+                    "example": "1000"
+                },
+                "amount_min": {
+                    "type": "string",
+                    "minLength": 0,
+                    "example": "0"
+                },
+                "category": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "counterparty": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "regex": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "доход",
+                        "расход"
+                    ]
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.Aggregated": {
+            "type": "object",
+            "properties": {
+                "average": {
+                    "type": "string"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "expense": {
+                    "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.TypeStats"
+                },
+                "income": {
+                    "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.TypeStats"
+                },
+                "median": {
+                    "type": "string"
+                },
+                "net": {
+                    "type": "string"
+                },
+                "percentile_90": {
+                    "type": "string"
+                },
+                "sum": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "after": {
+                    "type": "object"
+                },
+                "api_key_id": {
+                    "type": "integer"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "item_id": {
+                    "type": "integer"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.AuditPage": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.AuditEntry"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.BatchResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.FieldError"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "item": {
+                    "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Item"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
                 }
-            },
-            "delete": {
-                "description": "Remove an item by its ID",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "items"
-                ],
-                "summary": "Delete an item",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Item ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.Category": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Success message",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Item not found or invalid ID",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal server error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.CreatedAPIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "github_com_Komilov31_sales-tracker_internal_dto.CreateItem": {
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.ExchangeRate": {
             "type": "object",
-            "required": [
-                "amount",
-                "category",
-                "date",
-                "type"
-            ],
             "properties": {
-                "amount": {
-                    "type": "integer",
-                    "minimum": 0
+                "base": {
+                    "type": "string"
                 },
-                "category": {
+                "created_at": {
                     "type": "string"
                 },
                 "date": {
                     "type": "string"
                 },
-                "type": {
+                "id": {
+                    "type": "integer"
+                },
+                "quote": {
+                    "type": "string"
+                },
+                "rate": {
                     "type": "string",
-                    "enum": [
-                        "доход",
-                        "расход"
-                    ]
+                    "example": "89.69"
                 }
             }
         },
-        "github_com_Komilov31_sales-tracker_internal_dto.ItemWithoutAggregated": {
+        "github_com_Komilov31_sales-tracker_internal_model.FieldError": {
             "type": "object",
             "properties": {
-                "amount": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.GroupedAggregated": {
+            "type": "object",
+            "properties": {
+                "aggregated_data": {
+                    "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Aggregated"
+                },
+                "group": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.ImportReport": {
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.ImportRowError"
+                    }
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "line": {
                     "type": "integer"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.Item": {
+            "type": "object",
+            "properties": {
+                "aggregated_data": {
+                    "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Aggregated"
+                },
+                "amount": {
+                    "type": "string",
+                    "example": "149.90"
                 },
                 "category": {
                     "type": "string"
                 },
+                "counterparty": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "currency": {
+                    "type": "string"
+                },
                 "date": {
                     "type": "string"
                 },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "type": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
-        "github_com_Komilov31_sales-tracker_internal_dto.UpdateItem": {
+        "github_com_Komilov31_sales-tracker_internal_model.Ledger": {
             "type": "object",
             "properties": {
-                "amount": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.Member": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "user_id": {
                     "type": "integer"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.Rule": {
+            "type": "object",
+            "properties": {
+                "amount_max": {
+                    "type": "string",
+                    "example": "1000"
+                },
+                "amount_min": {
+                    "type": "string",
+                    "example": "0"
                 },
                 "category": {
                     "type": "string"
                 },
-                "date": {
+                "counterparty": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
                     "type": "string"
                 },
+                "id": {
+                    "type": "integer"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "regex": {
+                    "type": "boolean"
+                },
                 "type": {
                     "type": "string"
                 }
             }
         },
-        "github_com_Komilov31_sales-tracker_internal_model.Aggreagated": {
+        "github_com_Komilov31_sales-tracker_internal_model.RuleChange": {
             "type": "object",
             "properties": {
-                "avarage": {
-                    "type": "number"
+                "from": {
+                    "type": "string"
                 },
-                "count": {
+                "item_id": {
                     "type": "integer"
                 },
-                "median": {
-                    "type": "number"
+                "rule_id": {
+                    "type": "integer"
                 },
-                "percentile": {
-                    "type": "number"
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.RuleMatch": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
                 },
-                "sum": {
+                "rule": {
+                    "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Rule"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.RulesReport": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.RuleChange"
+                    }
+                },
+                "checked": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "updated": {
                     "type": "integer"
                 }
             }
         },
-        "github_com_Komilov31_sales-tracker_internal_model.Item": {
+        "github_com_Komilov31_sales-tracker_internal_model.Token": {
             "type": "object",
             "properties": {
-                "aggregated_data": {
-                    "$ref": "#/definitions/github_com_Komilov31_sales-tracker_internal_model.Aggreagated"
+                "expires_at": {
+                    "type": "string"
                 },
-                "amount": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.TypeStats": {
+            "type": "object",
+            "properties": {
+                "average": {
+                    "type": "string"
+                },
+                "count": {
                     "type": "integer"
                 },
-                "category": {
+                "median": {
                     "type": "string"
                 },
-                "created_at": {
+                "percentile_90": {
                     "type": "string"
                 },
-                "date": {
+                "sum": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_Komilov31_sales-tracker_internal_model.User": {
+            "type": "object",
+            "properties": {
+                "created_at": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "type": {
+                "login": {
                     "type": "string"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "Token from POST /auth/login prefixed with \"Bearer \"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
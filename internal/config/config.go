@@ -31,5 +31,11 @@ func initConfig(path string) *Config {
 	value, _ := os.LookupEnv("DB_PASSWORD")
 	cfg.Postgres.Password = value
 
+	value, _ = os.LookupEnv("CURSOR_SECRET")
+	cfg.Pagination.CursorSecret = value
+
+	value, _ = os.LookupEnv("TOKEN_SECRET")
+	cfg.Auth.TokenSecret = value
+
 	return &cfg
 }
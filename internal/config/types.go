@@ -3,6 +3,9 @@ package config
 type Config struct {
 	Postgres   PostgresConfig   `mapstructure:"postgres"`
 	HttpServer HttpServerConfig `mapstructure:"http_server"`
+	Pagination PaginationConfig `mapstructure:"pagination"`
+	Auth       AuthConfig       `mapstructure:"auth"`
+	Trash      TrashConfig      `mapstructure:"trash"`
 }
 
 type PostgresConfig struct {
@@ -17,3 +20,18 @@ type HttpServerConfig struct {
 	Address string `mapstructure:"address"`
 	Timeout int    `mapstructure:"timeout"`
 }
+
+type PaginationConfig struct {
+	CursorSecret string `mapstructure:"cursor_secret"`
+}
+
+type AuthConfig struct {
+	TokenSecret   string `mapstructure:"token_secret"`
+	TokenTTLHours int    `mapstructure:"token_ttl_hours"`
+}
+
+// TrashConfig sets how long deleted items are kept before they are purged,
+// zero keeps them forever.
+type TrashConfig struct {
+	RetentionDays int `mapstructure:"retention_days"`
+}
@@ -1,30 +1,274 @@
 package dto
 
-import "time"
+import (
+	"time"
 
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/shopspring/decimal"
+)
+
+// CreateItem describes new item. Items created without category get the
+// one of the first rule matching them.
 type CreateItem struct {
-	Type     string `json:"type" validate:"required,oneof=доход расход"`
-	Amount   int    `json:"amount" validate:"required,gte=0"`
-	Date     string `json:"date" validate:"required,datetime=2006-01-02"`
-	Category string `json:"category" validate:"required"`
+	Type         string          `json:"type" validate:"required,oneof=доход расход"`
+	Amount       decimal.Decimal `json:"amount" validate:"required,gt=0" swaggertype:"string" example:"149.90"`
+	Date         string          `json:"date" validate:"required,datetime=2006-01-02"`
+	Category     string          `json:"category,omitempty" validate:"omitempty,notblank"`
+	Currency     string          `json:"currency" validate:"omitempty,iso4217"`
+	Description  string          `json:"description,omitempty" validate:"max=500"`
+	Counterparty string          `json:"counterparty,omitempty" validate:"max=200"`
 }
 
 type ItemWithoutAggregated struct {
-	ID        int       `json:"id"`
-	Type      string    `json:"type"`
-	Amount    int       `json:"amount"`
-	Date      string    `json:"date"`
-	Category  string    `json:"category"`
-	CreatedAt time.Time `json:"created_at"`
+	ID           int             `json:"id"`
+	Type         string          `json:"type"`
+	Amount       decimal.Decimal `json:"amount" swaggertype:"string" example:"149.90"`
+	Date         string          `json:"date"`
+	Category     string          `json:"category"`
+	Currency     string          `json:"currency"`
+	Description  string          `json:"description,omitempty"`
+	Counterparty string          `json:"counterparty,omitempty"`
+	Version      int             `json:"version,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	DeletedAt    *time.Time      `json:"deleted_at,omitempty"`
+}
+
+// ConvertWithoutAggregated returns item in the shape items are sent in
+// everywhere but analytics.
+func ConvertWithoutAggregated(item *model.Item) ItemWithoutAggregated {
+	return ItemWithoutAggregated{
+		ID: item.ID, Type: item.Type, Amount: item.Amount,
+		Date: item.Date, Category: item.Category,
+		Currency: item.Currency, Description: item.Description,
+		Counterparty: item.Counterparty, Version: item.Version,
+		CreatedAt: item.CreatedAt, DeletedAt: item.DeletedAt,
+	}
 }
 
+// UpdateItem changes only fields which are not nil, every such field is
+// validated with the rules of CreateItem.
 type UpdateItem struct {
-	Type     *string `json:"type"`
-	Amount   *int    `json:"amount"`
-	Date     *string `json:"date"`
-	Category *string `json:"category"`
+	Type         *string          `json:"type" validate:"omitnil,oneof=доход расход"`
+	Amount       *decimal.Decimal `json:"amount" validate:"omitnil,gt=0" swaggertype:"string" example:"149.90"`
+	Date         *string          `json:"date" validate:"omitnil,datetime=2006-01-02"`
+	Category     *string          `json:"category" validate:"omitnil,notblank"`
+	Currency     *string          `json:"currency" validate:"omitnil,iso4217"`
+	Description  *string          `json:"description" validate:"omitnil,max=500"`
+	Counterparty *string          `json:"counterparty" validate:"omitnil,max=200"`
 }
 
+// GetItemsParams filters items of ledger LedgerID, the ledger is always
+// applied, so that items of other ledgers are never returned.
 type GetItemsParams struct {
-	SortBy []string
+	LedgerID         int
+	SortBy           []string
+	Type             string
+	Category         string
+	CategoryContains string
+	Currency         string
+	DateFrom         string
+	DateTo           string
+	AmountMin        *decimal.Decimal
+	AmountMax        *decimal.Decimal
+	CreatedFrom      *time.Time
+	CreatedTo        *time.Time
+	Limit            int
+	Cursor           string
+	Keyset           *Keyset
+	// Deleted selects items in trash instead of the regular ones.
+	Deleted bool
+}
+
+// Keyset is a decoded pagination cursor: values of sorting fields (followed by
+// id) of the item the page starts after.
+type Keyset struct {
+	Values   []string
+	Backward bool
+}
+
+type ItemsPage struct {
+	Items      []ItemWithoutAggregated `json:"items"`
+	NextCursor string                  `json:"next_cursor,omitempty"`
+	PrevCursor string                  `json:"prev_cursor,omitempty"`
+	Total      int                     `json:"total"`
+}
+
+type AnalyticsParams struct {
+	LedgerID int
+	From     string
+	To       string
+	GroupBy  []string
+	Currency string
+}
+
+// ExportOptions tune CSV exports, zero value gives comma separated UTF-8
+// with point as decimal separator, ISO 8601 dates, English header and all
+// columns in default order.
+type ExportOptions struct {
+	Delimiter        rune
+	BOM              bool
+	DecimalSeparator string
+	DateFormat       string
+	Language         string
+	Columns          []string
+}
+
+// StatementParams selects month of financial statement, Month is YYYY-MM.
+// With Currency set amounts are converted into it as in analytics.
+type StatementParams struct {
+	LedgerID int
+	Month    string
+	Currency string
+}
+
+type CreateExchangeRate struct {
+	Base  string          `json:"base" validate:"required,iso4217"`
+	Quote string          `json:"quote" validate:"required,iso4217,nefield=Base"`
+	Date  string          `json:"date" validate:"required,datetime=2006-01-02"`
+	Rate  decimal.Decimal `json:"rate" validate:"required,gt=0" swaggertype:"string" example:"89.69"`
+}
+
+type GetExchangeRatesParams struct {
+	LedgerID int
+	Base     string
+	Quote    string
+}
+
+type AnalyticsSummary struct {
+	Summary model.Aggregated        `json:"summary"`
+	Items   []ItemWithoutAggregated `json:"items,omitempty"`
+}
+
+// ImportOptions tells how to read items from CSV. Columns maps item fields
+// (type, amount, date, category, currency) to CSV header names, fields not
+// mentioned are looked up by their own name or header of Russian export.
+// Zero Delimiter is detected from the header row.
+type ImportOptions struct {
+	Columns   map[string]string
+	Delimiter rune
+	DryRun    bool
+}
+
+// BatchOperation is a single create, update or delete within a batch. Create
+// takes Item, update takes ID and Changes, delete takes ID. Update and delete
+// take Version item is expected to have, as items are changed by If-Match
+// only.
+type BatchOperation struct {
+	Op      string      `json:"op" validate:"required,oneof=create update delete"`
+	ID      int         `json:"id" validate:"required_unless=Op create,excluded_if=Op create"`
+	Version int         `json:"version,omitempty" validate:"min=0,required_unless=Op create,excluded_if=Op create"`
+	Item    *CreateItem `json:"item,omitempty" validate:"required_if=Op create,excluded_unless=Op create"`
+	Changes *UpdateItem `json:"changes,omitempty" validate:"required_if=Op update,excluded_unless=Op update"`
+}
+
+type Batch struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+type BatchResponse struct {
+	Error   string              `json:"error,omitempty"`
+	Code    string              `json:"code,omitempty"`
+	Results []model.BatchResult `json:"results"`
+}
+
+// ErrorResponse is the body of every error response. Error describes what
+// went wrong to people, Code names it for programs. Fields are sent with
+// validation errors and tell which rule every invalid field breaks.
+type ErrorResponse struct {
+	Error  string             `json:"error"`
+	Code   string             `json:"code"`
+	Fields []model.FieldError `json:"fields,omitempty"`
+}
+
+// Credentials are login and password of user. Password is limited to 72
+// bytes, longer ones are silently truncated by bcrypt.
+type Credentials struct {
+	Login    string `json:"login" validate:"required,min=3,max=64"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// CreateUser is user to create. RegisteredBy is id of user registering it,
+// zero for anonymous registration, which creates the first user only.
+type CreateUser struct {
+	Login        string
+	PasswordHash string
+	RegisteredBy int
+}
+
+type CreateLedger struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// CreateCategory describes category of ledger, ParentID makes it a
+// subcategory. Categories are updated as a whole, so UpdateCategory has the
+// same fields.
+type CreateCategory struct {
+	Name     string   `json:"name" validate:"required,notblank,max=100"`
+	ParentID *int     `json:"parent_id,omitempty" validate:"omitnil,min=1"`
+	Type     string   `json:"type,omitempty" validate:"omitempty,oneof=доход расход"`
+	Aliases  []string `json:"aliases,omitempty" validate:"max=20,unique,dive,required,notblank,max=100"`
+}
+
+type UpdateCategory CreateCategory
+
+// CreateRule describes rule of ledger, see model.Rule. Rules are updated as a
+// whole, so UpdateRule has the same fields.
+type CreateRule struct {
+	Priority     int              `json:"priority"`
+	Category     string           `json:"category" validate:"required,notblank,max=100"`
+	Description  string           `json:"description,omitempty" validate:"max=200"`
+	Counterparty string           `json:"counterparty,omitempty" validate:"max=200"`
+	Regex        bool             `json:"regex,omitempty"`
+	Type         string           `json:"type,omitempty" validate:"omitempty,oneof=доход расход"`
+	AmountMin    *decimal.Decimal `json:"amount_min,omitempty" validate:"omitnil,gte=0" swaggertype:"string" example:"0"`
+	AmountMax    *decimal.Decimal `json:"amount_max,omitempty" validate:"omitnil,gte=0" swaggertype:"string" example:"1000"`
+}
+
+type UpdateRule CreateRule
+
+// RuleSample is an item rules are tested against.
+type RuleSample struct {
+	Type         string          `json:"type" validate:"required,oneof=доход расход"`
+	Amount       decimal.Decimal `json:"amount" validate:"gte=0" swaggertype:"string" example:"149.90"`
+	Description  string          `json:"description,omitempty"`
+	Counterparty string          `json:"counterparty,omitempty"`
+}
+
+// ApplyRulesParams selects items of ledger rules are applied to, by date of
+// item. DryRun only reports changes.
+type ApplyRulesParams struct {
+	LedgerID int
+	DateFrom string
+	DateTo   string
+	DryRun   bool
+}
+
+type AddMember struct {
+	Login string `json:"login" validate:"required"`
+	Role  string `json:"role" validate:"required,oneof=viewer editor admin"`
+}
+
+type UpdateMember struct {
+	Role string `json:"role" validate:"required,oneof=viewer editor admin"`
+}
+
+// CreateAPIKey describes new API key, it never expires when ExpiresAt is
+// omitted.
+type CreateAPIKey struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,unique,dive,oneof=items:read items:write analytics:read export"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// AuditParams filters audit log of ledger, zero values match everything.
+// Entries are returned newest first, starting before BeforeID if it is set.
+type AuditParams struct {
+	LedgerID  int
+	ItemID    int
+	UserID    int
+	Operation string
+	From      *time.Time
+	To        *time.Time
+	BeforeID  int64
+	Limit     int
 }
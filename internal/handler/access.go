@@ -0,0 +1,393 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/wb-go/wbf/ginext"
+)
+
+var (
+	ErrForbidden = model.NewError(model.KindForbidden, "forbidden", "forbidden")
+)
+
+type accessKey struct{}
+
+// access is what request is allowed to do: user it is made by and ledger it
+// works with, together with the role of user in it. Requests made with API
+// key are limited to its scopes as well.
+type access struct {
+	user   *model.User
+	ledger *model.Ledger
+	apiKey *model.APIKey
+}
+
+// requestContext returns context service is called with, carrying user and
+// ledger of request for access control.
+func (h *Handler) requestContext(c *ginext.Context) context.Context {
+	user := currentUser(c)
+	if user == nil {
+		return h.ctx
+	}
+
+	ledger, _ := c.Get(ledgerKey)
+	l, _ := ledger.(*model.Ledger)
+	apiKey, _ := c.Get(apiKeyKey)
+	k, _ := apiKey.(*model.APIKey)
+	return context.WithValue(h.ctx, accessKey{}, access{user: user, ledger: l, apiKey: k})
+}
+
+// accessControl checks role of user in ledger before passing calls to
+// service: viewers may read items and analytics, editors may change items
+// and admins may manage members. API keys are further limited to methods
+// their scopes allow, anything else requires session token. Every method of
+// TrackerService is listed explicitly, so that a new one can not be left
+// unchecked by accident.
+type accessControl struct {
+	service TrackerService
+}
+
+// WithAccessControl wraps service with checks of ledger roles. User and
+// ledger are taken from context handlers call service with.
+func WithAccessControl(service TrackerService) TrackerService {
+	return &accessControl{service: service}
+}
+
+// check returns ErrForbidden unless context carries ledger with ledgerID,
+// role of user in it allows action and API key, if request is made with one,
+// has scope.
+func (a *accessControl) check(ctx context.Context, ledgerID int, required, scope, action string) error {
+	access, ok := ctx.Value(accessKey{}).(access)
+	if !ok || access.ledger == nil || access.ledger.ID != ledgerID {
+		return fmt.Errorf("%w: no access to ledger %d", ErrForbidden, ledgerID)
+	}
+
+	if access.apiKey != nil && (scope == "" || !access.apiKey.HasScope(scope)) {
+		return scopeError(access.apiKey, scope, action)
+	}
+
+	if !model.RoleAllows(access.ledger.Role, required) {
+		return fmt.Errorf("%w: %s role in ledger %d does not allow to %s, %s role is required",
+			ErrForbidden, access.ledger.Role, ledgerID, action, required)
+	}
+
+	return nil
+}
+
+// checkSession returns ErrForbidden if request is made with API key, as
+// action is not covered by any scope.
+func (a *accessControl) checkSession(ctx context.Context, action string) error {
+	access, ok := ctx.Value(accessKey{}).(access)
+	if ok && access.apiKey != nil {
+		return scopeError(access.apiKey, "", action)
+	}
+
+	return nil
+}
+
+func scopeError(apiKey *model.APIKey, scope, action string) error {
+	if scope == "" {
+		return fmt.Errorf("%w: api key %s can not be used to %s, log in instead", ErrForbidden, apiKey.Prefix, action)
+	}
+
+	return fmt.Errorf("%w: api key %s does not allow to %s, %s scope is required", ErrForbidden, apiKey.Prefix, action, scope)
+}
+
+func (a *accessControl) CreateItem(ctx context.Context, actor model.Actor, ledgerID int, item dto.CreateItem) (*model.Item, error) {
+	if err := a.check(ctx, ledgerID, model.RoleEditor, model.ScopeItemsWrite, "create items"); err != nil {
+		return nil, err
+	}
+	return a.service.CreateItem(ctx, actor, ledgerID, item)
+}
+
+func (a *accessControl) ImportItems(ctx context.Context, actor model.Actor, ledgerID int, r io.Reader, options dto.ImportOptions) (*model.ImportReport, error) {
+	if err := a.check(ctx, ledgerID, model.RoleEditor, model.ScopeItemsWrite, "import items"); err != nil {
+		return nil, err
+	}
+	return a.service.ImportItems(ctx, actor, ledgerID, r, options)
+}
+
+func (a *accessControl) GetItemsPage(ctx context.Context, params dto.GetItemsParams) (*model.ItemsPage, error) {
+	if err := a.check(ctx, params.LedgerID, model.RoleViewer, model.ScopeItemsRead, "read items"); err != nil {
+		return nil, err
+	}
+	return a.service.GetItemsPage(ctx, params)
+}
+
+func (a *accessControl) GetItem(ctx context.Context, ledgerID, id int) (*model.Item, error) {
+	if err := a.check(ctx, ledgerID, model.RoleViewer, model.ScopeItemsRead, "read items"); err != nil {
+		return nil, err
+	}
+	return a.service.GetItem(ctx, ledgerID, id)
+}
+
+func (a *accessControl) GetAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.Item, error) {
+	if err := a.check(ctx, params.LedgerID, model.RoleViewer, model.ScopeAnalyticsRead, "read analytics"); err != nil {
+		return nil, err
+	}
+	return a.service.GetAggregated(ctx, params)
+}
+
+func (a *accessControl) GetGroupedAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.GroupedAggregated, error) {
+	if err := a.check(ctx, params.LedgerID, model.RoleViewer, model.ScopeAnalyticsRead, "read analytics"); err != nil {
+		return nil, err
+	}
+	return a.service.GetGroupedAggregated(ctx, params)
+}
+
+func (a *accessControl) GetSummary(ctx context.Context, params dto.AnalyticsParams, includeItems bool) (*model.Summary, error) {
+	if err := a.check(ctx, params.LedgerID, model.RoleViewer, model.ScopeAnalyticsRead, "read analytics"); err != nil {
+		return nil, err
+	}
+	return a.service.GetSummary(ctx, params, includeItems)
+}
+
+func (a *accessControl) UpdateItem(ctx context.Context, actor model.Actor, ledgerID, id, version int, item dto.UpdateItem) error {
+	if err := a.check(ctx, ledgerID, model.RoleEditor, model.ScopeItemsWrite, "update items"); err != nil {
+		return err
+	}
+	return a.service.UpdateItem(ctx, actor, ledgerID, id, version, item)
+}
+
+func (a *accessControl) DeleteItem(ctx context.Context, actor model.Actor, ledgerID, id, version int) error {
+	if err := a.check(ctx, ledgerID, model.RoleEditor, model.ScopeItemsWrite, "delete items"); err != nil {
+		return err
+	}
+	return a.service.DeleteItem(ctx, actor, ledgerID, id, version)
+}
+
+func (a *accessControl) RestoreItem(ctx context.Context, actor model.Actor, ledgerID, id int) (*model.Item, error) {
+	if err := a.check(ctx, ledgerID, model.RoleEditor, model.ScopeItemsWrite, "restore items"); err != nil {
+		return nil, err
+	}
+	return a.service.RestoreItem(ctx, actor, ledgerID, id)
+}
+
+func (a *accessControl) ExecuteBatch(ctx context.Context, actor model.Actor, ledgerID int, operations []dto.BatchOperation) ([]model.BatchResult, error) {
+	if err := a.check(ctx, ledgerID, model.RoleEditor, model.ScopeItemsWrite, "change items"); err != nil {
+		return nil, err
+	}
+	return a.service.ExecuteBatch(ctx, actor, ledgerID, operations)
+}
+
+func (a *accessControl) GetItemHistory(ctx context.Context, ledgerID, itemID int) ([]model.AuditEntry, error) {
+	if err := a.check(ctx, ledgerID, model.RoleViewer, model.ScopeItemsRead, "read history of items"); err != nil {
+		return nil, err
+	}
+	return a.service.GetItemHistory(ctx, ledgerID, itemID)
+}
+
+func (a *accessControl) GetAudit(ctx context.Context, params dto.AuditParams) (*model.AuditPage, error) {
+	if err := a.check(ctx, params.LedgerID, model.RoleViewer, model.ScopeItemsRead, "read audit log"); err != nil {
+		return nil, err
+	}
+	return a.service.GetAudit(ctx, params)
+}
+
+func (a *accessControl) GetCategories(ctx context.Context, ledgerID int) ([]model.Category, error) {
+	if err := a.check(ctx, ledgerID, model.RoleViewer, model.ScopeItemsRead, "read categories"); err != nil {
+		return nil, err
+	}
+	return a.service.GetCategories(ctx, ledgerID)
+}
+
+func (a *accessControl) CreateCategory(ctx context.Context, ledgerID int, category dto.CreateCategory) (*model.Category, error) {
+	if err := a.check(ctx, ledgerID, model.RoleEditor, model.ScopeItemsWrite, "create categories"); err != nil {
+		return nil, err
+	}
+	return a.service.CreateCategory(ctx, ledgerID, category)
+}
+
+func (a *accessControl) UpdateCategory(ctx context.Context, actor model.Actor, ledgerID, id int, category dto.UpdateCategory) (*model.Category, error) {
+	if err := a.check(ctx, ledgerID, model.RoleEditor, model.ScopeItemsWrite, "update categories"); err != nil {
+		return nil, err
+	}
+	return a.service.UpdateCategory(ctx, actor, ledgerID, id, category)
+}
+
+func (a *accessControl) DeleteCategory(ctx context.Context, actor model.Actor, ledgerID, id, replacementID int) error {
+	if err := a.check(ctx, ledgerID, model.RoleEditor, model.ScopeItemsWrite, "delete categories"); err != nil {
+		return err
+	}
+	return a.service.DeleteCategory(ctx, actor, ledgerID, id, replacementID)
+}
+
+func (a *accessControl) GetRules(ctx context.Context, ledgerID int) ([]model.Rule, error) {
+	if err := a.check(ctx, ledgerID, model.RoleViewer, model.ScopeItemsRead, "read rules"); err != nil {
+		return nil, err
+	}
+	return a.service.GetRules(ctx, ledgerID)
+}
+
+func (a *accessControl) CreateRule(ctx context.Context, ledgerID int, rule dto.CreateRule) (*model.Rule, error) {
+	if err := a.check(ctx, ledgerID, model.RoleEditor, model.ScopeItemsWrite, "create rules"); err != nil {
+		return nil, err
+	}
+	return a.service.CreateRule(ctx, ledgerID, rule)
+}
+
+func (a *accessControl) UpdateRule(ctx context.Context, ledgerID, id int, rule dto.UpdateRule) (*model.Rule, error) {
+	if err := a.check(ctx, ledgerID, model.RoleEditor, model.ScopeItemsWrite, "update rules"); err != nil {
+		return nil, err
+	}
+	return a.service.UpdateRule(ctx, ledgerID, id, rule)
+}
+
+func (a *accessControl) DeleteRule(ctx context.Context, ledgerID, id int) error {
+	if err := a.check(ctx, ledgerID, model.RoleEditor, model.ScopeItemsWrite, "delete rules"); err != nil {
+		return err
+	}
+	return a.service.DeleteRule(ctx, ledgerID, id)
+}
+
+func (a *accessControl) TestRules(ctx context.Context, ledgerID int, sample dto.RuleSample) (*model.RuleMatch, error) {
+	if err := a.check(ctx, ledgerID, model.RoleViewer, model.ScopeItemsRead, "test rules"); err != nil {
+		return nil, err
+	}
+	return a.service.TestRules(ctx, ledgerID, sample)
+}
+
+func (a *accessControl) ApplyRules(ctx context.Context, actor model.Actor, params dto.ApplyRulesParams) (*model.RulesReport, error) {
+	if err := a.check(ctx, params.LedgerID, model.RoleEditor, model.ScopeItemsWrite, "apply rules"); err != nil {
+		return nil, err
+	}
+	return a.service.ApplyRules(ctx, actor, params)
+}
+
+func (a *accessControl) Exporters() []model.ExportFormat {
+	return a.service.Exporters()
+}
+
+func (a *accessControl) ExportItems(ctx context.Context, w io.Writer, format string, params dto.GetItemsParams, options dto.ExportOptions) error {
+	if err := a.check(ctx, params.LedgerID, model.RoleViewer, model.ScopeExport, "export items"); err != nil {
+		return err
+	}
+	return a.service.ExportItems(ctx, w, format, params, options)
+}
+
+func (a *accessControl) ExportAggregated(ctx context.Context, w io.Writer, format string, params dto.AnalyticsParams, options dto.ExportOptions) error {
+	if err := a.check(ctx, params.LedgerID, model.RoleViewer, model.ScopeExport, "export analytics"); err != nil {
+		return err
+	}
+	return a.service.ExportAggregated(ctx, w, format, params, options)
+}
+
+func (a *accessControl) StatementPDF(ctx context.Context, w io.Writer, params dto.StatementParams) error {
+	if err := a.check(ctx, params.LedgerID, model.RoleViewer, model.ScopeExport, "read statements"); err != nil {
+		return err
+	}
+	return a.service.StatementPDF(ctx, w, params)
+}
+
+// Exchange rates change converted analytics of the whole ledger, so only
+// admins may change them and not with API key.
+
+func (a *accessControl) CreateExchangeRate(ctx context.Context, ledgerID int, rate dto.CreateExchangeRate) (*model.ExchangeRate, error) {
+	if err := a.check(ctx, ledgerID, model.RoleAdmin, "", "create exchange rates"); err != nil {
+		return nil, err
+	}
+	return a.service.CreateExchangeRate(ctx, ledgerID, rate)
+}
+
+func (a *accessControl) GetExchangeRates(ctx context.Context, params dto.GetExchangeRatesParams) ([]model.ExchangeRate, error) {
+	if err := a.check(ctx, params.LedgerID, model.RoleViewer, model.ScopeAnalyticsRead, "read exchange rates"); err != nil {
+		return nil, err
+	}
+	return a.service.GetExchangeRates(ctx, params)
+}
+
+func (a *accessControl) DeleteExchangeRate(ctx context.Context, ledgerID, id int) error {
+	if err := a.check(ctx, ledgerID, model.RoleAdmin, "", "delete exchange rates"); err != nil {
+		return err
+	}
+	return a.service.DeleteExchangeRate(ctx, ledgerID, id)
+}
+
+// Accounts, API keys and ledgers of user itself are not subject to ledger
+// roles, but only reading ledgers is allowed with API key.
+
+func (a *accessControl) Register(ctx context.Context, credentials dto.Credentials, registeredBy *model.User) (*model.User, error) {
+	if err := a.checkSession(ctx, "register users"); err != nil {
+		return nil, err
+	}
+	return a.service.Register(ctx, credentials, registeredBy)
+}
+
+func (a *accessControl) Login(ctx context.Context, credentials dto.Credentials) (*model.Token, error) {
+	return a.service.Login(ctx, credentials)
+}
+
+func (a *accessControl) Authenticate(token string) (*model.User, error) {
+	return a.service.Authenticate(token)
+}
+
+func (a *accessControl) AuthenticateAPIKey(ctx context.Context, key string) (*model.User, *model.APIKey, error) {
+	return a.service.AuthenticateAPIKey(ctx, key)
+}
+
+func (a *accessControl) CreateAPIKey(ctx context.Context, userID int, key dto.CreateAPIKey) (*model.CreatedAPIKey, error) {
+	if err := a.checkSession(ctx, "create api keys"); err != nil {
+		return nil, err
+	}
+	return a.service.CreateAPIKey(ctx, userID, key)
+}
+
+func (a *accessControl) GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error) {
+	if err := a.checkSession(ctx, "list api keys"); err != nil {
+		return nil, err
+	}
+	return a.service.GetAPIKeys(ctx, userID)
+}
+
+func (a *accessControl) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	if err := a.checkSession(ctx, "revoke api keys"); err != nil {
+		return err
+	}
+	return a.service.RevokeAPIKey(ctx, userID, id)
+}
+
+func (a *accessControl) CreateLedger(ctx context.Context, userID int, name string) (*model.Ledger, error) {
+	if err := a.checkSession(ctx, "create ledgers"); err != nil {
+		return nil, err
+	}
+	return a.service.CreateLedger(ctx, userID, name)
+}
+
+func (a *accessControl) GetLedgers(ctx context.Context, userID int) ([]model.Ledger, error) {
+	return a.service.GetLedgers(ctx, userID)
+}
+
+func (a *accessControl) GetLedger(ctx context.Context, userID, ledgerID int) (*model.Ledger, error) {
+	return a.service.GetLedger(ctx, userID, ledgerID)
+}
+
+func (a *accessControl) GetMembers(ctx context.Context, ledgerID int) ([]model.Member, error) {
+	if err := a.check(ctx, ledgerID, model.RoleViewer, "", "list members"); err != nil {
+		return nil, err
+	}
+	return a.service.GetMembers(ctx, ledgerID)
+}
+
+func (a *accessControl) AddMember(ctx context.Context, ledgerID int, member dto.AddMember) (*model.Member, error) {
+	if err := a.check(ctx, ledgerID, model.RoleAdmin, "", "invite members"); err != nil {
+		return nil, err
+	}
+	return a.service.AddMember(ctx, ledgerID, member)
+}
+
+func (a *accessControl) UpdateMemberRole(ctx context.Context, ledgerID, userID int, role string) error {
+	if err := a.check(ctx, ledgerID, model.RoleAdmin, "", "change roles of members"); err != nil {
+		return err
+	}
+	return a.service.UpdateMemberRole(ctx, ledgerID, userID, role)
+}
+
+func (a *accessControl) RemoveMember(ctx context.Context, ledgerID, userID int) error {
+	if err := a.check(ctx, ledgerID, model.RoleAdmin, "", "remove members"); err != nil {
+		return err
+	}
+	return a.service.RemoveMember(ctx, ledgerID, userID)
+}
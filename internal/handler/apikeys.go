@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// CreateAPIKey godoc
+//
+//	@Summary		Create an API key
+//	@Description	Issues long-lived key for scripts and integrations, sent in Authorization header as "Bearer <key>". Scopes are items:read, items:write, analytics:read and export. The key is returned only once, only its hash is stored
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		dto.CreateAPIKey		true	"Name, scopes and optional expiry"
+//	@Success		201		{object}	model.CreatedAPIKey		"Created key"
+//	@Failure		400		{object}	dto.ErrorResponse		"Malformed payload"
+//	@Failure		422		{object}	dto.ErrorResponse		"Invalid payload"
+//	@Failure		401		{object}	dto.ErrorResponse		"Missing or invalid token"
+//	@Failure		403		{object}	dto.ErrorResponse		"API key can not create keys"
+//	@Failure		500		{object}	dto.ErrorResponse		"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/auth/keys [post]
+func (h *Handler) CreateAPIKey(c *ginext.Context) {
+	var createKey dto.CreateAPIKey
+	if !bindPayload(c, &createKey) {
+		return
+	}
+
+	if createKey.ExpiresAt != nil && !createKey.ExpiresAt.After(time.Now()) {
+		respondError(c, validationError(fmt.Errorf("expires_at must be in the future")))
+		return
+	}
+
+	key, err := h.service.CreateAPIKey(h.requestContext(c), currentUser(c).ID, createKey)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled POST request and created api key " + key.Prefix)
+	c.JSON(http.StatusCreated, key)
+}
+
+// GetAPIKeys godoc
+//
+//	@Summary		Get API keys
+//	@Description	Returns API keys of current user with their scopes, expiry and time of last use. Keys themselves are not returned
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200		{array}		model.APIKey		"API keys of user"
+//	@Failure		401		{object}	dto.ErrorResponse	"Missing or invalid token"
+//	@Failure		403		{object}	dto.ErrorResponse	"API key can not list keys"
+//	@Failure		500		{object}	dto.ErrorResponse	"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/auth/keys [get]
+func (h *Handler) GetAPIKeys(c *ginext.Context) {
+	keys, err := h.service.GetAPIKeys(h.requestContext(c), currentUser(c).ID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if keys == nil {
+		keys = []model.APIKey{}
+	}
+
+	zlog.Logger.Info().Msg("successfully handled GET request and returned api keys")
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey godoc
+//
+//	@Summary		Revoke an API key
+//	@Description	Deletes API key of current user, requests with it are rejected from now on
+//	@Tags			auth
+//	@Produce		json
+//	@Param			id	path		int					true	"API key ID"
+//	@Success		200	{object}	map[string]string	"Success message"
+//	@Failure		400	{object}	dto.ErrorResponse	"Invalid ID"
+//	@Failure		401	{object}	dto.ErrorResponse	"Missing or invalid token"
+//	@Failure		403	{object}	dto.ErrorResponse	"API key can not revoke keys"
+//	@Failure		404	{object}	dto.ErrorResponse	"API key not found"
+//	@Failure		500	{object}	dto.ErrorResponse	"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/auth/keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(c *ginext.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, errInvalidID)
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(h.requestContext(c), currentUser(c).ID, id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled DELETE request and revoked api key")
+	c.JSON(http.StatusOK, ginext.H{"status": "successfully revoked api key"})
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// attachmentWriter streams file to the client, sending headers right before
+// the first write. Until then nothing is sent, so errors occurring before any
+// data is produced can still be reported with a proper status.
+type attachmentWriter struct {
+	c           *ginext.Context
+	fileName    string
+	contentType string
+	started     bool
+}
+
+func newAttachmentWriter(c *ginext.Context, fileName, contentType string) *attachmentWriter {
+	return &attachmentWriter{c: c, fileName: fileName, contentType: contentType}
+}
+
+func (w *attachmentWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": w.fileName}))
+		w.c.Status(http.StatusOK)
+	}
+
+	return w.c.Writer.Write(p)
+}
+
+// fail reports err to the client unless the file is partially sent already.
+// Then the status can not be changed anymore, so the connection is dropped
+// for the client not to take the truncated file for a complete one.
+func (w *attachmentWriter) fail(err error) {
+	if w.started {
+		zlog.Logger.Error().Msg("could not finish sending " + w.fileName + ": " + err.Error())
+		panic(http.ErrAbortHandler)
+	}
+
+	respondError(w.c, err)
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// GetItemHistory godoc
+//
+//	@Summary		Get history of an item
+//	@Description	Returns every recorded create, update, delete and restore of item with actor, time and item before and after the change, the oldest first. History is kept after item is deleted
+//	@Tags			audit
+//	@Produce		json
+//	@Param			id			path		int					true	"Item ID"
+//	@Success		200			{array}		model.AuditEntry	"Changes of item"
+//	@Failure		400			{object}	dto.ErrorResponse	"Invalid ID"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int					false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/items/{id}/history [get]
+func (h *Handler) GetItemHistory(c *ginext.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, errInvalidID)
+		return
+	}
+
+	entries, err := h.service.GetItemHistory(h.requestContext(c), currentLedgerID(c), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if entries == nil {
+		entries = []model.AuditEntry{}
+	}
+
+	zlog.Logger.Info().Msg("successfully handled GET request and returned item history")
+	c.JSON(http.StatusOK, entries)
+}
+
+// GetAudit godoc
+//
+//	@Summary		Get audit log
+//	@Description	Returns changes of items of ledger, the newest first, page by page. Pass next_cursor of response as cursor to get the next page
+//	@Tags			audit
+//	@Produce		json
+//	@Param			item_id		query		int					false	"Only changes of item"
+//	@Param			user_id		query		int					false	"Only changes made by user"
+//	@Param			operation	query		string				false	"Only operation: create, update, delete or restore"
+//	@Param			from		query		string				false	"Changes made at or after, YYYY-MM-DD or RFC 3339"
+//	@Param			to			query		string				false	"Changes made at or before, YYYY-MM-DD or RFC 3339"
+//	@Param			limit		query		int					false	"Page size, 50 by default, at most 500"
+//	@Param			cursor		query		string				false	"Cursor of page"
+//	@Success		200			{object}	model.AuditPage		"Page of audit log"
+//	@Failure		400			{object}	dto.ErrorResponse	"Invalid query parameters"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int					false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/audit [get]
+func (h *Handler) GetAudit(c *ginext.Context) {
+	params, err := parseAuditParams(c)
+	if err != nil {
+		respondError(c, invalidQuery(err))
+		return
+	}
+
+	page, err := h.service.GetAudit(h.requestContext(c), params)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if page.Entries == nil {
+		page.Entries = []model.AuditEntry{}
+	}
+
+	zlog.Logger.Info().Msg("successfully handled GET request and returned audit log")
+	c.JSON(http.StatusOK, page)
+}
+
+// parseAuditParams reads filters and pagination of audit log of the current
+// ledger.
+func parseAuditParams(c *ginext.Context) (dto.AuditParams, error) {
+	params := dto.AuditParams{
+		LedgerID:  currentLedgerID(c),
+		Operation: c.Query("operation"),
+		Limit:     defaultPageLimit,
+	}
+
+	switch params.Operation {
+	case "", model.AuditCreate, model.AuditUpdate, model.AuditDelete, model.AuditRestore:
+	default:
+		return dto.AuditParams{}, fmt.Errorf("invalid operation, must be one of 'create', 'update', 'delete', 'restore'")
+	}
+
+	for name, dest := range map[string]*int{"item_id": &params.ItemID, "user_id": &params.UserID, "limit": &params.Limit} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return dto.AuditParams{}, fmt.Errorf("invalid %s, must be positive integer", name)
+		}
+		*dest = n
+	}
+	if params.Limit > maxPageLimit {
+		return dto.AuditParams{}, fmt.Errorf("invalid limit, must be between 1 and %d", maxPageLimit)
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id <= 0 {
+			return dto.AuditParams{}, fmt.Errorf("invalid cursor")
+		}
+		params.BeforeID = id
+	}
+
+	var err error
+	if params.From, err = parseTimestamp(c.Query("from"), false); err != nil {
+		return dto.AuditParams{}, err
+	}
+	if params.To, err = parseTimestamp(c.Query("to"), true); err != nil {
+		return dto.AuditParams{}, err
+	}
+	if params.From != nil && params.To != nil && params.From.After(*params.To) {
+		return dto.AuditParams{}, fmt.Errorf("from must not be after to")
+	}
+
+	return params, nil
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+var (
+	errMissingToken   = model.NewError(model.KindUnauthorized, "missing_token", "authorization header is missing")
+	errMalformedToken = model.NewError(model.KindUnauthorized, "invalid_token", "authorization header must be Bearer token")
+)
+
+const (
+	// userKey is the gin context key authenticated user is stored under.
+	userKey = "user"
+	// apiKeyKey is the gin context key API key of request is stored under.
+	apiKeyKey = "api_key"
+)
+
+// Authenticate is a middleware letting through only requests with valid
+// session token or API key in "Authorization: Bearer <token>" header. User
+// the token was issued to is available to handlers via currentUser.
+func (h *Handler) Authenticate(c *ginext.Context) {
+	user, apiKey, err := h.authenticate(c)
+	if err == nil && user == nil {
+		err = errMissingToken
+	}
+	if err != nil {
+		c.Header("WWW-Authenticate", `Bearer realm="sales-tracker"`)
+		respondError(c, err)
+		return
+	}
+
+	setUser(c, user, apiKey)
+	c.Next()
+}
+
+// authenticate returns user of bearer token along with API key if the token
+// is one, nil user and error if request has no token at all.
+func (h *Handler) authenticate(c *ginext.Context) (*model.User, *model.APIKey, error) {
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		return nil, nil, nil
+	}
+
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, nil, errMalformedToken
+	}
+
+	if strings.HasPrefix(token, model.APIKeyPrefix) {
+		return h.service.AuthenticateAPIKey(h.ctx, token)
+	}
+
+	user, err := h.service.Authenticate(token)
+	return user, nil, err
+}
+
+func setUser(c *ginext.Context, user *model.User, apiKey *model.APIKey) {
+	c.Set(userKey, user)
+	if apiKey != nil {
+		c.Set(apiKeyKey, apiKey)
+	}
+}
+
+// currentUser returns user set by Authenticate middleware.
+func currentUser(c *ginext.Context) *model.User {
+	user, _ := c.Get(userKey)
+	u, _ := user.(*model.User)
+	return u
+}
+
+// currentActor returns who makes changes in request for audit log, zero
+// actor if request is not authenticated.
+func currentActor(c *ginext.Context) model.Actor {
+	var actor model.Actor
+	if user := currentUser(c); user != nil {
+		actor.UserID = user.ID
+	}
+	if apiKey, ok := c.Get(apiKeyKey); ok {
+		actor.APIKeyID = &apiKey.(*model.APIKey).ID
+	}
+
+	return actor
+}
+
+// Login godoc
+//
+//	@Summary		Log in
+//	@Description	Checks login and password and issues signed token to be sent in Authorization header as "Bearer <token>"
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		dto.Credentials		true	"Login and password"
+//	@Success		200		{object}	model.Token			"Issued token"
+//	@Failure		400		{object}	dto.ErrorResponse	"Malformed payload"
+//	@Failure		422		{object}	dto.ErrorResponse	"Invalid payload"
+//	@Failure		401		{object}	dto.ErrorResponse	"Invalid login or password"
+//	@Failure		500		{object}	dto.ErrorResponse	"Internal server error"
+//	@Router			/auth/login [post]
+func (h *Handler) Login(c *ginext.Context) {
+	credentials, ok := bindCredentials(c)
+	if !ok {
+		return
+	}
+
+	token, err := h.service.Login(h.requestContext(c), credentials)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled POST request and logged in " + credentials.Login)
+	c.JSON(http.StatusOK, token)
+}
+
+// Register godoc
+//
+//	@Summary		Register a user
+//	@Description	Creates user account. Without token only the first account can be created, it becomes admin. Afterwards users are registered by admins
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		dto.Credentials		true	"Login and password"
+//	@Success		201		{object}	model.User			"Created user"
+//	@Failure		400		{object}	dto.ErrorResponse	"Malformed payload"
+//	@Failure		422		{object}	dto.ErrorResponse	"Invalid payload"
+//	@Failure		401		{object}	dto.ErrorResponse	"Invalid token"
+//	@Failure		403		{object}	dto.ErrorResponse	"Registration requires admin"
+//	@Failure		409		{object}	dto.ErrorResponse	"Login is taken"
+//	@Failure		500		{object}	dto.ErrorResponse	"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/auth/register [post]
+func (h *Handler) Register(c *ginext.Context) {
+	registeredBy, apiKey, err := h.authenticate(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if registeredBy != nil {
+		setUser(c, registeredBy, apiKey)
+	}
+
+	credentials, ok := bindCredentials(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.Register(h.requestContext(c), credentials, registeredBy)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled POST request and registered user " + user.Login)
+	c.JSON(http.StatusCreated, user)
+}
+
+// GetCurrentUser godoc
+//
+//	@Summary		Get current user
+//	@Description	Returns user the token was issued to
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200		{object}	model.User			"Current user"
+//	@Failure		401		{object}	dto.ErrorResponse	"Missing or invalid token"
+//	@Security		BearerAuth
+//	@Router			/auth/me [get]
+func (h *Handler) GetCurrentUser(c *ginext.Context) {
+	c.JSON(http.StatusOK, currentUser(c))
+}
+
+func bindCredentials(c *ginext.Context) (dto.Credentials, bool) {
+	var credentials dto.Credentials
+	if !bindPayload(c, &credentials) {
+		return dto.Credentials{}, false
+	}
+
+	return credentials, true
+}
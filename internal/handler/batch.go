@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/Komilov31/sales-tracker/internal/repository"
+	validate "github.com/Komilov31/sales-tracker/internal/validator"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// maxBatchSize limits number of operations in a single batch.
+const maxBatchSize = 1000
+
+// ExecuteBatch godoc
+//
+//	@Summary		Execute batch of item operations
+//	@Description	Runs create, update and delete operations in a single transaction: either all of them are applied or none. Results are reported per operation in request order.
+//	@Tags			items
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		dto.Batch			true	"Operations"
+//	@Success		200		{object}	dto.BatchResponse	"Results of all operations"
+//	@Failure		400		{object}	dto.ErrorResponse	"Malformed payload"
+//	@Failure		404		{object}	dto.BatchResponse	"Item of operation not found, nothing applied"
+//	@Failure		422		{object}	dto.BatchResponse	"Invalid operation, nothing applied"
+//	@Failure		403		{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		412		{object}	dto.BatchResponse	"Item was changed since version of operation, nothing applied"
+//	@Failure		500		{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/items/batch [post]
+func (h *Handler) ExecuteBatch(c *ginext.Context) {
+	var batch dto.Batch
+	if err := c.BindJSON(&batch); err != nil {
+		respondError(c, fmt.Errorf("%w: %v", errInvalidPayload, err))
+		return
+	}
+
+	if len(batch.Operations) == 0 || len(batch.Operations) > maxBatchSize {
+		respondError(c, validationError(fmt.Errorf("batch must have from 1 to %d operations", maxBatchSize)))
+		return
+	}
+
+	if results, ok := validateBatch(batch.Operations); !ok {
+		zlog.Logger.Error().Msg("invalid batch operations")
+		c.JSON(http.StatusUnprocessableEntity, dto.BatchResponse{Error: "invalid operations", Code: "validation_failed", Results: results})
+		return
+	}
+
+	results, err := h.service.ExecuteBatch(h.requestContext(c), currentActor(c), currentLedgerID(c), batch.Operations)
+	if err != nil {
+		// Failed operation is reported along with the rest unless the
+		// failure is internal.
+		var batchErr *repository.BatchError
+		status, response := errorResponse(err)
+		if !errors.As(err, &batchErr) || status == http.StatusInternalServerError {
+			respondError(c, err)
+			return
+		}
+
+		zlog.Logger.Error().Msg("could not execute batch: " + err.Error())
+		c.JSON(status, dto.BatchResponse{
+			Error:   response.Error,
+			Code:    response.Code,
+			Results: failedBatchResults(batch.Operations, batchErr),
+		})
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled POST request and executed batch")
+	c.JSON(http.StatusOK, dto.BatchResponse{Results: results})
+}
+
+// validateBatch validates every operation and reports which of them are
+// invalid, the rest are reported as skipped.
+func validateBatch(operations []dto.BatchOperation) ([]model.BatchResult, bool) {
+	results := make([]model.BatchResult, len(operations))
+	valid := true
+
+	for i, operation := range operations {
+		results[i] = model.BatchResult{Index: i, Op: operation.Op, ID: operation.ID, Status: model.BatchSkipped}
+		if err := validate.Validator.Struct(operation); err != nil {
+			results[i].Status = model.BatchInvalid
+			results[i].Fields = validate.Fields(err)
+			results[i].Error = validate.Message(results[i].Fields)
+			valid = false
+		}
+	}
+
+	return results, valid
+}
+
+// failedBatchResults reports operation which failed the batch along with
+// ones rolled back because of it and ones which were not executed at all.
+func failedBatchResults(operations []dto.BatchOperation, batchErr *repository.BatchError) []model.BatchResult {
+	results := make([]model.BatchResult, len(operations))
+
+	for i, operation := range operations {
+		results[i] = model.BatchResult{Index: i, Op: operation.Op, ID: operation.ID}
+		switch {
+		case i < batchErr.Index:
+			results[i].Status = model.BatchRolledBack
+		case i == batchErr.Index:
+			results[i].Status = model.BatchFailed
+			results[i].Error = batchErr.Err.Error()
+			var typed *model.Error
+			if errors.As(batchErr.Err, &typed) {
+				results[i].Fields = typed.Fields
+			}
+		default:
+			results[i].Status = model.BatchSkipped
+		}
+	}
+
+	return results
+}
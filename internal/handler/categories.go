@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+var errInvalidReplacement = model.NewError(model.KindInvalid, "invalid_query", "replace_with must be id of category")
+
+// GetCategories godoc
+//
+//	@Summary		Get categories
+//	@Description	Returns categories of ledger with their parents, types and aliases, sorted by name
+//	@Tags			categories
+//	@Produce		json
+//	@Success		200			{array}		model.Category		"Categories of ledger"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int					false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/categories [get]
+func (h *Handler) GetCategories(c *ginext.Context) {
+	categories, err := h.service.GetCategories(h.requestContext(c), currentLedgerID(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if categories == nil {
+		categories = []model.Category{}
+	}
+
+	zlog.Logger.Info().Msg("successfully handled GET request and returned categories")
+	c.JSON(http.StatusOK, categories)
+}
+
+// CreateCategory godoc
+//
+//	@Summary		Create a category
+//	@Description	Creates category of ledger, a subcategory if parent_id is given. Once ledger has categories, items may only use them or their aliases
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//	@Param			body		body		dto.CreateCategory	true	"Category"
+//	@Success		201			{object}	model.Category		"Created category"
+//	@Failure		400			{object}	dto.ErrorResponse	"Malformed payload"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		409			{object}	dto.ErrorResponse	"Name or alias is taken"
+//	@Failure		422			{object}	dto.ErrorResponse	"Invalid payload, parent or type"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int					false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/categories [post]
+func (h *Handler) CreateCategory(c *ginext.Context) {
+	var category dto.CreateCategory
+	if !bindPayload(c, &category) {
+		return
+	}
+
+	created, err := h.service.CreateCategory(h.requestContext(c), currentLedgerID(c), category)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled POST request and created category")
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateCategory godoc
+//
+//	@Summary		Update a category
+//	@Description	Replaces name, parent, type and aliases of category. Items of renamed category are renamed too
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int					true	"Category ID"
+//	@Param			body		body		dto.UpdateCategory	true	"Category"
+//	@Success		200			{object}	model.Category		"Updated category"
+//	@Failure		400			{object}	dto.ErrorResponse	"Invalid ID or malformed payload"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		404			{object}	dto.ErrorResponse	"Category not found"
+//	@Failure		409			{object}	dto.ErrorResponse	"Name or alias is taken, or items do not fit the type"
+//	@Failure		422			{object}	dto.ErrorResponse	"Invalid payload, parent or type"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int					false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/categories/{id} [put]
+func (h *Handler) UpdateCategory(c *ginext.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, errInvalidID)
+		return
+	}
+
+	var category dto.UpdateCategory
+	if !bindPayload(c, &category) {
+		return
+	}
+
+	updated, err := h.service.UpdateCategory(h.requestContext(c), currentActor(c), currentLedgerID(c), id, category)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled PUT request and updated category")
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteCategory godoc
+//
+//	@Summary		Delete a category
+//	@Description	Deletes category without subcategories. With replace_with its items are moved to the replacement, which takes name and aliases of deleted category as aliases; otherwise category must have no items
+//	@Tags			categories
+//	@Produce		json
+//	@Param			id				path		int					true	"Category ID"
+//	@Param			replace_with	query		int					false	"ID of category to move items to"
+//	@Success		200				{object}	map[string]string	"Success message"
+//	@Failure		400				{object}	dto.ErrorResponse	"Invalid ID or replacement"
+//	@Failure		403				{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		404				{object}	dto.ErrorResponse	"Category or replacement not found"
+//	@Failure		409				{object}	dto.ErrorResponse	"Category has items or subcategories, or items do not fit replacement"
+//	@Failure		500				{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID		header		int					false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/categories/{id} [delete]
+func (h *Handler) DeleteCategory(c *ginext.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, errInvalidID)
+		return
+	}
+
+	var replacementID int
+	if value := c.Query("replace_with"); value != "" {
+		replacementID, err = strconv.Atoi(value)
+		if err != nil || replacementID <= 0 {
+			respondError(c, errInvalidReplacement)
+			return
+		}
+	}
+
+	if err := h.service.DeleteCategory(h.requestContext(c), currentActor(c), currentLedgerID(c), id, replacementID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled DELETE request and deleted category")
+	c.JSON(http.StatusOK, ginext.H{"status": "successfully deleted category"})
+}
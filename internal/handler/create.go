@@ -4,8 +4,6 @@ import (
 	"net/http"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
-	validate "github.com/Komilov31/sales-tracker/internal/validator"
-	"github.com/go-playground/validator/v10"
 	"github.com/wb-go/wbf/ginext"
 	"github.com/wb-go/wbf/zlog"
 )
@@ -13,36 +11,31 @@ import (
 // CreateItem godoc
 //
 //	@Summary		Create a new item
-//	@Description	Creates a new expense or income entry in the sales tracker
+//	@Description	Creates a new expense or income entry in the sales tracker. Item without category gets the one of the first rule matching it
 //	@Tags			items
 //	@Accept			json
 //	@Produce		json
 //	@Param			body	body		dto.CreateItem	true	"Item to create"
 //	@Success		200		{object}	dto.ItemWithoutAggregated	"Created item"
-//	@Failure		400		{object}	map[string]string			"Invalid payload"
-//	@Failure		500		{object}	map[string]string			"Internal server error"
+//	@Failure		400		{object}	dto.ErrorResponse			"Malformed payload"
+//	@Failure		422		{object}	dto.ErrorResponse			"Invalid payload, amount precision or no rule matches item without category"
+//	@Failure		403		{object}	dto.ErrorResponse			"Role does not allow the action"
+//	@Failure		500		{object}	dto.ErrorResponse			"Internal server error"
+//	@Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
 //	@Router			/items [post]
 func (h *Handler) CreateItem(c *ginext.Context) {
 	var createItem dto.CreateItem
-	if err := c.BindJSON(&createItem); err != nil {
-		zlog.Logger.Error().Msg("could not unmarshal json: " + err.Error())
-		c.JSON(http.StatusBadRequest, ginext.H{"error": "invalid payload"})
+	if !bindPayload(c, &createItem) {
 		return
 	}
 
-	if err := validate.Validator.Struct(createItem); err != nil {
-		errors := err.(validator.ValidationErrors)
-		c.JSON(http.StatusBadRequest, ginext.H{"error": "invalid payload: " + errors.Error()})
-		return
-	}
-
-	item, err := h.service.CreateItem(h.ctx, createItem)
+	item, err := h.service.CreateItem(h.requestContext(c), currentActor(c), currentLedgerID(c), createItem)
 	if err != nil {
-		zlog.Logger.Error().Msg(err.Error())
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": "could not create item"})
+		respondError(c, err)
 		return
 	}
 
 	zlog.Logger.Info().Msg("successfully handled POST request and created item")
-	c.JSON(http.StatusOK, convertWithoutAggregated(item))
+	c.JSON(http.StatusOK, dto.ConvertWithoutAggregated(item))
 }
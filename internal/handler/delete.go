@@ -1,11 +1,10 @@
 package handler
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
 
-	"github.com/Komilov31/sales-tracker/internal/repository"
+	_ "github.com/Komilov31/sales-tracker/internal/dto"
 	"github.com/wb-go/wbf/ginext"
 	"github.com/wb-go/wbf/zlog"
 )
@@ -13,31 +12,37 @@ import (
 // DeleteItem godoc
 //
 //	@Summary		Delete an item
-//	@Description	Remove an item by its ID
+//	@Description	Move an item to trash by its ID provided it was not changed since its ETag was read, it can be restored until retention purges it
 //	@Tags			items
 //	@Produce		json
 //	@Param			id	path		int		true	"Item ID"
+//	@Param			If-Match	header	string	true	"ETag of item, * to delete any version"
 //	@Success		200	{object}	map[string]string	"Success message"
-//	@Failure		400	{object}	map[string]string	"Item not found or invalid ID"
-//	@Failure		500	{object}	map[string]string	"Internal server error"
+//	@Failure		400	{object}	dto.ErrorResponse	"Invalid ID or malformed If-Match header"
+//	@Failure		404	{object}	dto.ErrorResponse	"Item not found"
+//	@Failure		403	{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		412	{object}	dto.ErrorResponse	"Item was changed since its ETag was read"
+//	@Failure		428	{object}	dto.ErrorResponse	"If-Match header is missing"
+//	@Failure		500	{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
 //	@Router			/items/{id} [delete]
 func (h *Handler) DeleteItem(c *ginext.Context) {
 	itemID := c.Param("id")
 	id, err := strconv.Atoi(itemID)
 	if err != nil {
-		zlog.Logger.Error().Msg("invalid id: " + err.Error())
-		c.JSON(http.StatusBadRequest, ginext.H{"error": "invalid id"})
+		respondError(c, errInvalidID)
 		return
 	}
 
-	if err := h.service.DeleteItem(h.ctx, id); err != nil {
-		if errors.Is(err, repository.ErrNoSuchItem) {
-			zlog.Logger.Error().Msg(err.Error())
-			c.JSON(http.StatusBadRequest, ginext.H{"error": err.Error()})
-			return
-		}
-		zlog.Logger.Error().Msg(err.Error())
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": err.Error()})
+	version, err := parseIfMatch(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if err := h.service.DeleteItem(h.requestContext(c), currentActor(c), currentLedgerID(c), id, version); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	validate "github.com/Komilov31/sales-tracker/internal/validator"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// internalErrorCode is code of errors of unknown kind.
+const internalErrorCode = "internal_error"
+
+var (
+	errInvalidID      = model.NewError(model.KindInvalid, "invalid_id", "invalid id")
+	errInvalidPayload = model.NewError(model.KindInvalid, "invalid_payload", "invalid payload")
+)
+
+// errorStatuses are statuses responses to errors of every kind are sent
+// with.
+var errorStatuses = map[model.ErrorKind]int{
+	model.KindInternal:             http.StatusInternalServerError,
+	model.KindInvalid:              http.StatusBadRequest,
+	model.KindValidation:           http.StatusUnprocessableEntity,
+	model.KindUnauthorized:         http.StatusUnauthorized,
+	model.KindForbidden:            http.StatusForbidden,
+	model.KindNotFound:             http.StatusNotFound,
+	model.KindNotAcceptable:        http.StatusNotAcceptable,
+	model.KindConflict:             http.StatusConflict,
+	model.KindPreconditionFailed:   http.StatusPreconditionFailed,
+	model.KindPreconditionRequired: http.StatusPreconditionRequired,
+}
+
+// errorResponse returns status and body of response to err. Message of
+// internal errors is not sent, as it may reveal details of storage.
+func errorResponse(err error) (int, dto.ErrorResponse) {
+	var typed *model.Error
+	if !errors.As(err, &typed) || typed.Kind == model.KindInternal {
+		return http.StatusInternalServerError, dto.ErrorResponse{Error: "internal server error", Code: internalErrorCode}
+	}
+
+	return errorStatuses[typed.Kind], dto.ErrorResponse{Error: err.Error(), Code: typed.Code, Fields: typed.Fields}
+}
+
+// respondError logs err and aborts request with response to it.
+func respondError(c *ginext.Context, err error) {
+	zlog.Logger.Error().Msg(c.Request.Method + " " + c.FullPath() + ": " + err.Error())
+
+	status, response := errorResponse(err)
+	c.AbortWithStatusJSON(status, response)
+}
+
+// invalidQuery marks failure to parse query parameters as malformed request.
+func invalidQuery(err error) error {
+	return model.NewError(model.KindInvalid, "invalid_query", err.Error())
+}
+
+// validationError reports payload breaking validation rules, along with the
+// rule every field breaks if err comes from validator.
+func validationError(err error) error {
+	fields := validate.Fields(err)
+	if len(fields) == 0 {
+		return model.NewValidationError("validation_failed", "invalid payload: "+err.Error(), nil)
+	}
+
+	return model.NewValidationError("validation_failed", "invalid payload: "+validate.Message(fields), fields)
+}
+
+// bindPayload reads JSON body of request into payload and validates it. On
+// failure it responds with error and returns false.
+func bindPayload(c *ginext.Context, payload any) bool {
+	if err := c.BindJSON(payload); err != nil {
+		respondError(c, fmt.Errorf("%w: %v", errInvalidPayload, err))
+		return false
+	}
+
+	if err := validate.Validator.Struct(payload); err != nil {
+		respondError(c, validationError(err))
+		return false
+	}
+
+	return true
+}
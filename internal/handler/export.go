@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"fmt"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/Komilov31/sales-tracker/internal/service"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+const jsonMediaType = "application/json"
+
+// negotiateExport picks format of items or analytics response: the one
+// named in format query parameter or, without it, the most preferred one
+// of Accept header. Nil format stands for JSON, the default.
+func (h *Handler) negotiateExport(c *ginext.Context) (*model.ExportFormat, error) {
+	name := c.Query("format")
+	accept := c.GetHeader("Accept")
+	if name == "json" || (name == "" && accept == "") {
+		return nil, nil
+	}
+
+	formats := h.service.Exporters()
+	if name != "" {
+		names := []string{"json"}
+		for _, format := range formats {
+			if format.Name == name {
+				return &format, nil
+			}
+			names = append(names, format.Name)
+		}
+		return nil, invalidQuery(fmt.Errorf("invalid format, must be one of %s", strings.Join(names, ", ")))
+	}
+
+	offers := []string{jsonMediaType}
+	for _, format := range formats {
+		offers = append(offers, mediaType(format.ContentType))
+	}
+
+	offer := negotiate(accept, offers)
+	switch offer {
+	case -1:
+		message := "none of accepted media types is supported, must be one of " + strings.Join(offers, ", ")
+		return nil, model.NewError(model.KindNotAcceptable, "not_acceptable", message)
+	case 0:
+		return nil, nil
+	default:
+		return &formats[offer-1], nil
+	}
+}
+
+// negotiate returns index of offered media type most preferred by Accept
+// header, or -1 if none is acceptable. Preference is given by quality
+// values, wildcards match any offer and the first offer wins a tie.
+func negotiate(accept string, offers []string) int {
+	type accepted struct {
+		mediaType string
+		quality   float64
+	}
+
+	var ranges []accepted
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			ranges = append(ranges, accepted{mediaType: mediaType, quality: quality})
+		}
+	}
+
+	slices.SortStableFunc(ranges, func(a, b accepted) int {
+		switch {
+		case a.quality > b.quality:
+			return -1
+		case a.quality < b.quality:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	for _, r := range ranges {
+		for i, offer := range offers {
+			if r.mediaType == "*/*" || r.mediaType == offer ||
+				(strings.HasSuffix(r.mediaType, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(r.mediaType, "*"))) {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func mediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+
+	return mediaType
+}
+
+// exportItems sends items matching params as file in format.
+func (h *Handler) exportItems(c *ginext.Context, format *model.ExportFormat, params dto.GetItemsParams) {
+	options, err := parseExportOptions(c)
+	if err != nil {
+		respondError(c, invalidQuery(err))
+		return
+	}
+
+	w := newAttachmentWriter(c, "filtered_data"+format.Extension, format.ContentType)
+	if err := h.service.ExportItems(h.requestContext(c), w, format.Name, params, options); err != nil {
+		w.fail(err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfylly handled GET request and returned " + format.Name + " file with data")
+}
+
+// exportAggregated sends items within dates range with statistics as file
+// in format.
+func (h *Handler) exportAggregated(c *ginext.Context, format *model.ExportFormat, params dto.AnalyticsParams) {
+	options, err := parseExportOptions(c)
+	if err != nil {
+		respondError(c, invalidQuery(err))
+		return
+	}
+
+	w := newAttachmentWriter(c, "aggregated_data"+format.Extension, format.ContentType)
+	if err := h.service.ExportAggregated(h.requestContext(c), w, format.Name, params, options); err != nil {
+		w.fail(err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfylly handled GET request and returned " + format.Name + " file with data")
+}
+
+// exportFormat returns registered format with name.
+func (h *Handler) exportFormat(name string) *model.ExportFormat {
+	for _, format := range h.service.Exporters() {
+		if format.Name == name {
+			return &format
+		}
+	}
+
+	return nil
+}
+
+// exportAs serves routes dedicated to a single export format, kept for
+// clients which do not send format parameter or Accept header.
+func (h *Handler) exportAs(c *ginext.Context, name string, aggregated bool) {
+	var (
+		itemsParams     dto.GetItemsParams
+		analyticsParams dto.AnalyticsParams
+		err             error
+	)
+	if aggregated {
+		analyticsParams, err = parseAnalyticsParams(c)
+	} else {
+		itemsParams, err = parseGetParams(c)
+	}
+	if err != nil {
+		respondError(c, invalidQuery(err))
+		return
+	}
+
+	format := h.exportFormat(name)
+	if format == nil {
+		respondError(c, model.NewError(model.KindNotFound, "format_not_found", "format "+name+" is not supported"))
+		return
+	}
+
+	if aggregated {
+		h.exportAggregated(c, format, analyticsParams)
+		return
+	}
+	h.exportItems(c, format, itemsParams)
+}
+
+// parseDelimiter reads CSV delimiter, zero if it is not given.
+func parseDelimiter(c *ginext.Context) (rune, error) {
+	switch delimiter := c.Query("delimiter"); delimiter {
+	case "":
+		return 0, nil
+	case ",", ";", "|":
+		return rune(delimiter[0]), nil
+	case "tab", "\t":
+		return '\t', nil
+	default:
+		return 0, fmt.Errorf("invalid delimiter, must be one of ',', ';', '|', 'tab'")
+	}
+}
+
+// parseExportOptions reads CSV dialect of export. Column names may be given
+// both as a comma separated list and by repeating the parameter.
+func parseExportOptions(c *ginext.Context) (dto.ExportOptions, error) {
+	var options dto.ExportOptions
+
+	delimiter, err := parseDelimiter(c)
+	if err != nil {
+		return dto.ExportOptions{}, err
+	}
+	options.Delimiter = delimiter
+
+	if value := c.Query("bom"); value != "" {
+		bom, err := strconv.ParseBool(value)
+		if err != nil {
+			return dto.ExportOptions{}, fmt.Errorf("invalid bom, must be boolean")
+		}
+		options.BOM = bom
+	}
+
+	options.DecimalSeparator = c.Query("decimal_separator")
+	if options.DecimalSeparator != "" && options.DecimalSeparator != "." && options.DecimalSeparator != "," {
+		return dto.ExportOptions{}, fmt.Errorf("invalid decimal_separator, must be one of '.', ','")
+	}
+
+	options.DateFormat = c.Query("date_format")
+	if options.DateFormat != "" && options.DateFormat != service.DateFormatISO && options.DateFormat != service.DateFormatRU {
+		return dto.ExportOptions{}, fmt.Errorf("invalid date_format, must be one of 'iso', 'ru'")
+	}
+
+	options.Language = c.Query("lang")
+	if options.Language != "" && options.Language != service.LanguageEN && options.Language != service.LanguageRU {
+		return dto.ExportOptions{}, fmt.Errorf("invalid lang, must be one of 'en', 'ru'")
+	}
+
+	for _, value := range c.QueryArray("columns") {
+		for column := range strings.SplitSeq(value, ",") {
+			if column = strings.TrimSpace(column); column != "" {
+				options.Columns = append(options.Columns, column)
+			}
+		}
+	}
+
+	return options, nil
+}
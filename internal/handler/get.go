@@ -1,77 +1,199 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
-	"os"
+	"strconv"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
-	_ "github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/Komilov31/sales-tracker/internal/model"
 	"github.com/wb-go/wbf/ginext"
 	"github.com/wb-go/wbf/zlog"
 )
 
+// errSummaryFormat is returned when analytics summary is requested as file.
+var errSummaryFormat = model.NewError(model.KindNotAcceptable, "not_acceptable", "summary view is available only as json")
+
 // GetAllItems godoc
 //
-//	@Summary		Get all items
-//	@Description	Retrieve a list of all items, optionally sorted by specified fields
+//	@Summary		Get items
+//	@Description	Retrieve a page of items, optionally filtered and sorted by specified fields. With a non-JSON format requested by format parameter or Accept header all matching items are exported as a file, limit and cursor are ignored then.
 //	@Tags			items
 //	@Produce		json
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 //
 // @Param sort_by query []string false "Sort fields (e.g., date,amount)"
+// @Param type query string false "Item type (доход or расход)"
+// @Param category query string false "Exact category"
+// @Param category_contains query string false "Case-insensitive category substring"
+// @Param currency query string false "Currency (ISO 4217)"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Param amount_min query number false "Minimal amount"
+// @Param amount_max query number false "Maximal amount"
+// @Param created_from query string false "Created at lower bound (YYYY-MM-DD or RFC 3339)"
+// @Param created_to query string false "Created at upper bound (YYYY-MM-DD or RFC 3339)"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param cursor query string false "Cursor of the page returned in next_cursor or prev_cursor"
+// @Param format query string false "Response format: json (default), csv, ndjson or xlsx; overrides Accept header"
+// @Param delimiter query string false "CSV delimiter: , (default), ;, | or tab"
+// @Param bom query bool false "Prepend UTF-8 BOM to CSV"
+// @Param decimal_separator query string false "CSV decimal separator: . (default) or ,"
+// @Param date_format query string false "CSV dates: iso (default) or ru (DD.MM.YYYY)"
+// @Param lang query string false "CSV header language: en (default) or ru"
+// @Param columns query []string false "CSV columns in output order (e.g., date,amount,category)"
 //
-//	@Success		200		{array}		dto.ItemWithoutAggregated	"List of items"
-//	@Failure		400		{object}	map[string]string			"Invalid query parameters"
-//	@Failure		500		{object}	map[string]string			"Internal server error"
+//	@Success		200		{object}	dto.ItemsPage		"Page of items"
+//	@Failure		400		{object}	dto.ErrorResponse			"Invalid query parameters"
+//	@Failure		406		{object}	dto.ErrorResponse			"None of accepted media types is supported"
+//	@Failure		403		{object}	dto.ErrorResponse			"Role does not allow the action"
+//	@Failure		500		{object}	dto.ErrorResponse			"Internal server error"
+//	@Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
 //	@Router			/items [get]
 func (h *Handler) GetAllItems(c *ginext.Context) {
-	sortBy := c.QueryArray("sort_by")
+	format, err := h.negotiateExport(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	getItemsParams, err := parseGetParams(c)
+	if err == nil && format == nil {
+		err = parsePagination(c, &getItemsParams)
+	}
+	if err != nil {
+		respondError(c, invalidQuery(err))
+		return
+	}
 
-	if err := validateGetParams(sortBy); err != nil {
-		zlog.Logger.Error().Msg("invalid query parameter: " + err.Error())
-		c.JSON(http.StatusBadRequest, ginext.H{"error": err.Error()})
+	if format != nil {
+		h.exportItems(c, format, getItemsParams)
 		return
 	}
 
-	getItemsParams := dto.GetItemsParams{SortBy: sortBy}
-	items, err := h.service.GetAllItems(h.ctx, getItemsParams)
+	h.respondItemsPage(c, getItemsParams)
+}
+
+// respondItemsPage responds with page of items matching params.
+func (h *Handler) respondItemsPage(c *ginext.Context, params dto.GetItemsParams) {
+	page, err := h.service.GetItemsPage(h.requestContext(c), params)
 	if err != nil {
-		zlog.Logger.Error().Msg("could not get items: " + err.Error())
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	zlog.Logger.Info().Msg("successfylly handled GET request and returned items")
-	c.JSON(http.StatusOK, itemsWithoutAggregated(items))
+	c.JSON(http.StatusOK, dto.ItemsPage{
+		Items:      itemsWithoutAggregated(page.Items),
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+		Total:      page.Total,
+	})
+}
+
+// GetItem godoc
+//
+//	@Summary		Get an item
+//	@Description	Retrieve an item by its ID, ETag header carries its version to be sent in If-Match header of changes
+//	@Tags			items
+//	@Produce		json
+//	@Param			id	path		int		true	"Item ID"
+//	@Success		200	{object}	dto.ItemWithoutAggregated	"Item"
+//	@Header			200	{string}	ETag						"Version of item"
+//	@Failure		400	{object}	dto.ErrorResponse			"Invalid ID"
+//	@Failure		403	{object}	dto.ErrorResponse			"Role does not allow the action"
+//	@Failure		404	{object}	dto.ErrorResponse			"Item not found"
+//	@Failure		500	{object}	dto.ErrorResponse			"Internal server error"
+//	@Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/items/{id} [get]
+func (h *Handler) GetItem(c *ginext.Context) {
+	itemID := c.Param("id")
+	id, err := strconv.Atoi(itemID)
+	if err != nil {
+		respondError(c, errInvalidID)
+		return
+	}
+
+	item, err := h.service.GetItem(h.requestContext(c), currentLedgerID(c), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfylly handled GET request and returned item")
+	c.Header("ETag", itemETag(item.Version))
+	c.JSON(http.StatusOK, dto.ConvertWithoutAggregated(item))
 }
 
 // GetAggregated godoc
 //
 //	@Summary		Get aggregated analytics
-//	@Description	Retrieve aggregated statistics for items within a date range
+//	@Description	Retrieve aggregated statistics for items within a date range. By default every item is returned with statistics attached, view=summary returns statistics once. Items view can be exported as a file in a format requested by format parameter or Accept header.
 //	@Tags			analytics
 //	@Produce		json
-//	@Param			from	query		string	false	"Start date (YYYY-MM-DD)"
-//	@Param			to		query		string	false	"End date (YYYY-MM-DD)"
-//	@Success		200		{array}		model.Item	"Aggregated items"
-//	@Failure		400		{object}	map[string]string	"Invalid date parameters"
-//	@Failure		500		{object}	map[string]string	"Internal server error"
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			from			query		string	false	"Start date (YYYY-MM-DD)"
+//	@Param			to				query		string	false	"End date (YYYY-MM-DD)"
+//	@Param			view			query		string	false	"Response shape: items (default) or summary"
+//	@Param			include_items	query		bool	false	"Include items into summary view"
+//	@Param			currency		query		string	false	"Reporting currency (ISO 4217), amounts are converted into it"
+//	@Param			format			query		string	false	"Response format: json (default), csv, ndjson or xlsx; overrides Accept header"
+//	@Param			delimiter query string false "CSV delimiter: , (default), ;, | or tab"
+//	@Param			bom query bool false "Prepend UTF-8 BOM to CSV"
+//	@Param			decimal_separator query string false "CSV decimal separator: . (default) or ,"
+//	@Param			date_format query string false "CSV dates: iso (default) or ru (DD.MM.YYYY)"
+//	@Param			lang query string false "CSV header language: en (default) or ru"
+//	@Param			columns query []string false "CSV columns in output order (e.g., date,amount,category)"
+//	@Success		200				{array}		model.Item				"Aggregated items"
+//	@Success		200				{object}	dto.AnalyticsSummary	"Summary (view=summary)"
+//	@Failure		400				{object}	dto.ErrorResponse		"Invalid query parameters"
+//	@Failure		422				{object}	dto.ErrorResponse		"No exchange rate to convert amounts"
+//	@Failure		406				{object}	dto.ErrorResponse		"None of accepted media types is supported"
+//	@Failure		403				{object}	dto.ErrorResponse		"Role does not allow the action"
+//	@Failure		500				{object}	dto.ErrorResponse		"Internal server error"
+//	@Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
 //	@Router			/analytics [get]
 func (h *Handler) GetAggregated(c *ginext.Context) {
-	from := c.Query("from")
-	to := c.Query("to")
+	format, err := h.negotiateExport(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 
-	if from != "" && to != "" {
-		if err := validateDate(from, to); err != nil {
-			zlog.Logger.Error().Msg(err.Error())
-			c.JSON(http.StatusBadRequest, ginext.H{"error": err.Error()})
+	params, err := parseAnalyticsParams(c)
+	if err != nil {
+		respondError(c, invalidQuery(err))
+		return
+	}
+
+	switch c.DefaultQuery("view", "items") {
+	case "items":
+		if format != nil {
+			h.exportAggregated(c, format, params)
+			return
+		}
+	case "summary":
+		if format != nil {
+			respondError(c, errSummaryFormat)
 			return
 		}
+		h.getSummary(c, params)
+		return
+	default:
+		respondError(c, invalidQuery(fmt.Errorf("invalid view, must be one of 'items', 'summary'")))
+		return
 	}
 
-	items, err := h.service.GetAggregated(h.ctx, from, to)
+	items, err := h.service.GetAggregated(h.requestContext(c), params)
 	if err != nil {
-		zlog.Logger.Error().Msg(err.Error())
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -79,42 +201,90 @@ func (h *Handler) GetAggregated(c *ginext.Context) {
 	c.JSON(http.StatusOK, items)
 }
 
-// GetAggregatedCSV godoc
-//
-//	@Summary		Export aggregated analytics as CSV
-//	@Description	Download CSV file with aggregated statistics for a date range
-//	@Tags			analytics
-//	@Param			from	query		string	false	"Start date (YYYY-MM-DD)"
-//	@Param			to		query		string	false	"End date (YYYY-MM-DD)"
-//	@Success		200		{file}		application/octet-stream	"aggregated_data.csv"
-//	@Failure		400		{object}	map[string]string	"Invalid date parameters"
-//	@Failure		500		{object}	map[string]string	"Internal server error"
-//	@Router			/analytics/csv [get]
-func (h *Handler) GetAggregatedCSV(c *ginext.Context) {
-	from := c.Query("from")
-	to := c.Query("to")
-
-	if from != "" && to != "" {
-		if err := validateDate(from, to); err != nil {
-			zlog.Logger.Error().Msg(err.Error())
-			c.JSON(http.StatusBadRequest, ginext.H{"error": err.Error()})
+func (h *Handler) getSummary(c *ginext.Context, params dto.AnalyticsParams) {
+	includeItems := false
+	if value := c.Query("include_items"); value != "" {
+		var err error
+		if includeItems, err = strconv.ParseBool(value); err != nil {
+			respondError(c, invalidQuery(fmt.Errorf("invalid include_items, must be boolean")))
 			return
 		}
 	}
 
-	path, err := h.service.CSVAggregated(h.ctx, from, to)
+	summary, err := h.service.GetSummary(h.requestContext(c), params, includeItems)
 	if err != nil {
-		zlog.Logger.Error().Msg(err.Error())
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
-	zlog.Logger.Info().Msg("successfylly handled GET request and returned csv file with data")
-	c.FileAttachment(path, "aggregated_data.csv")
+	response := dto.AnalyticsSummary{Summary: summary.Aggregated}
+	if includeItems {
+		response.Items = itemsWithoutAggregated(summary.Items)
+	}
+
+	zlog.Logger.Info().Msg("successfylly handled GET request and returned analytics summary")
+	c.JSON(http.StatusOK, response)
+}
 
-	if err := os.Remove(path); err != nil {
-		zlog.Logger.Info().Msg("could not delete temporary csv file: " + err.Error())
+// GetGroupedAggregated godoc
+//
+//	@Summary		Get grouped analytics
+//	@Description	Retrieve statistics computed separately for every group of items within a date range
+//	@Tags			analytics
+//	@Produce		json
+//	@Param			from		query		string		false	"Start date (YYYY-MM-DD)"
+//	@Param			to			query		string		false	"End date (YYYY-MM-DD)"
+//	@Param			group_by	query		[]string	false	"Grouping (category, root_category, type, day, week, month, quarter, year)"
+//	@Param			currency	query		string		false	"Reporting currency (ISO 4217), amounts are converted into it"
+//	@Success		200			{array}		model.GroupedAggregated	"Statistics per group"
+//	@Failure		400			{object}	dto.ErrorResponse		"Invalid query parameters"
+//	@Failure		422			{object}	dto.ErrorResponse		"No exchange rate to convert amounts"
+//	@Failure		403			{object}	dto.ErrorResponse		"Role does not allow the action"
+//	@Failure		500			{object}	dto.ErrorResponse		"Internal server error"
+//	@Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/analytics/grouped [get]
+func (h *Handler) GetGroupedAggregated(c *ginext.Context) {
+	params, err := parseAnalyticsParams(c)
+	if err != nil {
+		respondError(c, invalidQuery(err))
+		return
 	}
+
+	groups, err := h.service.GetGroupedAggregated(h.requestContext(c), params)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfylly handled GET request and returned grouped aggregated data")
+	c.JSON(http.StatusOK, groups)
+}
+
+// GetAggregatedCSV godoc
+//
+//	@Summary		Export aggregated analytics as CSV
+//	@Description	Download CSV file with aggregated statistics for a date range
+//	@Tags			analytics
+//	@Param			from	query		string	false	"Start date (YYYY-MM-DD)"
+//	@Param			to		query		string	false	"End date (YYYY-MM-DD)"
+//	@Param			currency	query	string	false	"Reporting currency (ISO 4217), amounts are converted into it"
+//	@Param			delimiter query string false "CSV delimiter: , (default), ;, | or tab"
+//	@Param			bom query bool false "Prepend UTF-8 BOM to CSV"
+//	@Param			decimal_separator query string false "CSV decimal separator: . (default) or ,"
+//	@Param			date_format query string false "CSV dates: iso (default) or ru (DD.MM.YYYY)"
+//	@Param			lang query string false "CSV header language: en (default) or ru"
+//	@Param			columns query []string false "CSV columns in output order (e.g., date,amount,category)"
+//	@Success		200		{file}		application/octet-stream	"aggregated_data.csv"
+//	@Failure		400		{object}	dto.ErrorResponse	"Invalid date parameters"
+//	@Failure		422		{object}	dto.ErrorResponse	"No exchange rate to convert amounts"
+//	@Failure		403		{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		500		{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/analytics/csv [get]
+func (h *Handler) GetAggregatedCSV(c *ginext.Context) {
+	h.exportAs(c, "csv", true)
 }
 
 // GetFilteredCSV godoc
@@ -122,36 +292,82 @@ func (h *Handler) GetAggregatedCSV(c *ginext.Context) {
 //	@Summary		Export filtered items as CSV
 //	@Description	Download CSV file with filtered and sorted items
 //	@Tags			items
-//	@Produce		application/octet-stream
+//	@Produce		text/csv
 //
 // @Param sort_by query []string false "Sort fields (e.g., date,amount)"
+// @Param type query string false "Item type (доход or расход)"
+// @Param category query string false "Exact category"
+// @Param category_contains query string false "Case-insensitive category substring"
+// @Param currency query string false "Currency (ISO 4217)"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Param amount_min query number false "Minimal amount"
+// @Param amount_max query number false "Maximal amount"
+// @Param created_from query string false "Created at lower bound (YYYY-MM-DD or RFC 3339)"
+// @Param created_to query string false "Created at upper bound (YYYY-MM-DD or RFC 3339)"
+// @Param delimiter query string false "CSV delimiter: , (default), ;, | or tab"
+// @Param bom query bool false "Prepend UTF-8 BOM to CSV"
+// @Param decimal_separator query string false "CSV decimal separator: . (default) or ,"
+// @Param date_format query string false "CSV dates: iso (default) or ru (DD.MM.YYYY)"
+// @Param lang query string false "CSV header language: en (default) or ru"
+// @Param columns query []string false "CSV columns in output order (e.g., date,amount,category)"
 // @Success		200		{file}		application/octet-stream	"filtered_data.csv"
-// @Failure		400		{object}	map[string]string	"Invalid query parameters"
-// @Failure		500		{object}	map[string]string	"Internal server error"
+// @Failure		400		{object}	dto.ErrorResponse	"Invalid query parameters"
+// @Failure		500		{object}	dto.ErrorResponse	"Internal server error"
+// @Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+// @Security		BearerAuth
 // @Router			/items/csv [get]
 func (h *Handler) GetFilteredCSV(c *ginext.Context) {
-	sortBy := c.QueryArray("sort_by")
-
-	if err := validateGetParams(sortBy); err != nil {
-		zlog.Logger.Error().Msg("invalid query parameter: " + err.Error())
-		c.JSON(http.StatusBadRequest, ginext.H{"error": err.Error()})
-		return
-	}
-
-	getItemsParams := dto.GetItemsParams{SortBy: sortBy}
-	path, err := h.service.CSVAllItems(h.ctx, getItemsParams)
-	if err != nil {
-		zlog.Logger.Error().Msg(err.Error())
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": err.Error()})
-		return
-	}
+	h.exportAs(c, "csv", false)
+}
 
-	zlog.Logger.Info().Msg("successfylly handled GET request and returned csv file with data")
-	c.FileAttachment(path, "filtered_data.csv")
+// GetAggregatedXLSX godoc
+//
+//	@Summary		Export aggregated analytics as XLSX
+//	@Description	Download Excel workbook with items within a date range, statistics of the whole range and of every category on the Totals sheet and a sheet of items for every category
+//	@Tags			analytics
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			from	query		string	false	"Start date (YYYY-MM-DD)"
+//	@Param			to		query		string	false	"End date (YYYY-MM-DD)"
+//	@Param			currency	query	string	false	"Reporting currency (ISO 4217), amounts are converted into it"
+//	@Success		200		{file}		application/octet-stream	"aggregated_data.xlsx"
+//	@Failure		400		{object}	dto.ErrorResponse	"Invalid date parameters"
+//	@Failure		422		{object}	dto.ErrorResponse	"No exchange rate to convert amounts"
+//	@Failure		403		{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		500		{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/analytics/xlsx [get]
+func (h *Handler) GetAggregatedXLSX(c *ginext.Context) {
+	h.exportAs(c, "xlsx", true)
+}
 
-	if err := os.Remove(path); err != nil {
-		zlog.Logger.Info().Msg("could not delete temporary csv file: " + err.Error())
-	}
+// GetFilteredXLSX godoc
+//
+//	@Summary		Export filtered items as XLSX
+//	@Description	Download Excel workbook with filtered and sorted items, statistics for every currency on the Totals sheet and a sheet of items for every category
+//	@Tags			items
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//
+// @Param sort_by query []string false "Sort fields (e.g., date,amount)"
+// @Param type query string false "Item type (доход or расход)"
+// @Param category query string false "Exact category"
+// @Param category_contains query string false "Case-insensitive category substring"
+// @Param currency query string false "Currency (ISO 4217)"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Param amount_min query number false "Minimal amount"
+// @Param amount_max query number false "Maximal amount"
+// @Param created_from query string false "Created at lower bound (YYYY-MM-DD or RFC 3339)"
+// @Param created_to query string false "Created at upper bound (YYYY-MM-DD or RFC 3339)"
+// @Success		200		{file}		application/octet-stream	"filtered_data.xlsx"
+// @Failure		400		{object}	dto.ErrorResponse	"Invalid query parameters"
+// @Failure		500		{object}	dto.ErrorResponse	"Internal server error"
+// @Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+// @Security		BearerAuth
+// @Router			/items/xlsx [get]
+func (h *Handler) GetFilteredXLSX(c *ginext.Context) {
+	h.exportAs(c, "xlsx", false)
 }
 
 // GetMainPage godoc
@@ -2,19 +2,57 @@ package handler
 
 import (
 	"context"
+	"io"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
 	"github.com/Komilov31/sales-tracker/internal/model"
 )
 
 type TrackerService interface {
-	CreateItem(ctx context.Context, item dto.CreateItem) (*model.Item, error)
-	GetAllItems(ctx context.Context, params dto.GetItemsParams) ([]model.Item, error)
-	GetAggregated(ctx context.Context, from, to string) ([]model.Item, error)
-	UpdateItem(ctx context.Context, id int, item dto.UpdateItem) error
-	DeleteItem(ctx context.Context, id int) error
-	CSVAggregated(ctx context.Context, from, to string) (string, error)
-	CSVAllItems(ctx context.Context, params dto.GetItemsParams) (string, error)
+	CreateItem(ctx context.Context, actor model.Actor, ledgerID int, item dto.CreateItem) (*model.Item, error)
+	ImportItems(ctx context.Context, actor model.Actor, ledgerID int, r io.Reader, options dto.ImportOptions) (*model.ImportReport, error)
+	GetItem(ctx context.Context, ledgerID, id int) (*model.Item, error)
+	GetItemsPage(ctx context.Context, params dto.GetItemsParams) (*model.ItemsPage, error)
+	GetAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.Item, error)
+	GetGroupedAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.GroupedAggregated, error)
+	GetSummary(ctx context.Context, params dto.AnalyticsParams, includeItems bool) (*model.Summary, error)
+	UpdateItem(ctx context.Context, actor model.Actor, ledgerID, id, version int, item dto.UpdateItem) error
+	DeleteItem(ctx context.Context, actor model.Actor, ledgerID, id, version int) error
+	RestoreItem(ctx context.Context, actor model.Actor, ledgerID, id int) (*model.Item, error)
+	ExecuteBatch(ctx context.Context, actor model.Actor, ledgerID int, operations []dto.BatchOperation) ([]model.BatchResult, error)
+	Exporters() []model.ExportFormat
+	ExportItems(ctx context.Context, w io.Writer, format string, params dto.GetItemsParams, options dto.ExportOptions) error
+	ExportAggregated(ctx context.Context, w io.Writer, format string, params dto.AnalyticsParams, options dto.ExportOptions) error
+	StatementPDF(ctx context.Context, w io.Writer, params dto.StatementParams) error
+	CreateExchangeRate(ctx context.Context, ledgerID int, rate dto.CreateExchangeRate) (*model.ExchangeRate, error)
+	GetExchangeRates(ctx context.Context, params dto.GetExchangeRatesParams) ([]model.ExchangeRate, error)
+	DeleteExchangeRate(ctx context.Context, ledgerID, id int) error
+	Register(ctx context.Context, credentials dto.Credentials, registeredBy *model.User) (*model.User, error)
+	Login(ctx context.Context, credentials dto.Credentials) (*model.Token, error)
+	Authenticate(token string) (*model.User, error)
+	CreateLedger(ctx context.Context, userID int, name string) (*model.Ledger, error)
+	GetLedgers(ctx context.Context, userID int) ([]model.Ledger, error)
+	GetLedger(ctx context.Context, userID, ledgerID int) (*model.Ledger, error)
+	GetMembers(ctx context.Context, ledgerID int) ([]model.Member, error)
+	AddMember(ctx context.Context, ledgerID int, member dto.AddMember) (*model.Member, error)
+	UpdateMemberRole(ctx context.Context, ledgerID, userID int, role string) error
+	RemoveMember(ctx context.Context, ledgerID, userID int) error
+	CreateAPIKey(ctx context.Context, userID int, key dto.CreateAPIKey) (*model.CreatedAPIKey, error)
+	GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, id int) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*model.User, *model.APIKey, error)
+	GetItemHistory(ctx context.Context, ledgerID, itemID int) ([]model.AuditEntry, error)
+	GetAudit(ctx context.Context, params dto.AuditParams) (*model.AuditPage, error)
+	GetCategories(ctx context.Context, ledgerID int) ([]model.Category, error)
+	CreateCategory(ctx context.Context, ledgerID int, category dto.CreateCategory) (*model.Category, error)
+	UpdateCategory(ctx context.Context, actor model.Actor, ledgerID, id int, category dto.UpdateCategory) (*model.Category, error)
+	DeleteCategory(ctx context.Context, actor model.Actor, ledgerID, id, replacementID int) error
+	GetRules(ctx context.Context, ledgerID int) ([]model.Rule, error)
+	CreateRule(ctx context.Context, ledgerID int, rule dto.CreateRule) (*model.Rule, error)
+	UpdateRule(ctx context.Context, ledgerID, id int, rule dto.UpdateRule) (*model.Rule, error)
+	DeleteRule(ctx context.Context, ledgerID, id int) error
+	TestRules(ctx context.Context, ledgerID int, sample dto.RuleSample) (*model.RuleMatch, error)
+	ApplyRules(ctx context.Context, actor model.Actor, params dto.ApplyRulesParams) (*model.RulesReport, error)
 }
 
 type Handler struct {
@@ -4,55 +4,261 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
-	"os"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
 	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/Komilov31/sales-tracker/internal/repository"
+	"github.com/Komilov31/sales-tracker/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+var testExportFormats = []model.ExportFormat{
+	{Name: "csv", ContentType: "text/csv; charset=utf-8", Extension: ".csv"},
+	{Name: "ndjson", ContentType: "application/x-ndjson", Extension: ".ndjson"},
+	{Name: "xlsx", ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", Extension: ".xlsx"},
+}
+
 type mockTrackerService struct {
 	mock.Mock
 }
 
-func (m *mockTrackerService) CreateItem(ctx context.Context, item dto.CreateItem) (*model.Item, error) {
-	args := m.Called(ctx, item)
+func (m *mockTrackerService) CreateItem(ctx context.Context, actor model.Actor, ledgerID int, item dto.CreateItem) (*model.Item, error) {
+	args := m.Called(ctx, actor, ledgerID, item)
 	return args.Get(0).(*model.Item), args.Error(1)
 }
 
-func (m *mockTrackerService) GetAllItems(ctx context.Context, params dto.GetItemsParams) ([]model.Item, error) {
+func (m *mockTrackerService) ImportItems(ctx context.Context, actor model.Actor, ledgerID int, r io.Reader, options dto.ImportOptions) (*model.ImportReport, error) {
+	args := m.Called(ctx, actor, ledgerID, r, options)
+	return args.Get(0).(*model.ImportReport), args.Error(1)
+}
+
+func (m *mockTrackerService) GetItemsPage(ctx context.Context, params dto.GetItemsParams) (*model.ItemsPage, error) {
 	args := m.Called(ctx, params)
-	return args.Get(0).([]model.Item), args.Error(1)
+	return args.Get(0).(*model.ItemsPage), args.Error(1)
+}
+
+func (m *mockTrackerService) ExecuteBatch(ctx context.Context, actor model.Actor, ledgerID int, operations []dto.BatchOperation) ([]model.BatchResult, error) {
+	args := m.Called(ctx, actor, ledgerID, operations)
+	return args.Get(0).([]model.BatchResult), args.Error(1)
 }
 
-func (m *mockTrackerService) GetAggregated(ctx context.Context, from, to string) ([]model.Item, error) {
-	args := m.Called(ctx, from, to)
+func (m *mockTrackerService) GetAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.Item, error) {
+	args := m.Called(ctx, params)
 	return args.Get(0).([]model.Item), args.Error(1)
 }
 
-func (m *mockTrackerService) UpdateItem(ctx context.Context, id int, item dto.UpdateItem) error {
-	args := m.Called(ctx, id, item)
+func (m *mockTrackerService) GetGroupedAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.GroupedAggregated, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).([]model.GroupedAggregated), args.Error(1)
+}
+
+func (m *mockTrackerService) GetSummary(ctx context.Context, params dto.AnalyticsParams, includeItems bool) (*model.Summary, error) {
+	args := m.Called(ctx, params, includeItems)
+	return args.Get(0).(*model.Summary), args.Error(1)
+}
+
+func (m *mockTrackerService) UpdateItem(ctx context.Context, actor model.Actor, ledgerID, id, version int, item dto.UpdateItem) error {
+	args := m.Called(ctx, actor, ledgerID, id, version, item)
+	return args.Error(0)
+}
+
+func (m *mockTrackerService) DeleteItem(ctx context.Context, actor model.Actor, ledgerID, id, version int) error {
+	args := m.Called(ctx, actor, ledgerID, id, version)
+	return args.Error(0)
+}
+
+func (m *mockTrackerService) GetItem(ctx context.Context, ledgerID, id int) (*model.Item, error) {
+	args := m.Called(ctx, ledgerID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Item), args.Error(1)
+}
+
+// ExportItems writes content returned by the mock to w before returning the
+// error, so that failures in the middle of export can be simulated.
+func (m *mockTrackerService) ExportItems(ctx context.Context, w io.Writer, format string, params dto.GetItemsParams, options dto.ExportOptions) error {
+	args := m.Called(ctx, w, format, params, options)
+	io.WriteString(w, args.String(0))
+	return args.Error(1)
+}
+
+func (m *mockTrackerService) ExportAggregated(ctx context.Context, w io.Writer, format string, params dto.AnalyticsParams, options dto.ExportOptions) error {
+	args := m.Called(ctx, w, format, params, options)
+	io.WriteString(w, args.String(0))
+	return args.Error(1)
+}
+
+func (m *mockTrackerService) Exporters() []model.ExportFormat {
+	args := m.Called()
+	return args.Get(0).([]model.ExportFormat)
+}
+
+func (m *mockTrackerService) StatementPDF(ctx context.Context, w io.Writer, params dto.StatementParams) error {
+	args := m.Called(ctx, w, params)
+	io.WriteString(w, args.String(0))
+	return args.Error(1)
+}
+
+func (m *mockTrackerService) CreateExchangeRate(ctx context.Context, ledgerID int, rate dto.CreateExchangeRate) (*model.ExchangeRate, error) {
+	args := m.Called(ctx, ledgerID, rate)
+	return args.Get(0).(*model.ExchangeRate), args.Error(1)
+}
+
+func (m *mockTrackerService) GetExchangeRates(ctx context.Context, params dto.GetExchangeRatesParams) ([]model.ExchangeRate, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).([]model.ExchangeRate), args.Error(1)
+}
+
+func (m *mockTrackerService) DeleteExchangeRate(ctx context.Context, ledgerID, id int) error {
+	args := m.Called(ctx, ledgerID, id)
+	return args.Error(0)
+}
+
+func (m *mockTrackerService) Register(ctx context.Context, credentials dto.Credentials, registeredBy *model.User) (*model.User, error) {
+	args := m.Called(ctx, credentials, registeredBy)
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *mockTrackerService) Login(ctx context.Context, credentials dto.Credentials) (*model.Token, error) {
+	args := m.Called(ctx, credentials)
+	return args.Get(0).(*model.Token), args.Error(1)
+}
+
+func (m *mockTrackerService) Authenticate(token string) (*model.User, error) {
+	args := m.Called(token)
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *mockTrackerService) CreateLedger(ctx context.Context, userID int, name string) (*model.Ledger, error) {
+	args := m.Called(ctx, userID, name)
+	return args.Get(0).(*model.Ledger), args.Error(1)
+}
+
+func (m *mockTrackerService) GetLedgers(ctx context.Context, userID int) ([]model.Ledger, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]model.Ledger), args.Error(1)
+}
+
+func (m *mockTrackerService) GetLedger(ctx context.Context, userID, ledgerID int) (*model.Ledger, error) {
+	args := m.Called(ctx, userID, ledgerID)
+	return args.Get(0).(*model.Ledger), args.Error(1)
+}
+
+func (m *mockTrackerService) GetMembers(ctx context.Context, ledgerID int) ([]model.Member, error) {
+	args := m.Called(ctx, ledgerID)
+	return args.Get(0).([]model.Member), args.Error(1)
+}
+
+func (m *mockTrackerService) AddMember(ctx context.Context, ledgerID int, member dto.AddMember) (*model.Member, error) {
+	args := m.Called(ctx, ledgerID, member)
+	return args.Get(0).(*model.Member), args.Error(1)
+}
+
+func (m *mockTrackerService) UpdateMemberRole(ctx context.Context, ledgerID, userID int, role string) error {
+	args := m.Called(ctx, ledgerID, userID, role)
+	return args.Error(0)
+}
+
+func (m *mockTrackerService) RemoveMember(ctx context.Context, ledgerID, userID int) error {
+	args := m.Called(ctx, ledgerID, userID)
 	return args.Error(0)
 }
 
-func (m *mockTrackerService) DeleteItem(ctx context.Context, id int) error {
-	args := m.Called(ctx, id)
+func (m *mockTrackerService) CreateAPIKey(ctx context.Context, userID int, key dto.CreateAPIKey) (*model.CreatedAPIKey, error) {
+	args := m.Called(ctx, userID, key)
+	return args.Get(0).(*model.CreatedAPIKey), args.Error(1)
+}
+
+func (m *mockTrackerService) GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *mockTrackerService) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	args := m.Called(ctx, userID, id)
 	return args.Error(0)
 }
 
-func (m *mockTrackerService) CSVAggregated(ctx context.Context, from, to string) (string, error) {
-	args := m.Called(ctx, from, to)
-	return args.String(0), args.Error(1)
+func (m *mockTrackerService) AuthenticateAPIKey(ctx context.Context, key string) (*model.User, *model.APIKey, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(*model.User), args.Get(1).(*model.APIKey), args.Error(2)
+}
+
+func (m *mockTrackerService) GetItemHistory(ctx context.Context, ledgerID, itemID int) ([]model.AuditEntry, error) {
+	args := m.Called(ctx, ledgerID, itemID)
+	return args.Get(0).([]model.AuditEntry), args.Error(1)
 }
 
-func (m *mockTrackerService) CSVAllItems(ctx context.Context, params dto.GetItemsParams) (string, error) {
+func (m *mockTrackerService) GetAudit(ctx context.Context, params dto.AuditParams) (*model.AuditPage, error) {
 	args := m.Called(ctx, params)
-	return args.String(0), args.Error(1)
+	return args.Get(0).(*model.AuditPage), args.Error(1)
+}
+
+func (m *mockTrackerService) GetCategories(ctx context.Context, ledgerID int) ([]model.Category, error) {
+	args := m.Called(ctx, ledgerID)
+	return args.Get(0).([]model.Category), args.Error(1)
+}
+
+func (m *mockTrackerService) CreateCategory(ctx context.Context, ledgerID int, category dto.CreateCategory) (*model.Category, error) {
+	args := m.Called(ctx, ledgerID, category)
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *mockTrackerService) UpdateCategory(ctx context.Context, actor model.Actor, ledgerID, id int, category dto.UpdateCategory) (*model.Category, error) {
+	args := m.Called(ctx, actor, ledgerID, id, category)
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *mockTrackerService) DeleteCategory(ctx context.Context, actor model.Actor, ledgerID, id, replacementID int) error {
+	args := m.Called(ctx, actor, ledgerID, id, replacementID)
+	return args.Error(0)
+}
+
+func (m *mockTrackerService) GetRules(ctx context.Context, ledgerID int) ([]model.Rule, error) {
+	args := m.Called(ctx, ledgerID)
+	return args.Get(0).([]model.Rule), args.Error(1)
+}
+
+func (m *mockTrackerService) CreateRule(ctx context.Context, ledgerID int, rule dto.CreateRule) (*model.Rule, error) {
+	args := m.Called(ctx, ledgerID, rule)
+	return args.Get(0).(*model.Rule), args.Error(1)
+}
+
+func (m *mockTrackerService) UpdateRule(ctx context.Context, ledgerID, id int, rule dto.UpdateRule) (*model.Rule, error) {
+	args := m.Called(ctx, ledgerID, id, rule)
+	return args.Get(0).(*model.Rule), args.Error(1)
+}
+
+func (m *mockTrackerService) DeleteRule(ctx context.Context, ledgerID, id int) error {
+	args := m.Called(ctx, ledgerID, id)
+	return args.Error(0)
+}
+
+func (m *mockTrackerService) TestRules(ctx context.Context, ledgerID int, sample dto.RuleSample) (*model.RuleMatch, error) {
+	args := m.Called(ctx, ledgerID, sample)
+	return args.Get(0).(*model.RuleMatch), args.Error(1)
+}
+
+func (m *mockTrackerService) ApplyRules(ctx context.Context, actor model.Actor, params dto.ApplyRulesParams) (*model.RulesReport, error) {
+	args := m.Called(ctx, actor, params)
+	return args.Get(0).(*model.RulesReport), args.Error(1)
+}
+
+func (m *mockTrackerService) RestoreItem(ctx context.Context, actor model.Actor, ledgerID, id int) (*model.Item, error) {
+	args := m.Called(ctx, actor, ledgerID, id)
+	return args.Get(0).(*model.Item), args.Error(1)
 }
 
 func TestCreateItem(t *testing.T) {
@@ -61,9 +267,9 @@ func TestCreateItem(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		item := dto.CreateItem{Type: "доход", Amount: 100, Date: "2023-01-01", Category: "test"}
-		expected := &model.Item{ID: 1, Type: "доход", Amount: 100, Date: "2023-01-01", Category: "test"}
-		mockService.On("CreateItem", mock.Anything, item).Return(expected, nil)
+		item := dto.CreateItem{Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test"}
+		expected := &model.Item{ID: 1, Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test"}
+		mockService.On("CreateItem", mock.Anything, mock.Anything, mock.Anything, item).Return(expected, nil)
 
 		body, _ := json.Marshal(item)
 		req := httptest.NewRequest(http.MethodPost, "/items", bytes.NewBuffer(body))
@@ -82,6 +288,52 @@ func TestCreateItem(t *testing.T) {
 		mockService.AssertExpectations(t)
 	})
 
+	t.Run("decimal amount", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		item := dto.CreateItem{Type: "расход", Amount: decimal.RequireFromString("149.90"), Date: "2023-01-01", Category: "test", Currency: "RUB"}
+		expected := &model.Item{ID: 1, Type: "расход", Amount: item.Amount, Date: "2023-01-01", Category: "test", Currency: "RUB"}
+		mockService.On("CreateItem", mock.Anything, mock.Anything, mock.Anything, item).Return(expected, nil)
+
+		body := `{"type":"расход","amount":"149.90","date":"2023-01-01","category":"test","currency":"RUB"}`
+		req := httptest.NewRequest(http.MethodPost, "/items", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.CreateItem(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]any
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "149.9", response["amount"])
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("amount precision", func(t *testing.T) {
+		for _, body := range []string{
+			`{"type":"расход","amount":"149.901","date":"2023-01-01","category":"test"}`,
+			`{"type":"расход","amount":"1.5","date":"2023-01-01","category":"test","currency":"JPY"}`,
+			`{"type":"расход","amount":"-1","date":"2023-01-01","category":"test"}`,
+		} {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+			req := httptest.NewRequest(http.MethodPost, "/items", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.CreateItem(c)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, body)
+			mockService.AssertNotCalled(t, "CreateItem")
+		}
+	})
+
 	t.Run("invalid json", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
@@ -101,7 +353,7 @@ func TestCreateItem(t *testing.T) {
 	t.Run("validation error", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		item := dto.CreateItem{Type: "", Amount: 100, Date: "2023-01-01", Category: "test"}
+		item := dto.CreateItem{Type: "", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test"}
 		body, _ := json.Marshal(item)
 		req := httptest.NewRequest(http.MethodPost, "/items", bytes.NewBuffer(body))
 		req.Header.Set("Content-Type", "application/json")
@@ -112,15 +364,15 @@ func TestCreateItem(t *testing.T) {
 
 		handler.CreateItem(c)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
 		mockService.AssertNotCalled(t, "CreateItem")
 	})
 
 	t.Run("service error", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		item := dto.CreateItem{Type: "доход", Amount: 100, Date: "2023-01-01", Category: "test"}
-		mockService.On("CreateItem", mock.Anything, item).Return((*model.Item)(nil), assert.AnError)
+		item := dto.CreateItem{Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test"}
+		mockService.On("CreateItem", mock.Anything, mock.Anything, mock.Anything, item).Return((*model.Item)(nil), assert.AnError)
 
 		body, _ := json.Marshal(item)
 		req := httptest.NewRequest(http.MethodPost, "/items", bytes.NewBuffer(body))
@@ -143,9 +395,13 @@ func TestGetAllItems(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		params := dto.GetItemsParams{SortBy: []string{"date"}}
-		expected := []model.Item{{ID: 1, Type: "расход", Amount: 50, Date: "2023-01-01", Category: "test"}}
-		mockService.On("GetAllItems", mock.Anything, params).Return(expected, nil)
+		params := dto.GetItemsParams{SortBy: []string{"date"}, Limit: defaultPageLimit}
+		expected := &model.ItemsPage{
+			Items:      []model.Item{{ID: 1, Type: "расход", Amount: decimal.NewFromInt(50), Date: "2023-01-01", Category: "test"}},
+			NextCursor: "next",
+			Total:      2,
+		}
+		mockService.On("GetItemsPage", mock.Anything, params).Return(expected, nil)
 
 		req := httptest.NewRequest(http.MethodGet, "/items?sort_by=date", nil)
 		w := httptest.NewRecorder()
@@ -156,16 +412,41 @@ func TestGetAllItems(t *testing.T) {
 		handler.GetAllItems(c)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		var response []dto.ItemWithoutAggregated
+		var response dto.ItemsPage
 		json.Unmarshal(w.Body.Bytes(), &response)
-		assert.Len(t, response, 1)
+		assert.Len(t, response.Items, 1)
+		assert.Equal(t, "next", response.NextCursor)
+		assert.Equal(t, 2, response.Total)
 		mockService.AssertExpectations(t)
 	})
 
-	t.Run("invalid sort_by", func(t *testing.T) {
+	t.Run("with filters", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		req := httptest.NewRequest(http.MethodGet, "/items?sort_by=invalid", nil)
+		amountMin, amountMax := decimal.NewFromInt(10), decimal.NewFromInt(500)
+		createdTo := time.Date(2023, 1, 31, 23, 59, 59, 999999000, time.UTC)
+		params := dto.GetItemsParams{
+			Type:             "расход",
+			CategoryContains: "еда",
+			DateFrom:         "2023-01-01",
+			DateTo:           "2023-01-31",
+			AmountMin:        &amountMin,
+			AmountMax:        &amountMax,
+			CreatedTo:        &createdTo,
+			Limit:            defaultPageLimit,
+		}
+		mockService.On("GetItemsPage", mock.Anything, params).Return(&model.ItemsPage{}, nil)
+
+		query := url.Values{
+			"type":              {"расход"},
+			"category_contains": {"еда"},
+			"date_from":         {"2023-01-01"},
+			"date_to":           {"2023-01-31"},
+			"amount_min":        {"10"},
+			"amount_max":        {"500"},
+			"created_to":        {"2023-01-31"},
+		}
+		req := httptest.NewRequest(http.MethodGet, "/items?"+query.Encode(), nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
@@ -173,17 +454,41 @@ func TestGetAllItems(t *testing.T) {
 
 		handler.GetAllItems(c)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code)
-		mockService.AssertNotCalled(t, "GetAllItems")
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
 	})
 
-	t.Run("service error", func(t *testing.T) {
+	t.Run("invalid filters", func(t *testing.T) {
+		for _, query := range []string{
+			"type=invalid",
+			"date_from=01.01.2023",
+			"date_from=2023-02-01&date_to=2023-01-01",
+			"amount_min=-1",
+			"amount_min=100&amount_max=10",
+			"created_from=yesterday",
+		} {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+			req := httptest.NewRequest(http.MethodGet, "/items?"+query, nil)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.GetAllItems(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+			mockService.AssertNotCalled(t, "GetItemsPage")
+		}
+	})
+
+	t.Run("with cursor", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		params := dto.GetItemsParams{}
-		mockService.On("GetAllItems", mock.Anything, params).Return([]model.Item(nil), assert.AnError)
+		params := dto.GetItemsParams{Limit: 10, Cursor: "token"}
+		mockService.On("GetItemsPage", mock.Anything, params).Return(&model.ItemsPage{}, nil)
 
-		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req := httptest.NewRequest(http.MethodGet, "/items?limit=10&cursor=token", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
@@ -191,329 +496,2586 @@ func TestGetAllItems(t *testing.T) {
 
 		handler.GetAllItems(c)
 
-		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, http.StatusOK, w.Code)
 		mockService.AssertExpectations(t)
 	})
-}
-
-func TestGetAggregated(t *testing.T) {
-	gin.SetMode(gin.TestMode)
 
-	t.Run("success", func(t *testing.T) {
+	t.Run("invalid cursor", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		from, to := "2023-01-01", "2023-12-31"
-		expected := []model.Item{{ID: 1, Type: "доход", Amount: 100, Date: "2023-01-01", Category: "test"}}
-		mockService.On("GetAggregated", mock.Anything, from, to).Return(expected, nil)
+		params := dto.GetItemsParams{Limit: defaultPageLimit, Cursor: "forged"}
+		mockService.On("GetItemsPage", mock.Anything, params).Return((*model.ItemsPage)(nil), service.ErrInvalidCursor)
 
-		req := httptest.NewRequest(http.MethodGet, "/analytics?from=2023-01-01&to=2023-12-31", nil)
+		req := httptest.NewRequest(http.MethodGet, "/items?cursor=forged", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
 
-		handler.GetAggregated(c)
+		handler.GetAllItems(c)
 
-		assert.Equal(t, http.StatusOK, w.Code)
-		var response []model.Item
-		json.Unmarshal(w.Body.Bytes(), &response)
-		assert.Len(t, response, 1)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 		mockService.AssertExpectations(t)
 	})
 
-	t.Run("invalid date", func(t *testing.T) {
+	t.Run("invalid limit", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "limit=abc", "limit=501"} {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+			req := httptest.NewRequest(http.MethodGet, "/items?"+query, nil)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.GetAllItems(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+			mockService.AssertNotCalled(t, "GetItemsPage")
+		}
+	})
+
+	t.Run("invalid sort_by", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		req := httptest.NewRequest(http.MethodGet, "/analytics?from=invalid&to=2023-12-31", nil)
+		req := httptest.NewRequest(http.MethodGet, "/items?sort_by=invalid", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
 
-		handler.GetAggregated(c)
+		handler.GetAllItems(c)
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
-		mockService.AssertNotCalled(t, "GetAggregated")
+		mockService.AssertNotCalled(t, "GetItemsPage")
 	})
 
 	t.Run("service error", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		from, to := "2023-01-01", "2023-12-31"
-		mockService.On("GetAggregated", mock.Anything, from, to).Return([]model.Item(nil), assert.AnError)
+		params := dto.GetItemsParams{Limit: defaultPageLimit}
+		mockService.On("GetItemsPage", mock.Anything, params).Return((*model.ItemsPage)(nil), assert.AnError)
 
-		req := httptest.NewRequest(http.MethodGet, "/analytics?from=2023-01-01&to=2023-12-31", nil)
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
 
-		handler.GetAggregated(c)
+		handler.GetAllItems(c)
 
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		mockService.AssertExpectations(t)
 	})
 }
 
-func TestUpdateItem(t *testing.T) {
+func TestGetAggregated(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	t.Run("success", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		id := 1
-		item := dto.UpdateItem{Type: stringPtr("расход")}
-		mockService.On("UpdateItem", mock.Anything, id, item).Return(nil)
+		params := dto.AnalyticsParams{From: "2023-01-01", To: "2023-12-31"}
+		expected := []model.Item{{ID: 1, Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test"}}
+		mockService.On("GetAggregated", mock.Anything, params).Return(expected, nil)
 
-		body, _ := json.Marshal(item)
-		req := httptest.NewRequest(http.MethodPut, "/items/1", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
+		req := httptest.NewRequest(http.MethodGet, "/analytics?from=2023-01-01&to=2023-12-31", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
-		c.Params = gin.Params{{Key: "id", Value: "1"}}
 
-		handler.UpdateItem(c)
+		handler.GetAggregated(c)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		var response []model.Item
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Len(t, response, 1)
 		mockService.AssertExpectations(t)
 	})
 
-	t.Run("invalid id", func(t *testing.T) {
+	t.Run("reporting currency", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		req := httptest.NewRequest(http.MethodPut, "/items/invalid", nil)
-		w := httptest.NewRecorder()
-
-		c, _ := gin.CreateTestContext(w)
-		c.Request = req
-		c.Params = gin.Params{{Key: "id", Value: "invalid"}}
-
-		handler.UpdateItem(c)
-
-		assert.Equal(t, http.StatusBadRequest, w.Code)
-		mockService.AssertNotCalled(t, "UpdateItem")
-	})
+		params := dto.AnalyticsParams{Currency: "USD"}
+		mockService.On("GetAggregated", mock.Anything, params).Return([]model.Item{}, nil)
 
-	t.Run("invalid json", func(t *testing.T) {
-		mockService := &mockTrackerService{}
-		handler := New(context.Background(), mockService)
-		req := httptest.NewRequest(http.MethodPut, "/items/1", bytes.NewBufferString("invalid"))
-		req.Header.Set("Content-Type", "application/json")
+		req := httptest.NewRequest(http.MethodGet, "/analytics?currency=USD", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
-		c.Params = gin.Params{{Key: "id", Value: "1"}}
 
-		handler.UpdateItem(c)
+		handler.GetAggregated(c)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code)
-		mockService.AssertNotCalled(t, "UpdateItem")
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
 	})
 
-	t.Run("service error", func(t *testing.T) {
+	t.Run("invalid currency", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		id := 1
-		item := dto.UpdateItem{}
-		mockService.On("UpdateItem", mock.Anything, id, item).Return(assert.AnError)
-
-		body, _ := json.Marshal(item)
-		req := httptest.NewRequest(http.MethodPut, "/items/1", bytes.NewBuffer(body))
-		req.Header.Set("Content-Type", "application/json")
+		req := httptest.NewRequest(http.MethodGet, "/analytics?currency=XXY", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
-		c.Params = gin.Params{{Key: "id", Value: "1"}}
 
-		handler.UpdateItem(c)
+		handler.GetAggregated(c)
 
-		assert.Equal(t, http.StatusInternalServerError, w.Code)
-		mockService.AssertExpectations(t)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "GetAggregated")
 	})
-}
-
-func TestDeleteItem(t *testing.T) {
-	gin.SetMode(gin.TestMode)
 
-	t.Run("success", func(t *testing.T) {
+	t.Run("missing exchange rate", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		id := 1
-		mockService.On("DeleteItem", mock.Anything, id).Return(nil)
+		params := dto.AnalyticsParams{Currency: "EUR"}
+		err := fmt.Errorf("%w: USD to EUR on 2023-01-01", repository.ErrNoExchangeRate)
+		mockService.On("GetAggregated", mock.Anything, params).Return([]model.Item(nil), err)
 
-		req := httptest.NewRequest(http.MethodDelete, "/items/1", nil)
+		req := httptest.NewRequest(http.MethodGet, "/analytics?currency=EUR", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
-		c.Params = gin.Params{{Key: "id", Value: "1"}}
 
-		handler.DeleteItem(c)
+		handler.GetAggregated(c)
 
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
 		mockService.AssertExpectations(t)
 	})
 
-	t.Run("invalid id", func(t *testing.T) {
+	t.Run("invalid date", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		req := httptest.NewRequest(http.MethodDelete, "/items/invalid", nil)
+		req := httptest.NewRequest(http.MethodGet, "/analytics?from=invalid&to=2023-12-31", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
-		c.Params = gin.Params{{Key: "id", Value: "invalid"}}
 
-		handler.DeleteItem(c)
+		handler.GetAggregated(c)
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
-		mockService.AssertNotCalled(t, "DeleteItem")
+		mockService.AssertNotCalled(t, "GetAggregated")
 	})
 
 	t.Run("service error", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		id := 1
-		mockService.On("DeleteItem", mock.Anything, id).Return(assert.AnError)
+		params := dto.AnalyticsParams{From: "2023-01-01", To: "2023-12-31"}
+		mockService.On("GetAggregated", mock.Anything, params).Return([]model.Item(nil), assert.AnError)
 
-		req := httptest.NewRequest(http.MethodDelete, "/items/1", nil)
+		req := httptest.NewRequest(http.MethodGet, "/analytics?from=2023-01-01&to=2023-12-31", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
-		c.Params = gin.Params{{Key: "id", Value: "1"}}
 
-		handler.DeleteItem(c)
+		handler.GetAggregated(c)
 
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		mockService.AssertExpectations(t)
 	})
 }
 
-func TestGetAggregatedCSV(t *testing.T) {
+func TestGetSummary(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	t.Run("success", func(t *testing.T) {
+	t.Run("summary only", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		from, to := "2023-01-01", "2023-12-31"
-		tempFile, err := os.CreateTemp("", "test.csv")
-		if err != nil {
-			t.Fatal(err)
-		}
-		defer os.Remove(tempFile.Name())
-		mockService.On("CSVAggregated", mock.Anything, from, to).Return(tempFile.Name(), nil)
+		expected := &model.Summary{Aggregated: model.Aggregated{Sum: decimal.NewFromInt(100), Count: 1, Net: decimal.NewFromInt(100)}}
+		mockService.On("GetSummary", mock.Anything, dto.AnalyticsParams{From: "2023-01-01"}, false).Return(expected, nil)
 
-		req := httptest.NewRequest(http.MethodGet, "/analytics/csv?from=2023-01-01&to=2023-12-31", nil)
+		req := httptest.NewRequest(http.MethodGet, "/analytics?view=summary&from=2023-01-01", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
 
-		handler.GetAggregatedCSV(c)
+		handler.GetAggregated(c)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]json.RawMessage
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Contains(t, response, "summary")
+		assert.NotContains(t, response, "items")
 		mockService.AssertExpectations(t)
+		mockService.AssertNotCalled(t, "GetAggregated")
 	})
 
-	t.Run("invalid date", func(t *testing.T) {
+	t.Run("with items", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		req := httptest.NewRequest(http.MethodGet, "/analytics/csv?from=invalid&to=2023-12-31", nil)
+		expected := &model.Summary{
+			Aggregated: model.Aggregated{Sum: decimal.NewFromInt(100), Count: 1, Net: decimal.NewFromInt(100)},
+			Items:      []model.Item{{ID: 1, Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test"}},
+		}
+		mockService.On("GetSummary", mock.Anything, dto.AnalyticsParams{}, true).Return(expected, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/analytics?view=summary&include_items=true", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
 
-		handler.GetAggregatedCSV(c)
+		handler.GetAggregated(c)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code)
-		mockService.AssertNotCalled(t, "CSVAggregated")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response map[string]json.RawMessage
+		json.Unmarshal(w.Body.Bytes(), &response)
+		summary, _ := json.Marshal(expected.Aggregated)
+		assert.JSONEq(t, string(summary), string(response["summary"]))
+		var items []dto.ItemWithoutAggregated
+		json.Unmarshal(response["items"], &items)
+		assert.Len(t, items, 1)
+		mockService.AssertExpectations(t)
 	})
 
-	t.Run("service error", func(t *testing.T) {
-		mockService := &mockTrackerService{}
-		handler := New(context.Background(), mockService)
-		from, to := "2023-01-01", "2023-12-31"
-		mockService.On("CSVAggregated", mock.Anything, from, to).Return("", assert.AnError)
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"view=table", "view=summary&include_items=maybe"} {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+			req := httptest.NewRequest(http.MethodGet, "/analytics?"+query, nil)
+			w := httptest.NewRecorder()
 
-		req := httptest.NewRequest(http.MethodGet, "/analytics/csv?from=2023-01-01&to=2023-12-31", nil)
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.GetAggregated(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+			mockService.AssertNotCalled(t, "GetSummary")
+			mockService.AssertNotCalled(t, "GetAggregated")
+		}
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("GetSummary", mock.Anything, dto.AnalyticsParams{}, false).Return((*model.Summary)(nil), assert.AnError)
+
+		req := httptest.NewRequest(http.MethodGet, "/analytics?view=summary", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
 
-		handler.GetAggregatedCSV(c)
+		handler.GetAggregated(c)
 
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		mockService.AssertExpectations(t)
 	})
 }
 
-func TestGetFilteredCSV(t *testing.T) {
+func TestGetGroupedAggregated(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	t.Run("success", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		params := dto.GetItemsParams{SortBy: []string{"date"}}
-		tempFile, err := os.CreateTemp("", "test.csv")
-		if err != nil {
-			t.Fatal(err)
+		params := dto.AnalyticsParams{From: "2023-01-01", GroupBy: []string{"category", "month"}}
+		expected := []model.GroupedAggregated{{
+			Group:      map[string]string{"category": "test", "month": "2023-01"},
+			Aggregated: model.Aggregated{Sum: decimal.NewFromInt(100), Average: decimal.NewFromInt(100), Count: 1, Median: decimal.NewFromInt(100), Percentile_90: decimal.NewFromInt(100)},
+		}}
+		mockService.On("GetGroupedAggregated", mock.Anything, params).Return(expected, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/analytics/grouped?from=2023-01-01&group_by=category&group_by=month", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetGroupedAggregated(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		expectedJSON, _ := json.Marshal(expected)
+		assert.JSONEq(t, string(expectedJSON), w.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, query := range []string{
+			"group_by=invalid",
+			"group_by=month&group_by=month",
+			"from=invalid",
+			"from=2023-02-01&to=2023-01-01",
+		} {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+			req := httptest.NewRequest(http.MethodGet, "/analytics/grouped?"+query, nil)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.GetGroupedAggregated(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+			mockService.AssertNotCalled(t, "GetGroupedAggregated")
 		}
-		defer os.Remove(tempFile.Name())
-		mockService.On("CSVAllItems", mock.Anything, params).Return(tempFile.Name(), nil)
+	})
 
-		req := httptest.NewRequest(http.MethodGet, "/items/csv?sort_by=date", nil)
+	t.Run("service error", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.AnalyticsParams{GroupBy: []string{"type"}}
+		mockService.On("GetGroupedAggregated", mock.Anything, params).Return([]model.GroupedAggregated(nil), assert.AnError)
+
+		req := httptest.NewRequest(http.MethodGet, "/analytics/grouped?group_by=type", nil)
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
 
-		handler.GetFilteredCSV(c)
+		handler.GetGroupedAggregated(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestUpdateItem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		id := 1
+		item := dto.UpdateItem{Type: stringPtr("расход")}
+		mockService.On("UpdateItem", mock.Anything, mock.Anything, mock.Anything, id, 3, item).Return(nil)
+
+		body, _ := json.Marshal(item)
+		req := httptest.NewRequest(http.MethodPut, "/items/1", bytes.NewBuffer(body))
+		req.Header.Set("If-Match", `"3"`)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.UpdateItem(c)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"4"`, w.Header().Get("ETag"))
 		mockService.AssertExpectations(t)
 	})
 
-	t.Run("invalid sort_by", func(t *testing.T) {
+	t.Run("missing if-match", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		req := httptest.NewRequest(http.MethodGet, "/items/csv?sort_by=invalid", nil)
+		req := httptest.NewRequest(http.MethodPut, "/items/1", bytes.NewBufferString(`{"category":"food"}`))
+		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
 
-		handler.GetFilteredCSV(c)
+		handler.UpdateItem(c)
+
+		assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+		mockService.AssertNotCalled(t, "UpdateItem")
+	})
+
+	t.Run("version conflict", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		item := dto.UpdateItem{Category: stringPtr("food")}
+		mockService.On("UpdateItem", mock.Anything, mock.Anything, mock.Anything, 1, 3, item).Return(repository.ErrVersionConflict)
+
+		body, _ := json.Marshal(item)
+		req := httptest.NewRequest(http.MethodPut, "/items/1", bytes.NewBuffer(body))
+		req.Header.Set("If-Match", `"3"`)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.UpdateItem(c)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		req := httptest.NewRequest(http.MethodPut, "/items/invalid", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "invalid"}}
+
+		handler.UpdateItem(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "UpdateItem")
+	})
+
+	t.Run("invalid currency", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		body, _ := json.Marshal(dto.UpdateItem{Currency: stringPtr("RUR")})
+		req := httptest.NewRequest(http.MethodPut, "/items/1", bytes.NewBuffer(body))
+		req.Header.Set("If-Match", `"3"`)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.UpdateItem(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertNotCalled(t, "UpdateItem")
+	})
+
+	t.Run("invalid fields", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			body  string
+			field string
+			rule  string
+		}{
+			{"unknown type", `{"type":"прочее"}`, "type", "oneof"},
+			{"zero amount", `{"amount":"0"}`, "amount", "gt"},
+			{"negative amount", `{"amount":"-1"}`, "amount", "gt"},
+			// Too close to zero to be told from it as float64.
+			{"tiny negative amount", `{"amount":"-1e-400"}`, "amount", "gt"},
+			{"invalid date", `{"date":"2024-13-01"}`, "date", "datetime"},
+			{"empty category", `{"category":""}`, "category", "notblank"},
+			{"blank category", `{"category":"   "}`, "category", "notblank"},
+			{"currency precision", `{"amount":"0.5","currency":"JPY"}`, "amount", "currency_precision"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := &mockTrackerService{}
+				handler := New(context.Background(), mockService)
+				req := httptest.NewRequest(http.MethodPut, "/items/1", bytes.NewBufferString(tt.body))
+				req.Header.Set("If-Match", `"3"`)
+				req.Header.Set("Content-Type", "application/json")
+				w := httptest.NewRecorder()
+
+				c, _ := gin.CreateTestContext(w)
+				c.Request = req
+				c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+				handler.UpdateItem(c)
+
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				var response dto.ErrorResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+				assert.Equal(t, "validation_failed", response.Code)
+				if assert.Len(t, response.Fields, 1) {
+					assert.Equal(t, tt.field, response.Fields[0].Field)
+					assert.Equal(t, tt.rule, response.Fields[0].Rule)
+				}
+				mockService.AssertNotCalled(t, "UpdateItem")
+			})
+		}
+	})
+
+	t.Run("amount precision", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		amount := decimal.RequireFromString("0.5")
+		item := dto.UpdateItem{Amount: &amount}
+		mockService.On("UpdateItem", mock.Anything, mock.Anything, mock.Anything, 1, 3, item).Return(repository.ErrAmountPrecision)
+
+		req := httptest.NewRequest(http.MethodPut, "/items/1", bytes.NewBufferString(`{"amount":"0.5"}`))
+		req.Header.Set("If-Match", `"3"`)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.UpdateItem(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, repository.ErrAmountPrecision.Fields, response.Fields)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		req := httptest.NewRequest(http.MethodPut, "/items/1", bytes.NewBufferString("invalid"))
+		req.Header.Set("If-Match", `"3"`)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.UpdateItem(c)
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
-		mockService.AssertNotCalled(t, "CSVAllItems")
+		mockService.AssertNotCalled(t, "UpdateItem")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		item := dto.UpdateItem{}
+		mockService.On("UpdateItem", mock.Anything, mock.Anything, mock.Anything, 1, 3, item).Return(fmt.Errorf("could not update item: %w", repository.ErrNoSuchItem))
+
+		body, _ := json.Marshal(item)
+		req := httptest.NewRequest(http.MethodPut, "/items/1", bytes.NewBuffer(body))
+		req.Header.Set("If-Match", `"3"`)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.UpdateItem(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "item_not_found", response.Code)
+		mockService.AssertExpectations(t)
 	})
 
 	t.Run("service error", func(t *testing.T) {
 		mockService := &mockTrackerService{}
 		handler := New(context.Background(), mockService)
-		params := dto.GetItemsParams{}
-		mockService.On("CSVAllItems", mock.Anything, params).Return("", assert.AnError)
+		id := 1
+		item := dto.UpdateItem{}
+		mockService.On("UpdateItem", mock.Anything, mock.Anything, mock.Anything, id, 3, item).Return(assert.AnError)
 
-		req := httptest.NewRequest(http.MethodGet, "/items/csv", nil)
+		body, _ := json.Marshal(item)
+		req := httptest.NewRequest(http.MethodPut, "/items/1", bytes.NewBuffer(body))
+		req.Header.Set("If-Match", `"3"`)
+		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
 
 		c, _ := gin.CreateTestContext(w)
 		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
 
-		handler.GetFilteredCSV(c)
+		handler.UpdateItem(c)
 
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		mockService.AssertExpectations(t)
 	})
 }
 
-func stringPtr(s string) *string {
-	return &s
+func TestDeleteItem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		id := 1
+		mockService.On("DeleteItem", mock.Anything, mock.Anything, mock.Anything, id, 3).Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/items/1", nil)
+		req.Header.Set("If-Match", `"3"`)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.DeleteItem(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("any version", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("DeleteItem", mock.Anything, mock.Anything, mock.Anything, 1, 0).Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/items/1", nil)
+		req.Header.Set("If-Match", "*")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.DeleteItem(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("version conflict", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("DeleteItem", mock.Anything, mock.Anything, mock.Anything, 1, 3).Return(repository.ErrVersionConflict)
+
+		req := httptest.NewRequest(http.MethodDelete, "/items/1", nil)
+		req.Header.Set("If-Match", `"3"`)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.DeleteItem(c)
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("malformed if-match", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		req := httptest.NewRequest(http.MethodDelete, "/items/1", nil)
+		req.Header.Set("If-Match", "3")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.DeleteItem(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "invalid_if_match")
+		mockService.AssertNotCalled(t, "DeleteItem")
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		req := httptest.NewRequest(http.MethodDelete, "/items/invalid", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "invalid"}}
+
+		handler.DeleteItem(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "DeleteItem")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("DeleteItem", mock.Anything, mock.Anything, mock.Anything, 1, 3).Return(repository.ErrNoSuchItem)
+
+		req := httptest.NewRequest(http.MethodDelete, "/items/1", nil)
+		req.Header.Set("If-Match", `"3"`)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.DeleteItem(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "item_not_found", response.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		id := 1
+		mockService.On("DeleteItem", mock.Anything, mock.Anything, mock.Anything, id, 3).Return(assert.AnError)
+
+		req := httptest.NewRequest(http.MethodDelete, "/items/1", nil)
+		req.Header.Set("If-Match", `"3"`)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.DeleteItem(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetItem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		item := &model.Item{ID: 1, Type: "доход", Amount: decimal.RequireFromString("100"), Version: 3}
+		mockService.On("GetItem", mock.Anything, mock.Anything, 1).Return(item, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/items/1", nil)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.GetItem(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+		var response dto.ItemWithoutAggregated
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 3, response.Version)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("GetItem", mock.Anything, mock.Anything, 2).Return(nil, repository.ErrNoSuchItem)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/items/2", nil)
+		c.Params = gin.Params{{Key: "id", Value: "2"}}
+
+		handler.GetItem(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetAggregatedCSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.AnalyticsParams{From: "2023-01-01", To: "2023-12-31"}
+		content := "id,type,amount\n1,доход,100.00\n"
+		mockService.On("Exporters").Return(testExportFormats)
+		mockService.On("ExportAggregated", mock.Anything, mock.Anything, "csv", params, dto.ExportOptions{}).Return(content, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/analytics/csv?from=2023-01-01&to=2023-12-31", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetAggregatedCSV(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, content, w.Body.String())
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment; filename=aggregated_data.csv", w.Header().Get("Content-Disposition"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		req := httptest.NewRequest(http.MethodGet, "/analytics/csv?from=invalid&to=2023-12-31", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetAggregatedCSV(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ExportAggregated")
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.AnalyticsParams{From: "2023-01-01", To: "2023-12-31"}
+		mockService.On("Exporters").Return(testExportFormats)
+		mockService.On("ExportAggregated", mock.Anything, mock.Anything, "csv", params, dto.ExportOptions{}).Return("", assert.AnError)
+
+		req := httptest.NewRequest(http.MethodGet, "/analytics/csv?from=2023-01-01&to=2023-12-31", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetAggregatedCSV(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("error after data sent", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("Exporters").Return(testExportFormats)
+		mockService.On("ExportAggregated", mock.Anything, mock.Anything, "csv", dto.AnalyticsParams{}, dto.ExportOptions{}).Return("id,type\n", assert.AnError)
+
+		req := httptest.NewRequest(http.MethodGet, "/analytics/csv", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		// The connection is dropped, so the client does not take the
+		// truncated file for a complete one.
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() { handler.GetAggregatedCSV(c) })
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "id,type\n", w.Body.String())
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetFilteredCSV(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.GetItemsParams{SortBy: []string{"date"}}
+		content := "id,type,amount\n1,доход,100.00\n"
+		mockService.On("Exporters").Return(testExportFormats)
+		mockService.On("ExportItems", mock.Anything, mock.Anything, "csv", params, dto.ExportOptions{}).Return(content, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/items/csv?sort_by=date", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetFilteredCSV(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, content, w.Body.String())
+		assert.Equal(t, "attachment; filename=filtered_data.csv", w.Header().Get("Content-Disposition"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid sort_by", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		req := httptest.NewRequest(http.MethodGet, "/items/csv?sort_by=invalid", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetFilteredCSV(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ExportItems")
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.GetItemsParams{}
+		mockService.On("Exporters").Return(testExportFormats)
+		mockService.On("ExportItems", mock.Anything, mock.Anything, "csv", params, dto.ExportOptions{}).Return("", assert.AnError)
+
+		req := httptest.NewRequest(http.MethodGet, "/items/csv", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetFilteredCSV(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetFilteredCSVOptions(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		options := dto.ExportOptions{
+			Delimiter:        ';',
+			BOM:              true,
+			DecimalSeparator: ",",
+			DateFormat:       "ru",
+			Language:         "ru",
+			Columns:          []string{"date", "amount", "category"},
+		}
+		mockService.On("Exporters").Return(testExportFormats)
+		mockService.On("ExportItems", mock.Anything, mock.Anything, "csv", dto.GetItemsParams{}, options).Return("data", nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/items/csv?delimiter=%3B&bom=true&decimal_separator=,&date_format=ru&lang=ru&columns=date,amount&columns=category", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetFilteredCSV(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, query := range []string{"delimiter=x", "bom=maybe", "decimal_separator=%3B", "date_format=us", "lang=de"} {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+			mockService.On("Exporters").Return(testExportFormats)
+
+			req := httptest.NewRequest(http.MethodGet, "/items/csv?"+query, nil)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.GetFilteredCSV(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+			mockService.AssertNotCalled(t, "ExportItems")
+		}
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		options := dto.ExportOptions{Columns: []string{"sum"}}
+		mockService.On("Exporters").Return(testExportFormats)
+		mockService.On("ExportItems", mock.Anything, mock.Anything, "csv", dto.GetItemsParams{}, options).
+			Return("", fmt.Errorf("%w: unknown column \"sum\"", service.ErrInvalidExportOptions))
+
+		req := httptest.NewRequest(http.MethodGet, "/items/csv?columns=sum", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetFilteredCSV(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetAggregatedXLSX(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.AnalyticsParams{From: "2023-01-01", To: "2023-12-31"}
+		mockService.On("Exporters").Return(testExportFormats)
+		mockService.On("ExportAggregated", mock.Anything, mock.Anything, "xlsx", params, dto.ExportOptions{}).Return("PK", nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/analytics/xlsx?from=2023-01-01&to=2023-12-31", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetAggregatedXLSX(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "PK", w.Body.String())
+		assert.Equal(t, testExportFormats[2].ContentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment; filename=aggregated_data.xlsx", w.Header().Get("Content-Disposition"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("missing exchange rate", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.AnalyticsParams{Currency: "USD"}
+		mockService.On("Exporters").Return(testExportFormats)
+		mockService.On("ExportAggregated", mock.Anything, mock.Anything, "xlsx", params, dto.ExportOptions{}).Return("", repository.ErrNoExchangeRate)
+
+		req := httptest.NewRequest(http.MethodGet, "/analytics/xlsx?currency=USD", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetAggregatedXLSX(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetFilteredXLSX(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.GetItemsParams{Category: "food"}
+		mockService.On("Exporters").Return(testExportFormats)
+		mockService.On("ExportItems", mock.Anything, mock.Anything, "xlsx", params, dto.ExportOptions{}).Return("PK", nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/items/xlsx?category=food", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetFilteredXLSX(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "attachment; filename=filtered_data.xlsx", w.Header().Get("Content-Disposition"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.GetItemsParams{}
+		mockService.On("Exporters").Return(testExportFormats)
+		mockService.On("ExportItems", mock.Anything, mock.Anything, "xlsx", params, dto.ExportOptions{}).Return("", assert.AnError)
+
+		req := httptest.NewRequest(http.MethodGet, "/items/xlsx", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetFilteredXLSX(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestCreateExchangeRate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		rate := dto.CreateExchangeRate{Base: "USD", Quote: "RUB", Date: "2023-01-01", Rate: decimal.RequireFromString("90.5")}
+		expected := &model.ExchangeRate{ID: 1, Base: "USD", Quote: "RUB", Date: "2023-01-01", Rate: decimal.RequireFromString("90.5")}
+		mockService.On("CreateExchangeRate", mock.Anything, 5, rate).Return(expected, nil)
+
+		body, _ := json.Marshal(rate)
+		req := httptest.NewRequest(http.MethodPost, "/rates", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set(ledgerKey, &model.Ledger{ID: 5, Role: model.RoleAdmin})
+
+		handler.CreateExchangeRate(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response model.ExchangeRate
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, *expected, response)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("validation error", func(t *testing.T) {
+		for _, rate := range []dto.CreateExchangeRate{
+			{Base: "USD", Quote: "USD", Date: "2023-01-01", Rate: decimal.NewFromInt(1)},
+			{Base: "ABC", Quote: "RUB", Date: "2023-01-01", Rate: decimal.NewFromInt(1)},
+			{Base: "USD", Quote: "RUB", Date: "2023-01-01", Rate: decimal.NewFromInt(-1)},
+			{Base: "USD", Quote: "RUB", Date: "2023-01-01", Rate: decimal.Zero},
+		} {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+			body, _ := json.Marshal(rate)
+			req := httptest.NewRequest(http.MethodPost, "/rates", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.CreateExchangeRate(c)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+			mockService.AssertNotCalled(t, "CreateExchangeRate")
+		}
+	})
+}
+
+func TestGetExchangeRates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.GetExchangeRatesParams{LedgerID: 5, Base: "USD"}
+		expected := []model.ExchangeRate{{ID: 1, Base: "USD", Quote: "RUB", Date: "2023-01-01", Rate: decimal.RequireFromString("90.5")}}
+		mockService.On("GetExchangeRates", mock.Anything, params).Return(expected, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/rates?base=USD", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set(ledgerKey, &model.Ledger{ID: 5, Role: model.RoleViewer})
+
+		handler.GetExchangeRates(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid currency", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		req := httptest.NewRequest(http.MethodGet, "/rates?quote=rub", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetExchangeRates(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "GetExchangeRates")
+	})
+}
+
+func TestDeleteExchangeRate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("DeleteExchangeRate", mock.Anything, 5, 1).Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/rates/1", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Set(ledgerKey, &model.Ledger{ID: 5, Role: model.RoleAdmin})
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+		handler.DeleteExchangeRate(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("DeleteExchangeRate", mock.Anything, 0, 2).Return(repository.ErrNoSuchRate)
+
+		req := httptest.NewRequest(http.MethodDelete, "/rates/2", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		c.Params = gin.Params{{Key: "id", Value: "2"}}
+
+		handler.DeleteExchangeRate(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestImportItems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	file := "type,amount,date,category\nдоход,100,2023-01-01,Зарплата\n"
+
+	t.Run("multipart file", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		report := &model.ImportReport{Total: 1, Valid: 1, Imported: 1, Errors: []model.ImportRowError{}}
+		var uploaded []byte
+		mockService.On("ImportItems", mock.Anything, mock.Anything, mock.Anything, mock.Anything, dto.ImportOptions{Columns: map[string]string{}}).
+			Run(func(args mock.Arguments) { uploaded, _ = io.ReadAll(args.Get(3).(io.Reader)) }).
+			Return(report, nil)
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("file", "items.csv")
+		part.Write([]byte(file))
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/items/import", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.ImportItems(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, file, string(uploaded))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("csv body with options", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		options := dto.ImportOptions{Columns: map[string]string{"amount": "Сумма"}, Delimiter: ';', DryRun: true}
+		report := &model.ImportReport{Total: 1, Valid: 1, DryRun: true, Errors: []model.ImportRowError{}}
+		mockService.On("ImportItems", mock.Anything, mock.Anything, mock.Anything, mock.Anything, options).Return(report, nil)
+
+		query := url.Values{"dry_run": {"true"}, "delimiter": {";"}, "columns[amount]": {"Сумма"}}
+		req := httptest.NewRequest(http.MethodPost, "/items/import?"+query.Encode(), bytes.NewBufferString(file))
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.ImportItems(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid rows", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		report := &model.ImportReport{Total: 1, Errors: []model.ImportRowError{{Line: 2, Error: "invalid amount"}}}
+		mockService.On("ImportItems", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(report, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/items/import", bytes.NewBufferString(file))
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.ImportItems(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response model.ImportReport
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, *report, response)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		err := fmt.Errorf("%w: missing column", service.ErrInvalidImport)
+		mockService.On("ImportItems", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return((*model.ImportReport)(nil), err)
+
+		req := httptest.NewRequest(http.MethodPost, "/items/import", bytes.NewBufferString(file))
+		req.Header.Set("Content-Type", "text/csv")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.ImportItems(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, query := range []string{"dry_run=maybe", "columns[id]=ID", "delimiter=:"} {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+			req := httptest.NewRequest(http.MethodPost, "/items/import?"+query, bytes.NewBufferString(file))
+			req.Header.Set("Content-Type", "text/csv")
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.ImportItems(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+			mockService.AssertNotCalled(t, "ImportItems")
+		}
+	})
+}
+
+func TestExecuteBatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	item := dto.CreateItem{Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test"}
+	operations := []dto.BatchOperation{
+		{Op: "create", Item: &item},
+		{Op: "update", ID: 1, Version: 1, Changes: &dto.UpdateItem{Category: stringPtr("food")}},
+		{Op: "delete", ID: 2, Version: 3},
+	}
+
+	newRequest := func(batch dto.Batch) (*gin.Context, *httptest.ResponseRecorder) {
+		body, _ := json.Marshal(batch)
+		req := httptest.NewRequest(http.MethodPost, "/items/batch", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		return c, w
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		results := []model.BatchResult{
+			{Index: 0, Op: "create", ID: 3, Status: model.BatchCreated},
+			{Index: 1, Op: "update", ID: 1, Status: model.BatchUpdated},
+			{Index: 2, Op: "delete", ID: 2, Status: model.BatchDeleted},
+		}
+		mockService.On("ExecuteBatch", mock.Anything, mock.Anything, mock.Anything, operations).Return(results, nil)
+
+		c, w := newRequest(dto.Batch{Operations: operations})
+		handler.ExecuteBatch(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response dto.BatchResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, results, response.Results)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid operations", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		invalid := []dto.BatchOperation{
+			operations[0],
+			{Op: "update", Changes: &dto.UpdateItem{Currency: stringPtr("RUR")}},
+			{Op: "delete"},
+			{Op: "move", ID: 1},
+			{Op: "delete", ID: 2},
+			{Op: "create", Version: 1, Item: &item},
+		}
+
+		c, w := newRequest(dto.Batch{Operations: invalid})
+		handler.ExecuteBatch(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response dto.BatchResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		statuses := make([]string, len(response.Results))
+		for i, result := range response.Results {
+			statuses[i] = result.Status
+		}
+		assert.Equal(t, []string{model.BatchSkipped, model.BatchInvalid, model.BatchInvalid, model.BatchInvalid, model.BatchInvalid, model.BatchInvalid}, statuses)
+		mockService.AssertNotCalled(t, "ExecuteBatch")
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+
+		c, w := newRequest(dto.Batch{})
+		handler.ExecuteBatch(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertNotCalled(t, "ExecuteBatch")
+	})
+
+	t.Run("operation failed", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		err := &repository.BatchError{Index: 1, Err: repository.ErrNoSuchItem}
+		mockService.On("ExecuteBatch", mock.Anything, mock.Anything, mock.Anything, operations).Return([]model.BatchResult(nil), err)
+
+		c, w := newRequest(dto.Batch{Operations: operations})
+		handler.ExecuteBatch(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		var response dto.BatchResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, []model.BatchResult{
+			{Index: 0, Op: "create", Status: model.BatchRolledBack},
+			{Index: 1, Op: "update", ID: 1, Status: model.BatchFailed, Error: repository.ErrNoSuchItem.Error()},
+			{Index: 2, Op: "delete", ID: 2, Status: model.BatchSkipped},
+		}, response.Results)
+		assert.Equal(t, "item_not_found", response.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("service error", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("ExecuteBatch", mock.Anything, mock.Anything, mock.Anything, operations).Return([]model.BatchResult(nil), assert.AnError)
+
+		c, w := newRequest(dto.Batch{Operations: operations})
+		handler.ExecuteBatch(c)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestGetStatement(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.StatementParams{Month: "2024-02", Currency: "RUB"}
+		mockService.On("StatementPDF", mock.Anything, mock.Anything, params).Return("%PDF-1.3", nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/reports/statement?month=2024-02&currency=RUB", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetStatement(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment; filename=statement_2024-02.pdf", w.Header().Get("Content-Disposition"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid month", func(t *testing.T) {
+		for _, query := range []string{"", "?month=2024-13", "?month=2024-02-01", "?month=2024-02&currency=XXXX"} {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+
+			req := httptest.NewRequest(http.MethodGet, "/reports/statement"+query, nil)
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.GetStatement(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+			mockService.AssertNotCalled(t, "StatementPDF")
+		}
+	})
+
+	t.Run("missing exchange rate", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.StatementParams{Month: "2024-02", Currency: "USD"}
+		mockService.On("StatementPDF", mock.Anything, mock.Anything, params).Return("", repository.ErrNoExchangeRate)
+
+		req := httptest.NewRequest(http.MethodGet, "/reports/statement?month=2024-02&currency=USD", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetStatement(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestGetAllItemsExport(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		accept      string
+		format      string
+		contentType string
+		fileName    string
+	}{
+		{"format parameter", "?format=ndjson", "", "ndjson", "application/x-ndjson", "filtered_data.ndjson"},
+		{"accept header", "", "text/csv", "csv", "text/csv; charset=utf-8", "filtered_data.csv"},
+		{"accept quality", "", "text/csv;q=0.5, application/x-ndjson", "ndjson", "application/x-ndjson", "filtered_data.ndjson"},
+		{"format overrides accept", "?format=xlsx", "text/csv", "xlsx", testExportFormats[2].ContentType, "filtered_data.xlsx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+			// Pagination is not applied to exports.
+			mockService.On("Exporters").Return(testExportFormats)
+			mockService.On("ExportItems", mock.Anything, mock.Anything, tt.format, dto.GetItemsParams{Type: "доход"}, dto.ExportOptions{}).Return("data", nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/items"+tt.query, nil)
+			q := req.URL.Query()
+			q.Set("type", "доход")
+			q.Set("limit", "10")
+			req.URL.RawQuery = q.Encode()
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.GetAllItems(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "data", w.Body.String())
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, "attachment; filename="+tt.fileName, w.Header().Get("Content-Disposition"))
+			mockService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("unknown format", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("Exporters").Return(testExportFormats)
+
+		req := httptest.NewRequest(http.MethodGet, "/items?format=pdf", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetAllItems(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "ExportItems")
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("Exporters").Return(testExportFormats)
+
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("Accept", "image/png")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetAllItems(c)
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		mockService.AssertNotCalled(t, "ExportItems")
+	})
+}
+
+func TestGetAggregatedExport(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.AnalyticsParams{From: "2023-01-01"}
+		mockService.On("Exporters").Return(testExportFormats)
+		mockService.On("ExportAggregated", mock.Anything, mock.Anything, "ndjson", params, dto.ExportOptions{}).Return("{}\n", nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/analytics?from=2023-01-01", nil)
+		req.Header.Set("Accept", "application/x-ndjson")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetAggregated(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "attachment; filename=aggregated_data.ndjson", w.Header().Get("Content-Disposition"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("summary view", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("Exporters").Return(testExportFormats)
+
+		req := httptest.NewRequest(http.MethodGet, "/analytics?view=summary&format=csv", nil)
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.GetAggregated(c)
+
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		mockService.AssertNotCalled(t, "GetSummary")
+	})
+}
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "text/csv", "application/x-ndjson"}
+
+	assert.Equal(t, 0, negotiate("*/*", offers))
+	assert.Equal(t, 0, negotiate("text/html, application/xhtml+xml, */*;q=0.8", offers))
+	assert.Equal(t, 1, negotiate("text/*", offers))
+	assert.Equal(t, 2, negotiate("text/csv;q=0.2, application/x-ndjson;q=0.9", offers))
+	assert.Equal(t, 1, negotiate("application/json;q=0, text/csv", offers))
+	assert.Equal(t, -1, negotiate("image/png", offers))
+}
+
+func TestAuthenticate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := &mockTrackerService{}
+	handler := New(context.Background(), mockService)
+	user := &model.User{ID: 1, Login: "admin"}
+	mockService.On("Authenticate", "valid").Return(user, nil)
+	mockService.On("Authenticate", "forged").Return((*model.User)(nil), service.ErrInvalidToken)
+
+	router := gin.New()
+	router.GET("/items", handler.Authenticate, func(c *gin.Context) {
+		c.JSON(http.StatusOK, currentUser(c))
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{"valid token", "Bearer valid", http.StatusOK},
+		{"lowercase scheme", "bearer valid", http.StatusOK},
+		{"missing header", "", http.StatusUnauthorized},
+		{"basic scheme", "Basic YWRtaW46cGFzcw==", http.StatusUnauthorized},
+		{"invalid token", "Bearer forged", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/items", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				var response model.User
+				json.Unmarshal(w.Body.Bytes(), &response)
+				assert.Equal(t, *user, response)
+			} else {
+				assert.Equal(t, `Bearer realm="sales-tracker"`, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	credentials := dto.Credentials{Login: "admin", Password: "password123"}
+
+	t.Run("success", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		token := &model.Token{Token: "token", ExpiresAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+		mockService.On("Login", mock.Anything, credentials).Return(token, nil)
+
+		body := `{"login":"admin","password":"password123"}`
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.Login(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"token":"token","expires_at":"2024-01-02T00:00:00Z"}`, w.Body.String())
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("Login", mock.Anything, credentials).Return((*model.Token)(nil), service.ErrInvalidCredentials)
+
+		body := `{"login":"admin","password":"password123"}`
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.Login(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("short password", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+
+		body := `{"login":"admin","password":"short"}`
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.Login(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertNotCalled(t, "Login")
+	})
+}
+
+func TestRegister(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	credentials := dto.Credentials{Login: "accountant", Password: "password123"}
+	body := `{"login":"accountant","password":"password123"}`
+	admin := &model.User{ID: 1, Login: "admin"}
+
+	tests := []struct {
+		name          string
+		authorization string
+		registeredBy  *model.User
+		err           error
+		status        int
+	}{
+		{"first user", "", nil, nil, http.StatusCreated},
+		{"by authenticated user", "Bearer valid", admin, nil, http.StatusCreated},
+		{"registration closed", "", nil, repository.ErrRegistrationClosed, http.StatusForbidden},
+		{"by user other than admin", "Bearer valid", admin, repository.ErrRegistrationClosed, http.StatusForbidden},
+		{"login taken", "Bearer valid", admin, repository.ErrUserExists, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+			mockService.On("Authenticate", "valid").Return(admin, nil)
+			mockService.On("Register", mock.Anything, credentials, tt.registeredBy).
+				Return(&model.User{ID: 2, Login: "accountant"}, tt.err)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBufferString(body))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			handler.Register(c)
+
+			assert.Equal(t, tt.status, w.Code)
+			mockService.AssertCalled(t, "Register", mock.Anything, credentials, tt.registeredBy)
+		})
+	}
+
+	t.Run("invalid token", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("Authenticate", "forged").Return((*model.User)(nil), service.ErrInvalidToken)
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/register", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer forged")
+		w := httptest.NewRecorder()
+
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+
+		handler.Register(c)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		mockService.AssertNotCalled(t, "Register")
+	})
+}
+
+func TestSelectLedger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &model.User{ID: 1, Login: "admin"}
+	item := dto.CreateItem{Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test"}
+	body := `{"type":"доход","amount":"100","date":"2023-01-01","category":"test"}`
+
+	newRouter := func(handler *Handler) *gin.Engine {
+		router := gin.New()
+		router.POST("/items", func(c *gin.Context) { c.Set(userKey, user) }, handler.SelectLedger, handler.CreateItem)
+		return router
+	}
+
+	tests := []struct {
+		name     string
+		header   string
+		ledgerID int
+		ledger   *model.Ledger
+		err      error
+		status   int
+	}{
+		{"selected ledger", "5", 5, &model.Ledger{ID: 5, Name: "Семья"}, nil, http.StatusOK},
+		{"default ledger", "", 0, &model.Ledger{ID: 3, Name: "admin"}, nil, http.StatusOK},
+		{"not a member", "7", 7, nil, repository.ErrNoSuchLedger, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+			mockService.On("GetLedger", mock.Anything, user.ID, tt.ledgerID).Return(tt.ledger, tt.err)
+			if tt.ledger != nil {
+				mockService.On("CreateItem", mock.Anything, mock.Anything, tt.ledger.ID, item).Return(&model.Item{ID: 1}, nil)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/items", bytes.NewBufferString(body))
+			if tt.header != "" {
+				req.Header.Set("X-Ledger-ID", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			newRouter(handler).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.ledger != nil {
+				assert.Equal(t, fmt.Sprint(tt.ledger.ID), w.Header().Get("X-Ledger-ID"))
+			} else {
+				mockService.AssertNotCalled(t, "CreateItem")
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+
+	t.Run("invalid header", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+
+		req := httptest.NewRequest(http.MethodPost, "/items", bytes.NewBufferString(body))
+		req.Header.Set("X-Ledger-ID", "family")
+		w := httptest.NewRecorder()
+
+		newRouter(handler).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "GetLedger")
+	})
+}
+
+func TestLedgers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &model.User{ID: 1, Login: "admin"}
+
+	t.Run("list", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("GetLedgers", mock.Anything, user.ID).Return([]model.Ledger(nil), nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/ledgers", nil)
+		c.Set(userKey, user)
+
+		handler.GetLedgers(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+	})
+
+	t.Run("create", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("CreateLedger", mock.Anything, user.ID, "Семья").Return(&model.Ledger{ID: 5, Name: "Семья"}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/ledgers", bytes.NewBufferString(`{"name":"Семья"}`))
+		c.Set(userKey, user)
+
+		handler.CreateLedger(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("empty name", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/ledgers", bytes.NewBufferString(`{"name":""}`))
+		c.Set(userKey, user)
+
+		handler.CreateLedger(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertNotCalled(t, "CreateLedger")
+	})
+}
+
+func TestAccessControl(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &model.User{ID: 1, Login: "admin"}
+	body := `{"type":"доход","amount":"100","date":"2023-01-01","category":"test"}`
+
+	rate := `{"base":"USD","quote":"RUB","date":"2023-01-01","rate":90.5}`
+
+	newRouter := func(handler *Handler) *gin.Engine {
+		router := gin.New()
+		authenticated := func(c *gin.Context) { c.Set(userKey, user) }
+		router.GET("/items", authenticated, handler.SelectLedger, handler.GetAllItems)
+		router.POST("/items", authenticated, handler.SelectLedger, handler.CreateItem)
+		router.POST("/ledgers/:ledger_id/members", authenticated, handler.SelectLedger, handler.AddMember)
+		router.POST("/rates", authenticated, handler.SelectLedger, handler.CreateExchangeRate)
+		return router
+	}
+
+	tests := []struct {
+		name   string
+		role   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"viewer reads items", model.RoleViewer, http.MethodGet, "/items", "", http.StatusOK},
+		{"viewer creates item", model.RoleViewer, http.MethodPost, "/items", body, http.StatusForbidden},
+		{"editor creates item", model.RoleEditor, http.MethodPost, "/items", body, http.StatusOK},
+		{"editor invites member", model.RoleEditor, http.MethodPost, "/ledgers/5/members", `{"login":"guest","role":"viewer"}`, http.StatusForbidden},
+		{"admin invites member", model.RoleAdmin, http.MethodPost, "/ledgers/5/members", `{"login":"guest","role":"viewer"}`, http.StatusCreated},
+		{"editor creates rate", model.RoleEditor, http.MethodPost, "/rates", rate, http.StatusForbidden},
+		{"admin creates rate", model.RoleAdmin, http.MethodPost, "/rates", rate, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), WithAccessControl(mockService))
+			mockService.On("GetLedger", mock.Anything, user.ID, mock.Anything).Return(&model.Ledger{ID: 5, Name: "Семья", Role: tt.role}, nil)
+			mockService.On("GetItemsPage", mock.Anything, mock.Anything).Return(&model.ItemsPage{}, nil)
+			mockService.On("CreateItem", mock.Anything, mock.Anything, 5, mock.Anything).Return(&model.Item{ID: 1}, nil)
+			mockService.On("AddMember", mock.Anything, 5, mock.Anything).Return(&model.Member{UserID: 2, Login: "guest", Role: model.RoleViewer}, nil)
+			mockService.On("CreateExchangeRate", mock.Anything, 5, mock.Anything).Return(&model.ExchangeRate{ID: 1}, nil)
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("X-Ledger-ID", "5")
+			w := httptest.NewRecorder()
+			newRouter(handler).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), "role is required")
+				mockService.AssertNotCalled(t, "CreateItem", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				mockService.AssertNotCalled(t, "AddMember", mock.Anything, mock.Anything, mock.Anything)
+				mockService.AssertNotCalled(t, "CreateExchangeRate", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+
+	t.Run("other ledger", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), WithAccessControl(mockService))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodDelete, "/items/1", nil)
+		c.Params = gin.Params{{Key: "id", Value: "1"}}
+		c.Set(userKey, user)
+		c.Set(ledgerKey, &model.Ledger{ID: 5, Role: model.RoleAdmin})
+
+		err := handler.service.DeleteItem(handler.requestContext(c), currentActor(c), 7, 1, 0)
+		assert.ErrorIs(t, err, ErrForbidden)
+		mockService.AssertNotCalled(t, "DeleteItem", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestMembers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &model.User{ID: 1, Login: "admin"}
+	ledger := &model.Ledger{ID: 5, Name: "Семья", Role: model.RoleAdmin}
+
+	newContext := func(method, path, body string, params gin.Params) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		c.Params = params
+		c.Set(userKey, user)
+		c.Set(ledgerKey, ledger)
+		return c, w
+	}
+
+	t.Run("list", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("GetMembers", mock.Anything, ledger.ID).Return([]model.Member(nil), nil)
+
+		c, w := newContext(http.MethodGet, "/ledgers/5/members", "", nil)
+		handler.GetMembers(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+	})
+
+	t.Run("invite unknown user", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		member := dto.AddMember{Login: "guest", Role: model.RoleEditor}
+		mockService.On("AddMember", mock.Anything, ledger.ID, member).Return((*model.Member)(nil), repository.ErrNoSuchUser)
+
+		c, w := newContext(http.MethodPost, "/ledgers/5/members", `{"login":"guest","role":"editor"}`, nil)
+		handler.AddMember(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid role", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+
+		c, w := newContext(http.MethodPost, "/ledgers/5/members", `{"login":"guest","role":"owner"}`, nil)
+		handler.AddMember(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		mockService.AssertNotCalled(t, "AddMember", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("demote last admin", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("UpdateMemberRole", mock.Anything, ledger.ID, 1, model.RoleViewer).Return(repository.ErrLastAdmin)
+
+		c, w := newContext(http.MethodPut, "/ledgers/5/members/1", `{"role":"viewer"}`, gin.Params{{Key: "user_id", Value: "1"}})
+		handler.UpdateMember(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("RemoveMember", mock.Anything, ledger.ID, 2).Return(nil)
+
+		c, w := newContext(http.MethodDelete, "/ledgers/5/members/2", "", gin.Params{{Key: "user_id", Value: "2"}})
+		handler.RemoveMember(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("invalid user id", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+
+		c, w := newContext(http.MethodDelete, "/ledgers/5/members/x", "", gin.Params{{Key: "user_id", Value: "x"}})
+		handler.RemoveMember(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestCategories(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &model.User{ID: 1, Login: "editor"}
+	ledger := &model.Ledger{ID: 5, Name: "Семья", Role: model.RoleEditor}
+	parentID := 1
+
+	newContext := func(method, path, body string, params gin.Params) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		c.Params = params
+		c.Set(userKey, user)
+		c.Set(ledgerKey, ledger)
+		return c, w
+	}
+
+	t.Run("list", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("GetCategories", mock.Anything, ledger.ID).Return([]model.Category(nil), nil)
+
+		c, w := newContext(http.MethodGet, "/categories", "", nil)
+		handler.GetCategories(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+	})
+
+	t.Run("create subcategory", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		category := dto.CreateCategory{Name: "Кафе", ParentID: &parentID, Type: "расход", Aliases: []string{"Cafe"}}
+		created := &model.Category{ID: 2, ParentID: &parentID, Name: "Кафе", Type: "расход", Aliases: []string{"Cafe"}}
+		mockService.On("CreateCategory", mock.Anything, ledger.ID, category).Return(created, nil)
+
+		c, w := newContext(http.MethodPost, "/categories", `{"name":"Кафе","parent_id":1,"type":"расход","aliases":["Cafe"]}`, nil)
+		handler.CreateCategory(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response model.Category
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, *created, response)
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			body  string
+			field string
+		}{
+			{"blank name", `{"name":"  "}`, "name"},
+			{"unknown type", `{"name":"Кафе","type":"прочее"}`, "type"},
+			{"duplicate aliases", `{"name":"Кафе","aliases":["Cafe","Cafe"]}`, "aliases"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := &mockTrackerService{}
+				handler := New(context.Background(), mockService)
+
+				c, w := newContext(http.MethodPost, "/categories", tt.body, nil)
+				handler.CreateCategory(c)
+
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				var response dto.ErrorResponse
+				json.Unmarshal(w.Body.Bytes(), &response)
+				if assert.Len(t, response.Fields, 1) {
+					assert.Equal(t, tt.field, response.Fields[0].Field)
+				}
+				mockService.AssertNotCalled(t, "CreateCategory", mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("name taken", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("CreateCategory", mock.Anything, ledger.ID, dto.CreateCategory{Name: "Еда"}).Return((*model.Category)(nil), repository.ErrCategoryExists)
+
+		c, w := newContext(http.MethodPost, "/categories", `{"name":"Еда"}`, nil)
+		handler.CreateCategory(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("update cycle", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		category := dto.UpdateCategory{Name: "Еда", ParentID: &parentID}
+		mockService.On("UpdateCategory", mock.Anything, mock.Anything, ledger.ID, 3, category).Return((*model.Category)(nil), repository.ErrCategoryCycle)
+
+		c, w := newContext(http.MethodPut, "/categories/3", `{"name":"Еда","parent_id":1}`, gin.Params{{Key: "id", Value: "3"}})
+		handler.UpdateCategory(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "category_cycle", response.Code)
+		assert.Equal(t, "parent_id", response.Fields[0].Field)
+	})
+
+	t.Run("delete with replacement", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("DeleteCategory", mock.Anything, model.Actor{UserID: user.ID}, ledger.ID, 3, 1).Return(nil)
+
+		c, w := newContext(http.MethodDelete, "/categories/3?replace_with=1", "", gin.Params{{Key: "id", Value: "3"}})
+		handler.DeleteCategory(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("delete category in use", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("DeleteCategory", mock.Anything, mock.Anything, ledger.ID, 3, 0).Return(repository.ErrCategoryInUse)
+
+		c, w := newContext(http.MethodDelete, "/categories/3", "", gin.Params{{Key: "id", Value: "3"}})
+		handler.DeleteCategory(c)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("invalid replacement", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+
+		c, w := newContext(http.MethodDelete, "/categories/3?replace_with=food", "", gin.Params{{Key: "id", Value: "3"}})
+		handler.DeleteCategory(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "DeleteCategory", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCategorisationRules(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &model.User{ID: 1, Login: "editor"}
+	ledger := &model.Ledger{ID: 5, Name: "Семья", Role: model.RoleEditor}
+
+	newContext := func(method, path, body string, params gin.Params) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		c.Params = params
+		c.Set(userKey, user)
+		c.Set(ledgerKey, ledger)
+		return c, w
+	}
+
+	t.Run("list", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("GetRules", mock.Anything, ledger.ID).Return([]model.Rule(nil), nil)
+
+		c, w := newContext(http.MethodGet, "/rules", "", nil)
+		handler.GetRules(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+	})
+
+	t.Run("create", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		bound := decimal.NewFromInt(1000)
+		rule := dto.CreateRule{Priority: 10, Category: "Кафе", Description: "кофе|coffee", Regex: true, Type: "расход", AmountMax: &bound}
+		created := &model.Rule{ID: 1, Priority: 10, Category: "Кафе", Description: "кофе|coffee", Regex: true, Type: "расход", AmountMax: &bound}
+		mockService.On("CreateRule", mock.Anything, ledger.ID, rule).Return(created, nil)
+
+		c, w := newContext(http.MethodPost, "/rules", `{"priority":10,"category":"Кафе","description":"кофе|coffee","regex":true,"type":"расход","amount_max":"1000"}`, nil)
+		handler.CreateRule(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var response model.Rule
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, 1, response.ID)
+		assert.True(t, bound.Equal(*response.AmountMax))
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+
+		c, w := newContext(http.MethodPost, "/rules", `{"category":" ","type":"прочее"}`, nil)
+		handler.CreateRule(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Len(t, response.Fields, 2)
+		mockService.AssertNotCalled(t, "CreateRule", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("update unknown category", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		rule := dto.UpdateRule{Category: "Кофейни", Counterparty: "Starbucks"}
+		mockService.On("UpdateRule", mock.Anything, ledger.ID, 2, rule).Return((*model.Rule)(nil), repository.ErrUnknownCategory)
+
+		c, w := newContext(http.MethodPut, "/rules/2", `{"category":"Кофейни","counterparty":"Starbucks"}`, gin.Params{{Key: "id", Value: "2"}})
+		handler.UpdateRule(c)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var response dto.ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "unknown_category", response.Code)
+	})
+
+	t.Run("delete missing rule", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("DeleteRule", mock.Anything, ledger.ID, 9).Return(repository.ErrNoSuchRule)
+
+		c, w := newContext(http.MethodDelete, "/rules/9", "", gin.Params{{Key: "id", Value: "9"}})
+		handler.DeleteRule(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("test", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		sample := dto.RuleSample{Type: "расход", Amount: decimal.NewFromInt(250), Description: "Coffee"}
+		mockService.On("TestRules", mock.Anything, ledger.ID, sample).Return(&model.RuleMatch{}, nil)
+
+		c, w := newContext(http.MethodPost, "/rules/test", `{"type":"расход","amount":"250","description":"Coffee"}`, nil)
+		handler.TestRules(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"rule": null}`, w.Body.String())
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		params := dto.ApplyRulesParams{LedgerID: ledger.ID, DateFrom: "2024-01-01", DryRun: true}
+		report := &model.RulesReport{Checked: 2, DryRun: true, Changes: []model.RuleChange{{ItemID: 1, RuleID: 3, From: "Прочее", To: "Кафе"}}}
+		mockService.On("ApplyRules", mock.Anything, model.Actor{UserID: user.ID}, params).Return(report, nil)
+
+		c, w := newContext(http.MethodPost, "/rules/apply?date_from=2024-01-01&dry_run=true", "", nil)
+		handler.ApplyRules(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response model.RulesReport
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, *report, response)
+	})
+
+	t.Run("apply invalid query", func(t *testing.T) {
+		for _, query := range []string{"date_from=01.01.2024", "dry_run=maybe", "date_from=2024-02-01&date_to=2024-01-01"} {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+
+			c, w := newContext(http.MethodPost, "/rules/apply?"+query, "", nil)
+			handler.ApplyRules(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+			mockService.AssertNotCalled(t, "ApplyRules", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestAPIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &model.User{ID: 1, Login: "admin"}
+	apiKey := &model.APIKey{ID: 2, UserID: user.ID, Prefix: "st_abcdefgh", Scopes: []string{model.ScopeItemsWrite}}
+	body := `{"type":"доход","amount":"100","date":"2023-01-01","category":"test"}`
+
+	newRouter := func(handler *Handler) *gin.Engine {
+		router := gin.New()
+		router.GET("/items", handler.Authenticate, handler.SelectLedger, handler.GetAllItems)
+		router.POST("/items", handler.Authenticate, handler.SelectLedger, handler.CreateItem)
+		router.GET("/auth/keys", handler.Authenticate, handler.GetAPIKeys)
+		return router
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"scope allows", http.MethodPost, "/items", body, http.StatusOK},
+		{"scope missing", http.MethodGet, "/items", "", http.StatusForbidden},
+		{"session only", http.MethodGet, "/auth/keys", "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), WithAccessControl(mockService))
+			mockService.On("AuthenticateAPIKey", mock.Anything, "st_secret").Return(user, apiKey, nil)
+			mockService.On("GetLedger", mock.Anything, user.ID, 0).Return(&model.Ledger{ID: 5, Role: model.RoleEditor}, nil)
+			mockService.On("CreateItem", mock.Anything, mock.Anything, 5, mock.Anything).Return(&model.Item{ID: 1}, nil)
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Authorization", "Bearer st_secret")
+			w := httptest.NewRecorder()
+			newRouter(handler).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			mockService.AssertNotCalled(t, "Authenticate", mock.Anything)
+			mockService.AssertNotCalled(t, "GetItemsPage", mock.Anything, mock.Anything)
+			mockService.AssertNotCalled(t, "GetAPIKeys", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("create", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		createKey := dto.CreateAPIKey{Name: "bank sync", Scopes: []string{model.ScopeItemsWrite}}
+		mockService.On("CreateAPIKey", mock.Anything, user.ID, createKey).Return(&model.CreatedAPIKey{APIKey: *apiKey, Key: "st_secret"}, nil)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/auth/keys", bytes.NewBufferString(`{"name":"bank sync","scopes":["items:write"]}`))
+		c.Set(userKey, user)
+
+		handler.CreateAPIKey(c)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), `"key":"st_secret"`)
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		tests := []struct {
+			name string
+			body string
+		}{
+			{"unknown scope", `{"name":"sync","scopes":["items:delete"]}`},
+			{"no scopes", `{"name":"sync","scopes":[]}`},
+			{"expired", `{"name":"sync","scopes":["export"],"expires_at":"2020-01-01T00:00:00Z"}`},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := &mockTrackerService{}
+				handler := New(context.Background(), mockService)
+
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = httptest.NewRequest(http.MethodPost, "/auth/keys", bytes.NewBufferString(tt.body))
+				c.Set(userKey, user)
+
+				handler.CreateAPIKey(c)
+
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				mockService.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("revoke unknown", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("RevokeAPIKey", mock.Anything, user.ID, 9).Return(repository.ErrNoSuchAPIKey)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodDelete, "/auth/keys/9", nil)
+		c.Params = gin.Params{{Key: "id", Value: "9"}}
+		c.Set(userKey, user)
+
+		handler.RevokeAPIKey(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &model.User{ID: 1, Login: "admin"}
+	ledger := &model.Ledger{ID: 5, Role: model.RoleViewer}
+
+	newContext := func(path string) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, path, nil)
+		c.Set(userKey, user)
+		c.Set(ledgerKey, ledger)
+		return c, w
+	}
+
+	t.Run("actor", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		apiKey := &model.APIKey{ID: 7}
+		actor := model.Actor{UserID: user.ID, APIKeyID: &apiKey.ID}
+		mockService.On("DeleteItem", mock.Anything, actor, ledger.ID, 3, 1).Return(nil)
+
+		c, w := newContext("/items/3")
+		c.Request.Header.Set("If-Match", `"1"`)
+		c.Params = gin.Params{{Key: "id", Value: "3"}}
+		c.Set(apiKeyKey, apiKey)
+
+		handler.DeleteItem(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("GetItemHistory", mock.Anything, ledger.ID, 3).Return([]model.AuditEntry(nil), nil)
+
+		c, w := newContext("/items/3/history")
+		c.Params = gin.Params{{Key: "id", Value: "3"}}
+
+		handler.GetItemHistory(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC).Add(-time.Microsecond)
+		params := dto.AuditParams{
+			LedgerID: ledger.ID, ItemID: 3, UserID: 2, Operation: model.AuditUpdate,
+			From: &from, To: &to, BeforeID: 40, Limit: 10,
+		}
+		page := &model.AuditPage{Entries: []model.AuditEntry{{ID: 39, ItemID: 3, Operation: model.AuditUpdate}}, NextCursor: "39"}
+		mockService.On("GetAudit", mock.Anything, params).Return(page, nil)
+
+		c, w := newContext("/audit?item_id=3&user_id=2&operation=update&from=2024-01-01&to=2024-01-01&cursor=40&limit=10")
+		handler.GetAudit(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"next_cursor":"39"`)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		for _, query := range []string{"operation=rename", "item_id=x", "limit=1000", "cursor=-1", "from=2024-02-01&to=2024-01-01"} {
+			mockService := &mockTrackerService{}
+			handler := New(context.Background(), mockService)
+
+			c, w := newContext("/audit?" + query)
+			handler.GetAudit(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+			mockService.AssertNotCalled(t, "GetAudit", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestTrash(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ledger := &model.Ledger{ID: 5, Role: model.RoleEditor}
+
+	newContext := func(method, path string) (*gin.Context, *httptest.ResponseRecorder) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(method, path, nil)
+		c.Set(ledgerKey, ledger)
+		return c, w
+	}
+
+	t.Run("list", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		deletedAt := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		params := dto.GetItemsParams{LedgerID: ledger.ID, Type: "расход", Limit: defaultPageLimit, Deleted: true}
+		page := &model.ItemsPage{Items: []model.Item{{ID: 3, Type: "расход", DeletedAt: &deletedAt}}, Total: 1}
+		mockService.On("GetItemsPage", mock.Anything, params).Return(page, nil)
+
+		c, w := newContext(http.MethodGet, "/items/trash?type=расход")
+		handler.GetTrash(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"deleted_at":"2024-01-02T00:00:00Z"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("RestoreItem", mock.Anything, mock.Anything, ledger.ID, 3).Return(&model.Item{ID: 3}, nil)
+
+		c, w := newContext(http.MethodPost, "/items/3/restore")
+		c.Params = gin.Params{{Key: "id", Value: "3"}}
+		handler.RestoreItem(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("restore item not in trash", func(t *testing.T) {
+		mockService := &mockTrackerService{}
+		handler := New(context.Background(), mockService)
+		mockService.On("RestoreItem", mock.Anything, mock.Anything, ledger.ID, 4).Return((*model.Item)(nil), repository.ErrNoSuchItem)
+
+		c, w := newContext(http.MethodPost, "/items/4/restore")
+		c.Params = gin.Params{{Key: "id", Value: "4"}}
+		handler.RestoreItem(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestErrorResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    string
+		message string
+	}{
+		{"wrapped", fmt.Errorf("could not get item: %w", repository.ErrNoSuchItem), http.StatusNotFound, "item_not_found", "could not get item: " + repository.ErrNoSuchItem.Error()},
+		{"validation", repository.ErrNoExchangeRate, http.StatusUnprocessableEntity, "no_exchange_rate", repository.ErrNoExchangeRate.Error()},
+		{"conflict", repository.ErrLastAdmin, http.StatusConflict, "last_admin", repository.ErrLastAdmin.Error()},
+		{"invalid", service.ErrInvalidCursor, http.StatusBadRequest, "invalid_cursor", service.ErrInvalidCursor.Error()},
+		{"internal", assert.AnError, http.StatusInternalServerError, internalErrorCode, "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := errorResponse(tt.err)
+
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, dto.ErrorResponse{Error: tt.message, Code: tt.code}, response)
+		})
+	}
 }
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/Komilov31/sales-tracker/internal/service"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// maxImportSize limits size of uploaded CSV file.
+const maxImportSize = 10 << 20
+
+var (
+	errNoImportFile   = model.NewError(model.KindInvalid, "invalid_file", "could not get file from form")
+	errImportTooLarge = model.NewError(model.KindInvalid, "file_too_large", "file is too large")
+)
+
+// ImportItems godoc
+//
+//	@Summary		Import items from CSV
+//	@Description	Creates items from CSV in the export layout (header row with type, amount and date columns, optional category and currency, and description and counterparty of bank statements) in a single transaction. Files of every export dialect are accepted: headers in English or Russian, delimiter detected from the header row unless given, amounts with decimal comma and dates in Russian format. Rows without category are categorised by rules of ledger. Nothing is imported if any row is invalid, in which case the import report is returned with status 422.
+//	@Tags			items
+//	@Accept			multipart/form-data
+//	@Accept			text/csv
+//	@Produce		json
+//	@Param			file		formData	file	false	"CSV file, unless sent as text/csv body"
+//	@Param			dry_run		query		bool	false	"Only validate rows"
+//	@Param			delimiter	query		string	false	"CSV delimiter: , ; | or tab, detected by default"
+//	@Param			columns		query		string	false	"Column mapping as columns[field]=header, e.g. columns[amount]=Сумма"
+//	@Success		200			{object}	model.ImportReport	"Import report"
+//	@Failure		400			{object}	dto.ErrorResponse	"Invalid file or parameters"
+//	@Failure		422			{object}	model.ImportReport	"Invalid rows, nothing imported"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/items/import [post]
+func (h *Handler) ImportItems(c *ginext.Context) {
+	options, err := parseImportOptions(c)
+	if err != nil {
+		respondError(c, invalidQuery(err))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	var file io.Reader = c.Request.Body
+	if c.ContentType() == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			respondError(c, fmt.Errorf("%w: %v", errNoImportFile, err))
+			return
+		}
+
+		formFile, err := header.Open()
+		if err != nil {
+			respondError(c, fmt.Errorf("%w: %v", errNoImportFile, err))
+			return
+		}
+		defer formFile.Close()
+		file = formFile
+	}
+
+	report, err := h.service.ImportItems(h.requestContext(c), currentActor(c), currentLedgerID(c), file, options)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			err = fmt.Errorf("%w: %v", errImportTooLarge, err)
+		}
+		respondError(c, err)
+		return
+	}
+
+	if len(report.Errors) > 0 && !report.DryRun {
+		zlog.Logger.Error().Msg(fmt.Sprintf("could not import items: %d invalid rows", len(report.Errors)))
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled POST request and imported items")
+	c.JSON(http.StatusOK, report)
+}
+
+func parseImportOptions(c *ginext.Context) (dto.ImportOptions, error) {
+	var options dto.ImportOptions
+
+	if value := c.Query("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			return options, fmt.Errorf("invalid dry_run, must be boolean")
+		}
+		options.DryRun = dryRun
+	}
+
+	delimiter, err := parseDelimiter(c)
+	if err != nil {
+		return options, err
+	}
+	options.Delimiter = delimiter
+
+	options.Columns = c.QueryMap("columns")
+	for field := range options.Columns {
+		if !slices.Contains(service.ImportFields, field) {
+			return options, fmt.Errorf("unknown field in columns mapping: %s", field)
+		}
+	}
+
+	return options, nil
+}
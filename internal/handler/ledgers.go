@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+const (
+	// ledgerHeader selects ledger request works with, the oldest ledger of
+	// user is used when it is omitted.
+	ledgerHeader = "X-Ledger-ID"
+	// ledgerKey is the gin context key current ledger is stored under.
+	ledgerKey = "ledger"
+)
+
+// SelectLedger is a middleware resolving ledger of request for authenticated
+// user. Ledger is taken from ledger_id path parameter of route if it has one
+// and from X-Ledger-ID header otherwise. Ledgers user is not a member of are
+// reported as missing. The ledger used is echoed in X-Ledger-ID response
+// header.
+func (h *Handler) SelectLedger(c *ginext.Context) {
+	value, source := c.Param("ledger_id"), "ledger id"
+	if value == "" {
+		value, source = c.GetHeader(ledgerHeader), ledgerHeader+" header"
+	}
+
+	var ledgerID int
+	if value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			respondError(c, model.NewError(model.KindInvalid, "invalid_ledger_id", "invalid "+source))
+			return
+		}
+		ledgerID = id
+	}
+
+	ledger, err := h.service.GetLedger(h.requestContext(c), currentUser(c).ID, ledgerID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.Header(ledgerHeader, strconv.Itoa(ledger.ID))
+	c.Set(ledgerKey, ledger)
+	c.Next()
+}
+
+// currentLedgerID returns id of ledger set by SelectLedger middleware.
+func currentLedgerID(c *ginext.Context) int {
+	ledger, _ := c.Get(ledgerKey)
+	if l, ok := ledger.(*model.Ledger); ok {
+		return l.ID
+	}
+
+	return 0
+}
+
+// GetLedgers godoc
+//
+//	@Summary		Get ledgers
+//	@Description	Returns ledgers current user is a member of, the oldest first. The first one is used when X-Ledger-ID header is omitted
+//	@Tags			ledgers
+//	@Produce		json
+//	@Success		200		{array}		model.Ledger		"Ledgers of user"
+//	@Failure		401		{object}	dto.ErrorResponse	"Missing or invalid token"
+//	@Failure		500		{object}	dto.ErrorResponse	"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/ledgers [get]
+func (h *Handler) GetLedgers(c *ginext.Context) {
+	ledgers, err := h.service.GetLedgers(h.requestContext(c), currentUser(c).ID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if ledgers == nil {
+		ledgers = []model.Ledger{}
+	}
+
+	zlog.Logger.Info().Msg("successfully handled GET request and returned ledgers")
+	c.JSON(http.StatusOK, ledgers)
+}
+
+// CreateLedger godoc
+//
+//	@Summary		Create a ledger
+//	@Description	Creates ledger with current user as its member
+//	@Tags			ledgers
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		dto.CreateLedger	true	"Ledger"
+//	@Success		201		{object}	model.Ledger		"Created ledger"
+//	@Failure		400		{object}	dto.ErrorResponse	"Malformed payload"
+//	@Failure		422		{object}	dto.ErrorResponse	"Invalid payload"
+//	@Failure		401		{object}	dto.ErrorResponse	"Missing or invalid token"
+//	@Failure		403		{object}	dto.ErrorResponse	"API key can not create ledgers"
+//	@Failure		500		{object}	dto.ErrorResponse	"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/ledgers [post]
+func (h *Handler) CreateLedger(c *ginext.Context) {
+	var createLedger dto.CreateLedger
+	if !bindPayload(c, &createLedger) {
+		return
+	}
+
+	ledger, err := h.service.CreateLedger(h.requestContext(c), currentUser(c).ID, createLedger.Name)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled POST request and created ledger")
+	c.JSON(http.StatusCreated, ledger)
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// GetMembers godoc
+//
+//	@Summary		Get ledger members
+//	@Description	Returns users with access to ledger along with their roles. Available to every member
+//	@Tags			ledgers
+//	@Produce		json
+//	@Param			ledger_id	path		int					true	"Ledger ID"
+//	@Success		200			{array}		model.Member		"Members of ledger"
+//	@Failure		401			{object}	dto.ErrorResponse	"Missing or invalid token"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		404			{object}	dto.ErrorResponse	"Ledger not found"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/ledgers/{ledger_id}/members [get]
+func (h *Handler) GetMembers(c *ginext.Context) {
+	members, err := h.service.GetMembers(h.requestContext(c), currentLedgerID(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if members == nil {
+		members = []model.Member{}
+	}
+
+	zlog.Logger.Info().Msg("successfully handled GET request and returned members")
+	c.JSON(http.StatusOK, members)
+}
+
+// AddMember godoc
+//
+//	@Summary		Invite a member
+//	@Description	Gives registered user access to ledger with role viewer, editor or admin. Requires admin role
+//	@Tags			ledgers
+//	@Accept			json
+//	@Produce		json
+//	@Param			ledger_id	path		int					true	"Ledger ID"
+//	@Param			body		body		dto.AddMember		true	"Login of user and role"
+//	@Success		201			{object}	model.Member		"Added member"
+//	@Failure		400			{object}	dto.ErrorResponse	"Malformed payload"
+//	@Failure		422			{object}	dto.ErrorResponse	"Invalid payload"
+//	@Failure		401			{object}	dto.ErrorResponse	"Missing or invalid token"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		404			{object}	dto.ErrorResponse	"Ledger or user not found"
+//	@Failure		409			{object}	dto.ErrorResponse	"User is already a member"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/ledgers/{ledger_id}/members [post]
+func (h *Handler) AddMember(c *ginext.Context) {
+	var addMember dto.AddMember
+	if !bindPayload(c, &addMember) {
+		return
+	}
+
+	member, err := h.service.AddMember(h.requestContext(c), currentLedgerID(c), addMember)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled POST request and added member")
+	c.JSON(http.StatusCreated, member)
+}
+
+// UpdateMember godoc
+//
+//	@Summary		Change role of a member
+//	@Description	Changes role of ledger member. Requires admin role. The last admin of ledger can not be demoted
+//	@Tags			ledgers
+//	@Accept			json
+//	@Produce		json
+//	@Param			ledger_id	path		int					true	"Ledger ID"
+//	@Param			user_id		path		int					true	"User ID"
+//	@Param			body		body		dto.UpdateMember	true	"New role"
+//	@Success		200			{object}	map[string]string	"Success message"
+//	@Failure		400			{object}	dto.ErrorResponse	"Malformed payload or invalid user ID"
+//	@Failure		422			{object}	dto.ErrorResponse	"Invalid payload"
+//	@Failure		401			{object}	dto.ErrorResponse	"Missing or invalid token"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		404			{object}	dto.ErrorResponse	"Ledger or member not found"
+//	@Failure		409			{object}	dto.ErrorResponse	"Member is the last admin"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/ledgers/{ledger_id}/members/{user_id} [put]
+func (h *Handler) UpdateMember(c *ginext.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var updateMember dto.UpdateMember
+	if !bindPayload(c, &updateMember) {
+		return
+	}
+
+	err := h.service.UpdateMemberRole(h.requestContext(c), currentLedgerID(c), userID, updateMember.Role)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled PUT request and updated member")
+	c.JSON(http.StatusOK, ginext.H{"status": "successfully updated member"})
+}
+
+// RemoveMember godoc
+//
+//	@Summary		Remove a member
+//	@Description	Takes access to ledger away from user. Requires admin role. The last admin of ledger can not be removed
+//	@Tags			ledgers
+//	@Produce		json
+//	@Param			ledger_id	path		int					true	"Ledger ID"
+//	@Param			user_id		path		int					true	"User ID"
+//	@Success		200			{object}	map[string]string	"Success message"
+//	@Failure		400			{object}	dto.ErrorResponse	"Invalid user ID"
+//	@Failure		401			{object}	dto.ErrorResponse	"Missing or invalid token"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		404			{object}	dto.ErrorResponse	"Ledger or member not found"
+//	@Failure		409			{object}	dto.ErrorResponse	"Member is the last admin"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Security		BearerAuth
+//	@Router			/ledgers/{ledger_id}/members/{user_id} [delete]
+func (h *Handler) RemoveMember(c *ginext.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveMember(h.requestContext(c), currentLedgerID(c), userID); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled DELETE request and removed member")
+	c.JSON(http.StatusOK, ginext.H{"status": "successfully removed member"})
+}
+
+var errInvalidUserID = model.NewError(model.KindInvalid, "invalid_id", "invalid user id")
+
+func parseUserID(c *ginext.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil || id <= 0 {
+		respondError(c, errInvalidUserID)
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	_ "github.com/Komilov31/sales-tracker/internal/model"
+	validate "github.com/Komilov31/sales-tracker/internal/validator"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// CreateExchangeRate godoc
+//
+//	@Summary		Create or replace an exchange rate
+//	@Description	Sets how many units of quote currency one unit of base currency costs starting from date in ledger
+//	@Tags			rates
+//	@Accept			json
+//	@Produce		json
+//	@Param			body	body		dto.CreateExchangeRate	true	"Exchange rate"
+//	@Success		200		{object}	model.ExchangeRate		"Created exchange rate"
+//	@Failure		400		{object}	dto.ErrorResponse		"Malformed payload"
+//	@Failure		422		{object}	dto.ErrorResponse		"Invalid payload"
+//	@Failure		403		{object}	dto.ErrorResponse		"Only admins can create exchange rates, not with API key"
+//	@Failure		500		{object}	dto.ErrorResponse		"Internal server error"
+//	@Param			X-Ledger-ID	header	int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/rates [post]
+func (h *Handler) CreateExchangeRate(c *ginext.Context) {
+	var createRate dto.CreateExchangeRate
+	if !bindPayload(c, &createRate) {
+		return
+	}
+
+	rate, err := h.service.CreateExchangeRate(h.requestContext(c), currentLedgerID(c), createRate)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled POST request and created exchange rate")
+	c.JSON(http.StatusOK, rate)
+}
+
+// GetExchangeRates godoc
+//
+//	@Summary		Get exchange rates
+//	@Description	Retrieve exchange rates of ledger, optionally filtered by currencies
+//	@Tags			rates
+//	@Produce		json
+//	@Param			base	query		string	false	"Base currency (ISO 4217)"
+//	@Param			quote	query		string	false	"Quote currency (ISO 4217)"
+//	@Success		200		{array}		model.ExchangeRate	"List of exchange rates"
+//	@Failure		400		{object}	dto.ErrorResponse	"Invalid query parameters"
+//	@Failure		403		{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		500		{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header	int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/rates [get]
+func (h *Handler) GetExchangeRates(c *ginext.Context) {
+	params := dto.GetExchangeRatesParams{
+		LedgerID: currentLedgerID(c),
+		Base:     c.Query("base"),
+		Quote:    c.Query("quote"),
+	}
+
+	for _, currency := range []string{params.Base, params.Quote} {
+		if currency == "" {
+			continue
+		}
+		if err := validate.Validator.Var(currency, "iso4217"); err != nil {
+			respondError(c, invalidQuery(fmt.Errorf("invalid currency, must be ISO 4217 code")))
+			return
+		}
+	}
+
+	rates, err := h.service.GetExchangeRates(h.requestContext(c), params)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfylly handled GET request and returned exchange rates")
+	c.JSON(http.StatusOK, rates)
+}
+
+// DeleteExchangeRate godoc
+//
+//	@Summary		Delete an exchange rate
+//	@Description	Remove an exchange rate of ledger by its ID
+//	@Tags			rates
+//	@Produce		json
+//	@Param			id	path		int		true	"Exchange rate ID"
+//	@Success		200	{object}	map[string]string	"Success message"
+//	@Failure		400	{object}	dto.ErrorResponse	"Invalid ID"
+//	@Failure		404	{object}	dto.ErrorResponse	"Exchange rate not found"
+//	@Failure		403	{object}	dto.ErrorResponse	"Only admins can delete exchange rates, not with API key"
+//	@Failure		500	{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header	int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/rates/{id} [delete]
+func (h *Handler) DeleteExchangeRate(c *ginext.Context) {
+	rateID := c.Param("id")
+	id, err := strconv.Atoi(rateID)
+	if err != nil {
+		respondError(c, errInvalidID)
+		return
+	}
+
+	if err := h.service.DeleteExchangeRate(h.requestContext(c), currentLedgerID(c), id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled DELETE request and deleted exchange rate")
+	c.JSON(http.StatusOK, ginext.H{"status": "successfully delete exchange rate"})
+}
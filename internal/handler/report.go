@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/service"
+	validate "github.com/Komilov31/sales-tracker/internal/validator"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// GetStatement godoc
+//
+//	@Summary		Get monthly statement as PDF
+//	@Description	Download printable statement for a month: opening and closing balance, income and expense tables by category with count, sum, average, median and 90th percentile
+//	@Tags			reports
+//	@Produce		application/pdf
+//	@Param			month		query		string	true	"Month (YYYY-MM)"
+//	@Param			currency	query		string	false	"Reporting currency (ISO 4217), amounts are converted into it"
+//	@Success		200			{file}		application/octet-stream	"statement_YYYY-MM.pdf"
+//	@Failure		400			{object}	dto.ErrorResponse	"Invalid query parameters"
+//	@Failure		422			{object}	dto.ErrorResponse	"No exchange rate to convert amounts"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/reports/statement [get]
+func (h *Handler) GetStatement(c *ginext.Context) {
+	params, err := parseStatementParams(c)
+	if err != nil {
+		respondError(c, invalidQuery(err))
+		return
+	}
+
+	w := newAttachmentWriter(c, "statement_"+params.Month+".pdf", "application/pdf")
+	if err := h.service.StatementPDF(h.requestContext(c), w, params); err != nil {
+		w.fail(err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled GET request and returned statement")
+}
+
+func parseStatementParams(c *ginext.Context) (dto.StatementParams, error) {
+	params := dto.StatementParams{
+		LedgerID: currentLedgerID(c),
+		Month:    c.Query("month"),
+		Currency: c.Query("currency"),
+	}
+
+	if _, err := time.Parse(service.StatementMonthLayout, params.Month); err != nil {
+		return dto.StatementParams{}, fmt.Errorf("invalid month, must be in format 'YYYY-MM'")
+	}
+
+	if params.Currency != "" {
+		if err := validate.Validator.Var(params.Currency, "iso4217"); err != nil {
+			return dto.StatementParams{}, fmt.Errorf("invalid currency, must be ISO 4217 code")
+		}
+	}
+
+	return params, nil
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// GetRules godoc
+//
+//	@Summary		Get categorisation rules
+//	@Description	Returns rules of ledger in the order they are tried: the highest priority first, the older first among equal priorities
+//	@Tags			rules
+//	@Produce		json
+//	@Success		200			{array}		model.Rule			"Rules of ledger"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int					false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/rules [get]
+func (h *Handler) GetRules(c *ginext.Context) {
+	rules, err := h.service.GetRules(h.requestContext(c), currentLedgerID(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	if rules == nil {
+		rules = []model.Rule{}
+	}
+
+	zlog.Logger.Info().Msg("successfully handled GET request and returned rules")
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateRule godoc
+//
+//	@Summary		Create a categorisation rule
+//	@Description	Creates rule assigning category to items created without one. Description and counterparty are matched case-insensitively as substrings, or as regular expressions if regex is set
+//	@Tags			rules
+//	@Accept			json
+//	@Produce		json
+//	@Param			body		body		dto.CreateRule		true	"Rule"
+//	@Success		201			{object}	model.Rule			"Created rule"
+//	@Failure		400			{object}	dto.ErrorResponse	"Malformed payload"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		422			{object}	dto.ErrorResponse	"Invalid payload or unknown category"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int					false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/rules [post]
+func (h *Handler) CreateRule(c *ginext.Context) {
+	var rule dto.CreateRule
+	if !bindPayload(c, &rule) {
+		return
+	}
+
+	created, err := h.service.CreateRule(h.requestContext(c), currentLedgerID(c), rule)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled POST request and created rule")
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateRule godoc
+//
+//	@Summary		Update a categorisation rule
+//	@Description	Replaces conditions, priority and category of rule
+//	@Tags			rules
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int					true	"Rule ID"
+//	@Param			body		body		dto.UpdateRule		true	"Rule"
+//	@Success		200			{object}	model.Rule			"Updated rule"
+//	@Failure		400			{object}	dto.ErrorResponse	"Invalid ID or malformed payload"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		404			{object}	dto.ErrorResponse	"Rule not found"
+//	@Failure		422			{object}	dto.ErrorResponse	"Invalid payload or unknown category"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int					false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/rules/{id} [put]
+func (h *Handler) UpdateRule(c *ginext.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, errInvalidID)
+		return
+	}
+
+	var rule dto.UpdateRule
+	if !bindPayload(c, &rule) {
+		return
+	}
+
+	updated, err := h.service.UpdateRule(h.requestContext(c), currentLedgerID(c), id, rule)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled PUT request and updated rule")
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteRule godoc
+//
+//	@Summary		Delete a categorisation rule
+//	@Description	Deletes rule, items it has categorised keep their category
+//	@Tags			rules
+//	@Produce		json
+//	@Param			id			path		int					true	"Rule ID"
+//	@Success		200			{object}	map[string]string	"Success message"
+//	@Failure		400			{object}	dto.ErrorResponse	"Invalid ID"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		404			{object}	dto.ErrorResponse	"Rule not found"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int					false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/rules/{id} [delete]
+func (h *Handler) DeleteRule(c *ginext.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, errInvalidID)
+		return
+	}
+
+	if err := h.service.DeleteRule(h.requestContext(c), currentLedgerID(c), id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled DELETE request and deleted rule")
+	c.JSON(http.StatusOK, ginext.H{"status": "successfully deleted rule"})
+}
+
+// TestRules godoc
+//
+//	@Summary		Test categorisation rules
+//	@Description	Tells which rule would fire for the given item and which category item would get, rule is null if none matches. Nothing is changed
+//	@Tags			rules
+//	@Accept			json
+//	@Produce		json
+//	@Param			body		body		dto.RuleSample		true	"Item to test rules against"
+//	@Success		200			{object}	model.RuleMatch		"Matching rule"
+//	@Failure		400			{object}	dto.ErrorResponse	"Malformed payload"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		422			{object}	dto.ErrorResponse	"Invalid payload"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int					false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/rules/test [post]
+func (h *Handler) TestRules(c *ginext.Context) {
+	var sample dto.RuleSample
+	if !bindPayload(c, &sample) {
+		return
+	}
+
+	match, err := h.service.TestRules(h.requestContext(c), currentLedgerID(c), sample)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled POST request and tested rules")
+	c.JSON(http.StatusOK, match)
+}
+
+// ApplyRules godoc
+//
+//	@Summary		Re-apply categorisation rules
+//	@Description	Moves every item matched by a rule to category of the first such rule, items no rule matches keep their category. All items are changed at once or none, each change is recorded in audit log
+//	@Tags			rules
+//	@Produce		json
+//	@Param			date_from	query		string				false	"Start date of items (YYYY-MM-DD)"
+//	@Param			date_to		query		string				false	"End date of items (YYYY-MM-DD)"
+//	@Param			dry_run		query		bool				false	"Only report changes"
+//	@Success		200			{object}	model.RulesReport	"Changed items"
+//	@Failure		400			{object}	dto.ErrorResponse	"Invalid query parameters"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		412			{object}	dto.ErrorResponse	"Item was changed while rules were applied"
+//	@Failure		422			{object}	dto.ErrorResponse	"Category of rule does not fit item"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int					false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/rules/apply [post]
+func (h *Handler) ApplyRules(c *ginext.Context) {
+	params, err := parseApplyRulesParams(c)
+	if err != nil {
+		respondError(c, invalidQuery(err))
+		return
+	}
+
+	report, err := h.service.ApplyRules(h.requestContext(c), currentActor(c), params)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled POST request and applied rules")
+	c.JSON(http.StatusOK, report)
+}
+
+func parseApplyRulesParams(c *ginext.Context) (dto.ApplyRulesParams, error) {
+	params := dto.ApplyRulesParams{
+		LedgerID: currentLedgerID(c),
+		DateFrom: c.Query("date_from"),
+		DateTo:   c.Query("date_to"),
+	}
+
+	for _, date := range []string{params.DateFrom, params.DateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return dto.ApplyRulesParams{}, fmt.Errorf("invalid date format in query parameter, must be in format 'YYYY-MM-DD'")
+		}
+	}
+	if params.DateFrom != "" && params.DateTo != "" && params.DateFrom > params.DateTo {
+		return dto.ApplyRulesParams{}, fmt.Errorf("date_from must not be after date_to")
+	}
+
+	if value := c.Query("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			return dto.ApplyRulesParams{}, fmt.Errorf("invalid dry_run, must be boolean")
+		}
+		params.DryRun = dryRun
+	}
+
+	return params, nil
+}
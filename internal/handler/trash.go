@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// GetTrash godoc
+//
+//	@Summary		Get deleted items
+//	@Description	Retrieve a page of items in trash, filtered and sorted as GET /items. Items stay in trash until restored or purged by retention
+//	@Tags			items
+//	@Produce		json
+//
+// @Param sort_by query []string false "Sort fields (e.g., date,amount)"
+// @Param type query string false "Item type (доход or расход)"
+// @Param category query string false "Exact category"
+// @Param category_contains query string false "Case-insensitive category substring"
+// @Param currency query string false "Currency (ISO 4217)"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param cursor query string false "Cursor of the page returned in next_cursor or prev_cursor"
+//
+//	@Success		200			{object}	dto.ItemsPage		"Page of deleted items"
+//	@Failure		400			{object}	dto.ErrorResponse	"Invalid query parameters"
+//	@Failure		403			{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		500			{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int					false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/items/trash [get]
+func (h *Handler) GetTrash(c *ginext.Context) {
+	params, err := parseGetParams(c)
+	if err == nil {
+		err = parsePagination(c, &params)
+	}
+	if err != nil {
+		respondError(c, invalidQuery(err))
+		return
+	}
+	params.Deleted = true
+
+	h.respondItemsPage(c, params)
+}
+
+// RestoreItem godoc
+//
+//	@Summary		Restore a deleted item
+//	@Description	Takes item out of trash, it is included in items and analytics again
+//	@Tags			items
+//	@Produce		json
+//	@Param			id			path		int							true	"Item ID"
+//	@Success		200			{object}	dto.ItemWithoutAggregated	"Restored item"
+//	@Failure		400			{object}	dto.ErrorResponse			"Invalid ID"
+//	@Failure		403			{object}	dto.ErrorResponse			"Role does not allow the action"
+//	@Failure		404			{object}	dto.ErrorResponse			"Item is not in trash"
+//	@Failure		500			{object}	dto.ErrorResponse			"Internal server error"
+//	@Param			X-Ledger-ID	header		int							false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
+//	@Router			/items/{id}/restore [post]
+func (h *Handler) RestoreItem(c *ginext.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondError(c, errInvalidID)
+		return
+	}
+
+	item, err := h.service.RestoreItem(h.requestContext(c), currentActor(c), currentLedgerID(c), id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	zlog.Logger.Info().Msg("successfully handled POST request and restored item")
+	c.JSON(http.StatusOK, dto.ConvertWithoutAggregated(item))
+}
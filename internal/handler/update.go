@@ -12,38 +12,52 @@ import (
 // UpdateItem godoc
 //
 //	@Summary		Update an item
-//	@Description	Partially update an existing item by ID
+//	@Description	Partially update an existing item by ID provided it was not changed since its ETag was read
 //	@Tags			items
 //	@Accept			json
 //	@Produce		json
 //	@Param			id		path		int		true	"Item ID"
+//	@Param			If-Match	header	string	true	"ETag of item, * to update any version"
 //	@Param			body	body		dto.UpdateItem	true	"Fields to update"
 //	@Success		200		{object}	map[string]string	"Success message"
-//	@Failure		400		{object}	map[string]string	"Invalid ID or payload"
-//	@Failure		500		{object}	map[string]string	"Internal server error"
+//	@Header			200		{string}	ETag				"New version of item"
+//	@Failure		400		{object}	dto.ErrorResponse	"Invalid ID, malformed payload or If-Match header"
+//	@Failure		404		{object}	dto.ErrorResponse	"Item not found"
+//	@Failure		422		{object}	dto.ErrorResponse	"Invalid payload, amount precision or no field to update"
+//	@Failure		403		{object}	dto.ErrorResponse	"Role does not allow the action"
+//	@Failure		412		{object}	dto.ErrorResponse	"Item was changed since its ETag was read"
+//	@Failure		428		{object}	dto.ErrorResponse	"If-Match header is missing"
+//	@Failure		500		{object}	dto.ErrorResponse	"Internal server error"
+//	@Param			X-Ledger-ID	header		int	false	"Ledger ID, the oldest ledger of user by default"
+//	@Security		BearerAuth
 //	@Router			/items/{id} [put]
 func (h *Handler) UpdateItem(c *ginext.Context) {
 	itemID := c.Param("id")
 	id, err := strconv.Atoi(itemID)
 	if err != nil {
-		zlog.Logger.Error().Msg("invalid id: " + err.Error())
-		c.JSON(http.StatusBadRequest, ginext.H{"error": "invalid id"})
+		respondError(c, errInvalidID)
+		return
+	}
+
+	version, err := parseIfMatch(c)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
 	var updateItem dto.UpdateItem
-	if err := c.BindJSON(&updateItem); err != nil {
-		zlog.Logger.Error().Msg("could not unmarshal json: " + err.Error())
-		c.JSON(http.StatusBadRequest, ginext.H{"error": "invalid payload"})
+	if !bindPayload(c, &updateItem) {
 		return
 	}
 
-	if err := h.service.UpdateItem(h.ctx, id, updateItem); err != nil {
-		zlog.Logger.Error().Msg("could not update item: " + err.Error())
-		c.JSON(http.StatusInternalServerError, ginext.H{"error": err.Error()})
+	if err := h.service.UpdateItem(h.requestContext(c), currentActor(c), currentLedgerID(c), id, version, updateItem); err != nil {
+		respondError(c, err)
 		return
 	}
 
 	zlog.Logger.Info().Msg("successfully handled PUT request and updated item")
+	if version != 0 {
+		c.Header("ETag", itemETag(version+1))
+	}
 	c.JSON(http.StatusOK, ginext.H{"status": "successfully updated item"})
 }
@@ -2,10 +2,20 @@ package handler
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
 	"github.com/Komilov31/sales-tracker/internal/model"
+	validate "github.com/Komilov31/sales-tracker/internal/validator"
+	"github.com/shopspring/decimal"
+	"github.com/wb-go/wbf/ginext"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
 )
 
 func validateGetParams(sortBy []string) error {
@@ -27,31 +37,215 @@ func validateGetParams(sortBy []string) error {
 	return nil
 }
 
-func validateDate(from, to string) error {
-	if _, err := time.Parse(time.DateOnly, from); err != nil {
-		return fmt.Errorf("invalid date format in query parameter")
+// parseGetParams reads sorting and filtering query parameters shared by
+// items listing and items export, items are limited to the current ledger.
+func parseGetParams(c *ginext.Context) (dto.GetItemsParams, error) {
+	params := dto.GetItemsParams{
+		LedgerID:         currentLedgerID(c),
+		SortBy:           c.QueryArray("sort_by"),
+		Type:             c.Query("type"),
+		Category:         c.Query("category"),
+		CategoryContains: c.Query("category_contains"),
+		DateFrom:         c.Query("date_from"),
+		DateTo:           c.Query("date_to"),
+		Currency:         c.Query("currency"),
+	}
+
+	if err := validateGetParams(params.SortBy); err != nil {
+		return dto.GetItemsParams{}, err
+	}
+
+	if params.Type != "" && params.Type != "доход" && params.Type != "расход" {
+		return dto.GetItemsParams{}, fmt.Errorf("invalid type, must be one of 'доход', 'расход'")
+	}
+
+	if params.Currency != "" {
+		if err := validate.Validator.Var(params.Currency, "iso4217"); err != nil {
+			return dto.GetItemsParams{}, fmt.Errorf("invalid currency, must be ISO 4217 code")
+		}
+	}
+
+	for _, date := range []string{params.DateFrom, params.DateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return dto.GetItemsParams{}, fmt.Errorf("invalid date format in query parameter, must be in format 'YYYY-MM-DD'")
+		}
+	}
+	if params.DateFrom != "" && params.DateTo != "" && params.DateFrom > params.DateTo {
+		return dto.GetItemsParams{}, fmt.Errorf("date_from must not be after date_to")
 	}
 
-	if _, err := time.Parse(time.DateOnly, to); err != nil {
-		return fmt.Errorf("invalid date format in query parameter, must be in format 'YY-MM-DD'")
+	var err error
+	if params.AmountMin, err = parseAmount(c.Query("amount_min")); err != nil {
+		return dto.GetItemsParams{}, err
+	}
+	if params.AmountMax, err = parseAmount(c.Query("amount_max")); err != nil {
+		return dto.GetItemsParams{}, err
+	}
+	if params.AmountMin != nil && params.AmountMax != nil && params.AmountMin.GreaterThan(*params.AmountMax) {
+		return dto.GetItemsParams{}, fmt.Errorf("amount_min must not be greater than amount_max")
+	}
+
+	if params.CreatedFrom, err = parseTimestamp(c.Query("created_from"), false); err != nil {
+		return dto.GetItemsParams{}, err
+	}
+	if params.CreatedTo, err = parseTimestamp(c.Query("created_to"), true); err != nil {
+		return dto.GetItemsParams{}, err
+	}
+	if params.CreatedFrom != nil && params.CreatedTo != nil && params.CreatedFrom.After(*params.CreatedTo) {
+		return dto.GetItemsParams{}, fmt.Errorf("created_from must not be after created_to")
+	}
+
+	return params, nil
+}
+
+// parseAnalyticsParams reads date range and grouping of analytics request
+// within the current ledger. Any of the range bounds may be omitted.
+func parseAnalyticsParams(c *ginext.Context) (dto.AnalyticsParams, error) {
+	params := dto.AnalyticsParams{
+		LedgerID: currentLedgerID(c),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+		GroupBy:  c.QueryArray("group_by"),
+		Currency: c.Query("currency"),
+	}
+
+	if params.Currency != "" {
+		if err := validate.Validator.Var(params.Currency, "iso4217"); err != nil {
+			return dto.AnalyticsParams{}, fmt.Errorf("invalid currency, must be ISO 4217 code")
+		}
+	}
+
+	for _, date := range []string{params.From, params.To} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return dto.AnalyticsParams{}, fmt.Errorf("invalid date format in query parameter, must be in format 'YYYY-MM-DD'")
+		}
+	}
+	if params.From != "" && params.To != "" && params.From > params.To {
+		return dto.AnalyticsParams{}, fmt.Errorf("from must not be after to")
+	}
+
+	groups := map[string]bool{
+		"category":      false,
+		"root_category": false,
+		"type":          false,
+		"day":           false,
+		"week":          false,
+		"month":         false,
+		"quarter":       false,
+		"year":          false,
+	}
+
+	for _, group := range params.GroupBy {
+		seen, ok := groups[group]
+		if !ok {
+			return dto.AnalyticsParams{}, fmt.Errorf("invalid field name for grouping")
+		}
+		if seen {
+			return dto.AnalyticsParams{}, fmt.Errorf("duplicate field name for grouping")
+		}
+		groups[group] = true
+	}
+
+	return params, nil
+}
+
+func parsePagination(c *ginext.Context, params *dto.GetItemsParams) error {
+	params.Limit = defaultPageLimit
+	params.Cursor = c.Query("cursor")
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return fmt.Errorf("invalid limit, must be between 1 and %d", maxPageLimit)
+		}
+		params.Limit = limit
 	}
 
 	return nil
 }
 
-func convertWithoutAggregated(item *model.Item) dto.ItemWithoutAggregated {
-	return dto.ItemWithoutAggregated{
-		ID: item.ID, Type: item.Type, Amount: item.Amount,
-		Date: item.Date, Category: item.Category,
-		CreatedAt: item.CreatedAt,
+func parseAmount(value string) (*decimal.Decimal, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	amount, err := decimal.NewFromString(value)
+	if err != nil || amount.IsNegative() {
+		return nil, fmt.Errorf("invalid amount in query parameter, must be non-negative decimal number")
+	}
+
+	return &amount, nil
+}
+
+// parseTimestamp accepts either RFC 3339 timestamp or plain date. Plain date
+// used as upper bound covers the whole day.
+func parseTimestamp(value string, upperBound bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp in query parameter, must be in format 'YYYY-MM-DD' or RFC 3339")
+	}
+
+	if upperBound {
+		t = t.AddDate(0, 0, 1).Add(-time.Microsecond)
+	}
+
+	return &t, nil
+}
+
+// itemETag is ETag of item with version, clients send it back in If-Match
+// header to change the item.
+func itemETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+var (
+	errIfMatchRequired = model.NewError(model.KindPreconditionRequired, "if_match_required", "If-Match header with ETag of item is required")
+	errInvalidIfMatch  = model.NewError(model.KindInvalid, "invalid_if_match", "If-Match header must be ETag of item")
+)
+
+// parseIfMatch reads version of item from If-Match header, which is required
+// to change items so that concurrent changes are not silently overwritten.
+// "*" matches any version and gives zero.
+func parseIfMatch(c *ginext.Context) (int, error) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" {
+		return 0, errIfMatchRequired
+	}
+	if ifMatch == "*" {
+		return 0, nil
 	}
+
+	unquoted, err := strconv.Unquote(ifMatch)
+	if err != nil {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, errInvalidIfMatch
+	}
+
+	return version, nil
 }
 
 func itemsWithoutAggregated(items []model.Item) []dto.ItemWithoutAggregated {
 	withoutAggregated := make([]dto.ItemWithoutAggregated, len(items))
 
 	for i, item := range items {
-		withoutAggregated[i] = convertWithoutAggregated(&item)
+		withoutAggregated[i] = dto.ConvertWithoutAggregated(&item)
 	}
 
 	return withoutAggregated
@@ -0,0 +1,24 @@
+package model
+
+// defaultExponent is the number of minor unit digits of most currencies.
+const defaultExponent = 2
+
+// currencyExponents lists ISO 4217 currencies whose minor unit differs from
+// defaultExponent. Must be kept in sync with currency_exponent SQL function.
+var currencyExponents = map[string]int32{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyExponent returns number of digits after the decimal point amounts
+// in currency may have, e.g. 2 for RUB (kopecks) and 0 for JPY.
+func CurrencyExponent(currency string) int32 {
+	if exponent, ok := currencyExponents[currency]; ok {
+		return exponent
+	}
+
+	return defaultExponent
+}
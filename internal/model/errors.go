@@ -0,0 +1,60 @@
+package model
+
+// ErrorKind tells what went wrong in terms of the API, so that every layer
+// reports, say, a missing item alike and handlers respond to it the same way
+// whichever layer it comes from.
+type ErrorKind int
+
+const (
+	// KindInternal is an unexpected failure, its details are never shown to
+	// clients. Errors which are not *Error are internal as well.
+	KindInternal ErrorKind = iota
+	// KindInvalid is a malformed request: path or query parameter, header or
+	// body which can not be parsed.
+	KindInvalid
+	// KindValidation is a well-formed request with values breaking the rules.
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindNotAcceptable
+	// KindConflict is a change clashing with the current state, such as taken
+	// login or removal of the last admin.
+	KindConflict
+	// KindPreconditionFailed is a change of item which was changed since the
+	// version client has read.
+	KindPreconditionFailed
+	KindPreconditionRequired
+)
+
+// Error is an error of known kind. Code names the error for clients and never
+// changes, unlike Message. Errors are compared by identity, so wrapping
+// sentinel *Error with fmt.Errorf keeps its kind and code. Validation errors
+// may tell which fields break which rules in Fields.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+// FieldError tells that value of Field breaks Rule. Field is the path of value
+// in JSON payload, such as amount or changes.amount.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func NewError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// NewValidationError is a validation error caused by fields.
+func NewValidationError(code, message string, fields []FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: fields}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
@@ -1,21 +1,316 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type Item struct {
+	ID           int             `json:"id"`
+	Type         string          `json:"type"`
+	Amount       decimal.Decimal `json:"amount" swaggertype:"string" example:"149.90"`
+	Date         string          `json:"date"`
+	Category     string          `json:"category"`
+	Currency     string          `json:"currency"`
+	Description  string          `json:"description,omitempty"`
+	Counterparty string          `json:"counterparty,omitempty"`
+	Version      int             `json:"version"`
+	CreatedAt    time.Time       `json:"created_at"`
+	DeletedAt    *time.Time      `json:"deleted_at,omitempty"`
+	Aggregated   Aggregated      `json:"aggregated_data,omitempty"`
+}
+
+// Aggregated keeps statistics of signed amounts (expenses are negative) in
+// its top-level fields and statistics of every type on its own in Income and
+// Expense, where amounts are positive. All money values are exact decimals.
+type Aggregated struct {
+	Sum           decimal.Decimal `json:"sum" swaggertype:"string"`
+	Average       decimal.Decimal `json:"average" swaggertype:"string"`
+	Count         int             `json:"count"`
+	Median        decimal.Decimal `json:"median" swaggertype:"string"`
+	Percentile_90 decimal.Decimal `json:"percentile_90" swaggertype:"string"`
+	Income        TypeStats       `json:"income"`
+	Expense       TypeStats       `json:"expense"`
+	Net           decimal.Decimal `json:"net" swaggertype:"string"`
+}
+
+type TypeStats struct {
+	Sum           decimal.Decimal `json:"sum" swaggertype:"string"`
+	Average       decimal.Decimal `json:"average" swaggertype:"string"`
+	Count         int             `json:"count"`
+	Median        decimal.Decimal `json:"median" swaggertype:"string"`
+	Percentile_90 decimal.Decimal `json:"percentile_90" swaggertype:"string"`
+}
+
+type GroupedAggregated struct {
+	Group      map[string]string `json:"group"`
+	Aggregated Aggregated        `json:"aggregated_data"`
+}
+
+type Summary struct {
+	Aggregated Aggregated
+	Items      []Item
+}
+
+type ItemsPage struct {
+	Items      []Item
+	NextCursor string
+	PrevCursor string
+	Total      int
+}
+
+// Category is a category of ledger items. Subcategories refer to their parent
+// with ParentID. Type limits category and its subcategories to income or
+// expense items, empty Type fits both. Items created with any of Aliases get
+// Name as their category.
+type Category struct {
+	ID        int       `json:"id"`
+	ParentID  *int      `json:"parent_id,omitempty"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type,omitempty"`
+	Aliases   []string  `json:"aliases"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Rule assigns Category to items created without one. Every condition it
+// has must hold: Description and Counterparty are found in the same fields of
+// item, case aside, as substrings or as regular expressions if Regex is set;
+// Type and amount bounds limit items rule fits. Rules are tried from the
+// highest Priority, the older one first if priorities are equal.
+type Rule struct {
+	ID           int              `json:"id"`
+	Priority     int              `json:"priority"`
+	Category     string           `json:"category"`
+	Description  string           `json:"description,omitempty"`
+	Counterparty string           `json:"counterparty,omitempty"`
+	Regex        bool             `json:"regex"`
+	Type         string           `json:"type,omitempty"`
+	AmountMin    *decimal.Decimal `json:"amount_min,omitempty" swaggertype:"string" example:"0"`
+	AmountMax    *decimal.Decimal `json:"amount_max,omitempty" swaggertype:"string" example:"1000"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+// RuleMatch tells which rule fires for an item, Rule is nil if none does.
+type RuleMatch struct {
+	Rule     *Rule  `json:"rule"`
+	Category string `json:"category,omitempty"`
+}
+
+// RulesReport describes outcome of applying rules to existing items. Changes
+// lists items whose category rules change, they are changed unless DryRun is
+// set.
+type RulesReport struct {
+	Checked int          `json:"checked"`
+	Updated int          `json:"updated"`
+	DryRun  bool         `json:"dry_run"`
+	Changes []RuleChange `json:"changes"`
+}
+
+// RuleChange tells that rule with RuleID moves item from category From to To.
+type RuleChange struct {
+	ItemID int    `json:"item_id"`
+	RuleID int    `json:"rule_id"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// ImportReport describes outcome of items import. Items are imported only if
+// every row is valid, so Imported is zero whenever Errors is not empty.
+type ImportReport struct {
+	Total    int              `json:"total"`
+	Valid    int              `json:"valid"`
+	Imported int              `json:"imported"`
+	DryRun   bool             `json:"dry_run"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// ImportRowError tells why row at Line of CSV file could not be imported.
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Statuses of batch operations. Operations of a failed batch are either
+// failed, rolled back (executed before the failure) or skipped.
+const (
+	BatchCreated    = "created"
+	BatchUpdated    = "updated"
+	BatchDeleted    = "deleted"
+	BatchInvalid    = "invalid"
+	BatchFailed     = "failed"
+	BatchRolledBack = "rolled_back"
+	BatchSkipped    = "skipped"
+)
+
+type BatchResult struct {
+	Index  int          `json:"index"`
+	Op     string       `json:"op"`
+	ID     int          `json:"id,omitempty"`
+	Status string       `json:"status"`
+	Error  string       `json:"error,omitempty"`
+	Fields []FieldError `json:"fields,omitempty"`
+	Item   *Item        `json:"item,omitempty"`
+}
+
+// ExchangeRate tells that one unit of Base costs Rate units of Quote
+// starting from Date.
+type ExchangeRate struct {
+	ID        int             `json:"id"`
+	Base      string          `json:"base"`
+	Quote     string          `json:"quote"`
+	Date      string          `json:"date"`
+	Rate      decimal.Decimal `json:"rate" swaggertype:"string" example:"89.69"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Statement is a monthly financial statement. Opening balance is net of all
+// items before From, closing one adds net of the month to it. Categories hold
+// statistics of every category within the month.
+type Statement struct {
+	From       string
+	To         string
+	Currency   string
+	Opening    decimal.Decimal
+	Closing    decimal.Decimal
+	Aggregated Aggregated
+	Categories []GroupedAggregated
+}
+
+// ExportFormat describes format items and analytics can be exported in.
+// Name is used in format query parameter, ContentType is matched against
+// Accept header and sent with the file.
+type ExportFormat struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Extension   string `json:"extension"`
+}
+
+// User is an account allowed to use the API. Password is kept only as
+// bcrypt hash which is never sent to clients.
+type User struct {
+	ID           int       `json:"id"`
+	Login        string    `json:"login"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Token is a signed session token issued on login, it is sent back in
+// Authorization header as "Bearer <token>".
+type Token struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Ledger is a separate book of items shared by its members. Items, analytics
+// and exports are always computed within a single ledger. Role is the role of
+// the user ledger was requested by.
+type Ledger struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Roles of ledger members, every next role is allowed everything the
+// previous one is: viewers read items and analytics, editors change items,
+// admins manage members.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var roleRanks = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleAdmin: 3}
+
+// RoleAllows tells whether role grants everything required role does.
+func RoleAllows(role, required string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[required]
+}
+
+// Member is a user with access to ledger.
+type Member struct {
+	UserID    int       `json:"user_id"`
+	Login     string    `json:"login"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// APIKeyPrefix starts every API key, it tells keys apart from session tokens
+// sent in the same Authorization header.
+const APIKeyPrefix = "st_"
+
+// Scopes of API keys. Unlike session tokens, API keys may call only
+// endpoints their scopes allow, the role of the user in ledger still applies.
+const (
+	ScopeItemsRead     = "items:read"
+	ScopeItemsWrite    = "items:write"
+	ScopeAnalyticsRead = "analytics:read"
+	ScopeExport        = "export"
+)
+
+// APIKey is a long-lived credential of user for scripts and integrations.
+// Only sha256 hash of the key is stored, Prefix is its first characters
+// letting user recognize the key.
+type APIKey struct {
 	ID         int        `json:"id"`
-	Type       string     `json:"type"`
-	Amount     int        `json:"amount"`
-	Date       string     `json:"date"`
-	Category   string     `json:"category"`
+	UserID     int        `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
 	CreatedAt  time.Time  `json:"created_at"`
-	Aggregated Aggregated `json:"aggregated_data,omitempty"`
 }
 
-type Aggregated struct {
-	Sum           int     `json:"sum"`
-	Average       float64 `json:"average"`
-	Count         int     `json:"count"`
-	Median        float64 `json:"median"`
-	Percentile_90 float64 `json:"percentile_90"`
+// HasScope tells whether key was given scope.
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// CreatedAPIKey is API key along with its secret, which is shown only once,
+// when the key is created.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// Actor is who changes items: user and API key if the change is made with
+// one.
+type Actor struct {
+	UserID   int
+	APIKeyID *int
+}
+
+// Operations recorded in audit log.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+)
+
+// AuditEntry records a single change of item. Before and After are rows of
+// item as JSON, Before is empty for created items and After for deleted
+// ones. Restored items have both, Before being the item in trash.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	ItemID    int             `json:"item_id"`
+	UserID    int             `json:"user_id"`
+	Login     string          `json:"login"`
+	APIKeyID  *int            `json:"api_key_id,omitempty"`
+	Operation string          `json:"operation"`
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditPage is a page of audit log, newest entries first. NextCursor is
+// empty on the last page.
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
 }
@@ -2,53 +2,283 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/Komilov31/sales-tracker/internal/dto"
 	"github.com/Komilov31/sales-tracker/internal/model"
 )
 
-func (r *Repository) GetAggregated(ctx context.Context, from, to string) ([]model.Item, error) {
-	query := `SELECT *,
-       SUM(CASE WHEN type = 'расход' THEN -amount ELSE amount END) OVER () AS total_sum,
-       AVG(CASE WHEN type = 'расход' THEN -amount ELSE amount END) OVER () AS avg_amount,
-       COUNT(*) OVER () AS total_count,
-       percentile_cont_window(array_agg(CASE WHEN type = 'расход' THEN -amount ELSE amount END) OVER (), 0.5) AS median_amount,
-       percentile_cont_window(array_agg(CASE WHEN type = 'расход' THEN -amount ELSE amount END) OVER (), 0.9) AS percentile_90_amount
-	   FROM items
-	   WHERE (($1 = '' AND $2 = '') OR (date BETWEEN $1::date AND $2::date));`
+// signedAmount treats expenses as negative amounts.
+const signedAmount = "CASE WHEN type = 'расход' THEN -amount ELSE amount END"
 
-	var items []model.Item
-	rows, err := r.db.Master.QueryContext(
-		ctx,
-		query,
-		from,
-		to,
+// groupExpressions maps supported group_by values to SQL expressions. Time
+// buckets are rendered as sortable strings, e.g. 2024-W05, 2024-Q1.
+// root_category rolls subcategories up into their top-level category.
+var groupExpressions = map[string]string{
+	"category":      "category",
+	"root_category": "category_root(ledger_id, category)",
+	"type":          "type",
+	"day":           "to_char(date, 'YYYY-MM-DD')",
+	"week":          `to_char(date, 'IYYY-"W"IW')`,
+	"month":         "to_char(date, 'YYYY-MM')",
+	"quarter":       `to_char(date, 'YYYY-"Q"Q')`,
+	"year":          "to_char(date, 'YYYY')",
+}
+
+// convertedItems selects items with rate converting item currency into
+// currency passed as $1: the latest rate of item ledger known on the item
+// date, either direct or inverse one. Rate is NULL when no suitable rate
+// exists.
+const convertedItems = `SELECT i.*,
+		CASE WHEN i.currency = $1 THEN 1 ELSE (
+			SELECT CASE WHEN r.base = i.currency THEN r.rate ELSE 1 / r.rate END
+			FROM exchange_rates r
+			WHERE r.ledger_id = i.ledger_id
+				AND ((r.base = i.currency AND r.quote = $1) OR (r.base = $1 AND r.quote = i.currency))
+				AND r.date <= i.date
+			ORDER BY r.date DESC, r.base = i.currency DESC
+			LIMIT 1
+		) END AS rate
+	FROM items i`
+
+// itemsSource returns relation analytics queries select items from along
+// with its arguments. With currency set amounts are converted into it.
+func itemsSource(currency string) (string, []any) {
+	if currency == "" {
+		return "items", nil
+	}
+
+	return `(SELECT id, ledger_id, type, ROUND(amount * rate, currency_exponent($1)) AS amount, date, category,
+		$1::char(3) AS currency, created_at, deleted_at
+		FROM (` + convertedItems + `) AS converted) AS items`, []any{currency}
+}
+
+// checkRates makes sure every item of analytics can be converted into
+// reporting currency.
+func (r *Repository) checkRates(ctx context.Context, params dto.AnalyticsParams) error {
+	currency := params.Currency
+	if currency == "" {
+		return nil
+	}
+
+	where, args := prepareAnalyticsFilters(params, currency)
+	query := "SELECT currency, date FROM (" + convertedItems + ") AS items" + where + " AND rate IS NULL LIMIT 1"
+
+	var (
+		itemCurrency string
+		date         time.Time
 	)
+	err := r.db.Master.QueryRowContext(ctx, query, args...).Scan(&itemCurrency, &date)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not check exchange rates: %w", err)
+	}
+
+	return fmt.Errorf("%w: %s to %s on %s", ErrNoExchangeRate, itemCurrency, currency, date.Format(time.DateOnly))
+}
+
+// statsColumns computes sum, average, count, median and 90th percentile of
+// value over rows matching filter. With window set statistics are computed
+// over the whole result set and attached to every row, otherwise they are
+// regular aggregates over a group. Percentiles are interpolated in NUMERIC by
+// numeric_percentile, as percentile_cont works with double precision only.
+func statsColumns(value, filter string, window bool) []string {
+	if filter != "" {
+		filter = " FILTER (WHERE " + filter + ")"
+	}
+
+	over := ""
+	if window {
+		over = " OVER ()"
+	}
+
+	value = "(" + value + ")"
+	percentile := func(fraction string) string {
+		return fmt.Sprintf("COALESCE(numeric_percentile(array_agg(%s)%s%s, %s), 0)", value, filter, over, fraction)
+	}
+
+	return []string{
+		"COALESCE(SUM(" + value + ")" + filter + over + ", 0)",
+		"COALESCE(AVG(" + value + ")" + filter + over + ", 0)",
+		"COUNT(*)" + filter + over,
+		percentile("0.5"),
+		percentile("0.9"),
+	}
+}
+
+// aggregateColumns returns select list matching aggregatedDest: signed
+// statistics followed by income and expense statistics.
+func aggregateColumns(window bool) string {
+	columns := statsColumns(signedAmount, "", window)
+	columns = append(columns, statsColumns("amount", "type = 'доход'", window)...)
+	columns = append(columns, statsColumns("amount", "type = 'расход'", window)...)
+	return strings.Join(columns, ", ")
+}
+
+func aggregatedDest(aggregated *model.Aggregated) []any {
+	return []any{
+		&aggregated.Sum,
+		&aggregated.Average,
+		&aggregated.Count,
+		&aggregated.Median,
+		&aggregated.Percentile_90,
+		&aggregated.Income.Sum,
+		&aggregated.Income.Average,
+		&aggregated.Income.Count,
+		&aggregated.Income.Median,
+		&aggregated.Income.Percentile_90,
+		&aggregated.Expense.Sum,
+		&aggregated.Expense.Average,
+		&aggregated.Expense.Count,
+		&aggregated.Expense.Median,
+		&aggregated.Expense.Percentile_90,
+	}
+}
+
+func (r *Repository) GetAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.Item, error) {
+	var items []model.Item
+	err := r.StreamAggregated(ctx, params, func(item model.Item) error {
+		items = append(items, item)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("could not get aggregated data: %w", err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// StreamAggregated calls fn for every item of ledger within dates range with
+// statistics attached as rows are read from the database. Iteration stops at
+// the first error returned by fn.
+func (r *Repository) StreamAggregated(ctx context.Context, params dto.AnalyticsParams, fn func(model.Item) error) error {
+	if err := r.checkRates(ctx, params); err != nil {
+		return err
 	}
 
+	source, args := itemsSource(params.Currency)
+	query := `SELECT id, type, amount, date, category, currency, created_at, ` + aggregateColumns(true) + `
+	   FROM ` + source
+
+	where, args := prepareAnalyticsFilters(params, args...)
+
+	rows, err := r.db.Master.QueryContext(ctx, query+where+" ORDER BY id", args...)
+	if err != nil {
+		return fmt.Errorf("could not get aggregated data: %w", err)
+	}
+	defer rows.Close()
+
 	for rows.Next() {
 		var item model.Item
-		err := rows.Scan(
+		dest := []any{
 			&item.ID,
 			&item.Type,
 			&item.Amount,
 			&item.Date,
 			&item.Category,
+			&item.Currency,
 			&item.CreatedAt,
-			&item.Aggregated.Sum,
-			&item.Aggregated.Average,
-			&item.Aggregated.Count,
-			&item.Aggregated.Median,
-			&item.Aggregated.Percentile_90,
-		)
+		}
+		err := rows.Scan(append(dest, aggregatedDest(&item.Aggregated)...)...)
 		if err != nil {
-			return nil, fmt.Errorf("could not scan aggregated data to model: %w", err)
+			return fmt.Errorf("could not scan aggregated data to model: %w", err)
 		}
+		item.Aggregated.Net = item.Aggregated.Income.Sum.Sub(item.Aggregated.Expense.Sum)
 
-		items = append(items, item)
+		if err := fn(item); err != nil {
+			return err
+		}
 	}
 
-	return items, nil
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not get aggregated data: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) GetGroupedAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.GroupedAggregated, error) {
+	groupColumns := make([]string, len(params.GroupBy))
+	for i, group := range params.GroupBy {
+		expression, ok := groupExpressions[group]
+		if !ok {
+			return nil, fmt.Errorf("could not get grouped aggregated data: unknown group %q", group)
+		}
+		groupColumns[i] = expression
+	}
+
+	if err := r.checkRates(ctx, params); err != nil {
+		return nil, err
+	}
+
+	source, args := itemsSource(params.Currency)
+	selectList := append(slices.Clone(groupColumns), aggregateColumns(false))
+	query := "SELECT " + strings.Join(selectList, ", ") + " FROM " + source
+
+	where, args := prepareAnalyticsFilters(params, args...)
+	query += where
+
+	if len(groupColumns) > 0 {
+		groupBy := strings.Join(groupColumns, ", ")
+		query += " GROUP BY " + groupBy + " ORDER BY " + groupBy
+	}
+
+	rows, err := r.db.Master.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get grouped aggregated data: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []model.GroupedAggregated
+	for rows.Next() {
+		values := make([]string, len(groupColumns))
+		var group model.GroupedAggregated
+
+		dest := make([]any, 0, len(values))
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+
+		if err := rows.Scan(append(dest, aggregatedDest(&group.Aggregated)...)...); err != nil {
+			return nil, fmt.Errorf("could not scan grouped aggregated data to model: %w", err)
+		}
+		group.Aggregated.Net = group.Aggregated.Income.Sum.Sub(group.Aggregated.Expense.Sum)
+
+		group.Group = make(map[string]string, len(values))
+		for i, name := range params.GroupBy {
+			group.Group[name] = values[i]
+		}
+
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get grouped aggregated data: %w", err)
+	}
+
+	return groups, nil
+}
+
+// GetSummary computes statistics over all items within dates range with a
+// single aggregate query.
+func (r *Repository) GetSummary(ctx context.Context, params dto.AnalyticsParams) (*model.Aggregated, error) {
+	params.GroupBy = nil
+
+	groups, err := r.GetGroupedAggregated(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("could not get summary: %w", err)
+	}
+
+	if len(groups) == 0 {
+		return &model.Aggregated{}, nil
+	}
+
+	return &groups[0].Aggregated, nil
 }
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/lib/pq"
+)
+
+const apiKeyColumns = "id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at"
+
+func apiKeyDest(key *model.APIKey) []any {
+	return []any{
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		pq.Array(&key.Scopes),
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+	}
+}
+
+// CreateAPIKey stores key of user by its hash, the key itself is never
+// stored.
+func (r *Repository) CreateAPIKey(ctx context.Context, key model.APIKey, hash string) (*model.APIKey, error) {
+	query := `INSERT INTO api_keys(user_id, name, prefix, key_hash, scopes, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING ` + apiKeyColumns
+
+	var created model.APIKey
+	err := r.db.Master.QueryRowContext(ctx, query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		hash,
+		pq.Array(key.Scopes),
+		key.ExpiresAt,
+	).Scan(apiKeyDest(&created)...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create api key in db: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *Repository) GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE user_id = $1 ORDER BY id"
+
+	rows, err := r.db.Master.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get api keys from db: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		var key model.APIKey
+		if err := rows.Scan(apiKeyDest(&key)...); err != nil {
+			return nil, fmt.Errorf("could not scan row result to model: %w", err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get api keys from db: %w", err)
+	}
+
+	return keys, nil
+}
+
+// UseAPIKey finds unexpired key by its hash along with its owner and marks it
+// as used now.
+func (r *Repository) UseAPIKey(ctx context.Context, hash string) (*model.APIKey, *model.User, error) {
+	query := `UPDATE api_keys k SET last_used_at = CURRENT_TIMESTAMP
+	FROM users u
+	WHERE u.id = k.user_id AND k.key_hash = $1
+		AND (k.expires_at IS NULL OR k.expires_at > CURRENT_TIMESTAMP)
+	RETURNING k.id, k.user_id, k.name, k.prefix, k.scopes, k.expires_at, k.last_used_at, k.created_at,
+		u.login, u.created_at`
+
+	var (
+		key  model.APIKey
+		user model.User
+	)
+	dest := append(apiKeyDest(&key), &user.Login, &user.CreatedAt)
+	if err := r.db.Master.QueryRowContext(ctx, query, hash).Scan(dest...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrNoSuchAPIKey
+		}
+		return nil, nil, fmt.Errorf("could not get api key from db: %w", err)
+	}
+	user.ID = key.UserID
+
+	return &key, &user, nil
+}
+
+// RevokeAPIKey deletes key of user, keys of other users are reported as
+// missing.
+func (r *Repository) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	result, err := r.db.Master.ExecContext(ctx, "DELETE FROM api_keys WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("could not delete api key from db: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrNoSuchAPIKey
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+)
+
+// writeAudit records change of item by actor. It is called within the
+// transaction making the change, so that no change goes unrecorded.
+func writeAudit(ctx context.Context, q querier, actor model.Actor, ledgerID, itemID int, operation string, before, after []byte) error {
+	query := `INSERT INTO audit_log(ledger_id, item_id, user_id, api_key_id, operation, before, after)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := q.ExecContext(ctx, query, ledgerID, itemID, actor.UserID, actor.APIKeyID, operation, jsonArg(before), jsonArg(after))
+	if err != nil {
+		return fmt.Errorf("could not write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// jsonArg passes JSON as text, lib/pq would send []byte as bytea.
+func jsonArg(value []byte) any {
+	if value == nil {
+		return nil
+	}
+
+	return string(value)
+}
+
+// GetItemHistory returns every change of item, the oldest first. History is
+// kept after item is deleted.
+func (r *Repository) GetItemHistory(ctx context.Context, ledgerID, itemID int) ([]model.AuditEntry, error) {
+	return r.getAuditEntries(ctx, " WHERE a.ledger_id = $1 AND a.item_id = $2 ORDER BY a.id", ledgerID, itemID)
+}
+
+// GetAudit returns page of audit log of ledger matching params, the newest
+// entries first.
+func (r *Repository) GetAudit(ctx context.Context, params dto.AuditParams) (*model.AuditPage, error) {
+	conditions := []string{"a.ledger_id = $1"}
+	args := []any{params.LedgerID}
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if params.ItemID != 0 {
+		add("a.item_id = $%d", params.ItemID)
+	}
+	if params.UserID != 0 {
+		add("a.user_id = $%d", params.UserID)
+	}
+	if params.Operation != "" {
+		add("a.operation = $%d", params.Operation)
+	}
+	if params.From != nil {
+		add("a.created_at >= $%d", *params.From)
+	}
+	if params.To != nil {
+		add("a.created_at <= $%d", *params.To)
+	}
+	if params.BeforeID != 0 {
+		add("a.id < $%d", params.BeforeID)
+	}
+
+	// One entry more than requested tells whether there is the next page.
+	args = append(args, params.Limit+1)
+	where := " WHERE " + strings.Join(conditions, " AND ") + fmt.Sprintf(" ORDER BY a.id DESC LIMIT $%d", len(args))
+
+	entries, err := r.getAuditEntries(ctx, where, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.AuditPage{Entries: entries}
+	if len(entries) > params.Limit {
+		page.Entries = entries[:params.Limit]
+		page.NextCursor = strconv.FormatInt(page.Entries[params.Limit-1].ID, 10)
+	}
+
+	return page, nil
+}
+
+func (r *Repository) getAuditEntries(ctx context.Context, where string, args ...any) ([]model.AuditEntry, error) {
+	query := `SELECT a.id, a.item_id, a.user_id, u.login, a.api_key_id, a.operation, a.before, a.after, a.created_at
+	FROM audit_log a
+	JOIN users u ON u.id = a.user_id` + where
+
+	rows, err := r.db.Master.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get audit log from db: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []model.AuditEntry
+	for rows.Next() {
+		var (
+			entry         model.AuditEntry
+			before, after []byte
+		)
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ItemID,
+			&entry.UserID,
+			&entry.Login,
+			&entry.APIKeyID,
+			&entry.Operation,
+			&before,
+			&after,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan row result to model: %w", err)
+		}
+		entry.Before, entry.After = before, after
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get audit log from db: %w", err)
+	}
+
+	return entries, nil
+}
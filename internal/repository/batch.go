@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+)
+
+// BatchError tells which operation of a batch failed.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %s", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// ExecuteBatch runs operations on items of ledger in order within a single
+// transaction. If any of them fails nothing is changed and *BatchError is
+// returned.
+func (r *Repository) ExecuteBatch(ctx context.Context, actor model.Actor, ledgerID int, operations []dto.BatchOperation) ([]model.BatchResult, error) {
+	results := make([]model.BatchResult, 0, len(operations))
+
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		for i, operation := range operations {
+			result := model.BatchResult{Index: i, Op: operation.Op, ID: operation.ID}
+
+			var err error
+			switch operation.Op {
+			case "create":
+				result.Item, err = createItem(ctx, tx, actor, ledgerID, *operation.Item)
+				if err == nil {
+					result.ID = result.Item.ID
+				}
+				result.Status = model.BatchCreated
+			case "update":
+				err = updateItem(ctx, tx, actor, ledgerID, operation.ID, operation.Version, *operation.Changes)
+				result.Status = model.BatchUpdated
+			case "delete":
+				err = deleteItem(ctx, tx, actor, ledgerID, operation.ID, operation.Version)
+				result.Status = model.BatchDeleted
+			default:
+				err = fmt.Errorf("unknown operation %q", operation.Op)
+			}
+			if err != nil {
+				return &BatchError{Index: i, Err: err}
+			}
+
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
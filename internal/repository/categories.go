@@ -0,0 +1,477 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrNoSuchCategory       = model.NewError(model.KindNotFound, "category_not_found", "there is no category with such id")
+	ErrCategoryExists       = model.NewError(model.KindConflict, "category_exists", "category or alias with such name already exists")
+	ErrCategoryInUse        = model.NewError(model.KindConflict, "category_in_use", "category has items, subcategories or rules")
+	ErrCategoryTypeConflict = model.NewError(model.KindConflict, "category_type_conflict", "category has items, subcategories or rules of another type")
+	ErrCategoryCycle        = model.NewValidationError("category_cycle", "category can not be a subcategory of itself", []model.FieldError{
+		{Field: "parent_id", Rule: "category_cycle", Message: "parent_id must not be the category or its subcategory"},
+	})
+	ErrNoSuchParent = model.NewValidationError("parent_not_found", "there is no parent category with such id", []model.FieldError{
+		{Field: "parent_id", Rule: "category_exists", Message: "parent_id must be a category of ledger"},
+	})
+	ErrParentType = model.NewValidationError("parent_type_mismatch", "category type differs from type of its parent", []model.FieldError{
+		{Field: "type", Rule: "parent_type", Message: "type must match type of parent category"},
+	})
+	ErrUnknownCategory = model.NewValidationError("unknown_category", "there is no such category in ledger", []model.FieldError{
+		{Field: "category", Rule: "category_exists", Message: "category must be a category or alias of ledger"},
+	})
+	ErrCategoryType = model.NewValidationError("category_type_mismatch", "category does not fit type of item", []model.FieldError{
+		{Field: "category", Rule: "category_type", Message: "category must fit type of item"},
+	})
+)
+
+const categoriesQuery = `SELECT c.id, c.parent_id, c.name, COALESCE(c.type, ''), c.created_at,
+		COALESCE(array_agg(a.alias ORDER BY a.alias) FILTER (WHERE a.alias IS NOT NULL), '{}')
+	FROM categories c
+	LEFT JOIN category_aliases a ON a.category_id = c.id
+	WHERE c.ledger_id = $1`
+
+func (r *Repository) GetCategories(ctx context.Context, ledgerID int) ([]model.Category, error) {
+	return getCategories(ctx, r.db.Master, " GROUP BY c.id ORDER BY c.name", ledgerID)
+}
+
+func getCategories(ctx context.Context, q querier, conditions string, args ...any) ([]model.Category, error) {
+	rows, err := q.QueryContext(ctx, categoriesQuery+conditions, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get categories from db: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []model.Category
+	for rows.Next() {
+		var category model.Category
+		err := rows.Scan(
+			&category.ID,
+			&category.ParentID,
+			&category.Name,
+			&category.Type,
+			&category.CreatedAt,
+			pq.Array(&category.Aliases),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan row result to model: %w", err)
+		}
+
+		categories = append(categories, category)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get categories from db: %w", err)
+	}
+
+	return categories, nil
+}
+
+func getCategory(ctx context.Context, q querier, ledgerID, id int) (*model.Category, error) {
+	categories, err := getCategories(ctx, q, " AND c.id = $2 GROUP BY c.id", ledgerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return nil, ErrNoSuchCategory
+	}
+
+	return &categories[0], nil
+}
+
+func (r *Repository) CreateCategory(ctx context.Context, ledgerID int, category dto.CreateCategory) (*model.Category, error) {
+	var created *model.Category
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := checkParent(ctx, tx, ledgerID, 0, category.ParentID, category.Type); err != nil {
+			return err
+		}
+		if err := checkCategoryNames(ctx, tx, ledgerID, 0, append([]string{category.Name}, category.Aliases...)); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO categories(ledger_id, parent_id, name, type)
+		VALUES ($1, $2, $3, NULLIF($4, '')) RETURNING id`
+
+		var id int
+		err := tx.QueryRowContext(ctx, query, ledgerID, category.ParentID, category.Name, category.Type).Scan(&id)
+		if isUniqueViolation(err) {
+			return ErrCategoryExists
+		}
+		if err != nil {
+			return fmt.Errorf("could not create category in db: %w", err)
+		}
+
+		if err := insertAliases(ctx, tx, ledgerID, id, category.Aliases); err != nil {
+			return err
+		}
+
+		created, err = getCategory(ctx, tx, ledgerID, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// UpdateCategory replaces category with id. Items of renamed category are
+// renamed too and recorded in audit log as changed by actor. The new type
+// must fit items and rules of category and its subcategories.
+func (r *Repository) UpdateCategory(ctx context.Context, actor model.Actor, ledgerID, id int, category dto.UpdateCategory) (*model.Category, error) {
+	var updated *model.Category
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		name, err := lockCategory(ctx, tx, ledgerID, id)
+		if err != nil {
+			return err
+		}
+
+		categoryType, err := checkParent(ctx, tx, ledgerID, id, category.ParentID, category.Type)
+		if err != nil {
+			return err
+		}
+		if err := checkCategoryNames(ctx, tx, ledgerID, id, append([]string{category.Name}, category.Aliases...)); err != nil {
+			return err
+		}
+		if err := checkSubtreeType(ctx, tx, ledgerID, id, categoryType); err != nil {
+			return err
+		}
+
+		query := `UPDATE categories SET name = $3, parent_id = $4, type = NULLIF($5, '')
+		WHERE id = $1 AND ledger_id = $2`
+		_, err = tx.ExecContext(ctx, query, id, ledgerID, category.Name, category.ParentID, category.Type)
+		if isUniqueViolation(err) {
+			return ErrCategoryExists
+		}
+		if err != nil {
+			return fmt.Errorf("could not update category in db: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM category_aliases WHERE category_id = $1", id); err != nil {
+			return fmt.Errorf("could not delete aliases of category: %w", err)
+		}
+		if err := insertAliases(ctx, tx, ledgerID, id, category.Aliases); err != nil {
+			return err
+		}
+
+		if name != category.Name {
+			if err := moveItems(ctx, tx, actor, ledgerID, name, category.Name); err != nil {
+				return err
+			}
+		}
+
+		updated, err = getCategory(ctx, tx, ledgerID, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// DeleteCategory deletes category with id which has no subcategories. Unless
+// replacementID is zero, items of category are moved to the replacement,
+// which takes name and aliases of deleted category as its aliases, and rules
+// assign the replacement. Otherwise category must have no items, including
+// the ones in trash, and no rules.
+func (r *Repository) DeleteCategory(ctx context.Context, actor model.Actor, ledgerID, id, replacementID int) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		name, err := lockCategory(ctx, tx, ledgerID, id)
+		if err != nil {
+			return err
+		}
+
+		query := `SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1),
+			EXISTS (SELECT 1 FROM items WHERE ledger_id = $2 AND category = $3)
+				OR EXISTS (SELECT 1 FROM rules WHERE ledger_id = $2 AND category = $3)`
+
+		var hasSubcategories, inUse bool
+		if err := tx.QueryRowContext(ctx, query, id, ledgerID, name).Scan(&hasSubcategories, &inUse); err != nil {
+			return fmt.Errorf("could not check usage of category: %w", err)
+		}
+		if hasSubcategories || (inUse && replacementID == 0) {
+			return ErrCategoryInUse
+		}
+
+		if replacementID != 0 {
+			if err := replaceCategory(ctx, tx, actor, ledgerID, id, name, replacementID); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM categories WHERE id = $1", id); err != nil {
+			return fmt.Errorf("could not delete category from db: %w", err)
+		}
+
+		if replacementID != 0 {
+			return insertAliases(ctx, tx, ledgerID, replacementID, []string{name})
+		}
+
+		return nil
+	})
+}
+
+// replaceCategory moves items and aliases of category to its replacement.
+func replaceCategory(ctx context.Context, q querier, actor model.Actor, ledgerID, id int, name string, replacementID int) error {
+	replacement, err := lockCategory(ctx, q, ledgerID, replacementID)
+	if err != nil {
+		return fmt.Errorf("replacement: %w", err)
+	}
+
+	replacementType, err := categoryType(ctx, q, replacementID)
+	if err != nil {
+		return err
+	}
+	if replacementType != "" {
+		var mismatch bool
+		query := "SELECT EXISTS (SELECT 1 FROM items WHERE ledger_id = $1 AND category = $2 AND type <> $3)"
+		if err := q.QueryRowContext(ctx, query, ledgerID, name, replacementType).Scan(&mismatch); err != nil {
+			return fmt.Errorf("could not check items of category: %w", err)
+		}
+		if mismatch {
+			return ErrCategoryTypeConflict
+		}
+	}
+
+	if err := moveItems(ctx, q, actor, ledgerID, name, replacement); err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, "UPDATE category_aliases SET category_id = $2 WHERE category_id = $1", id, replacementID)
+	if err != nil {
+		return fmt.Errorf("could not move aliases of category: %w", err)
+	}
+
+	return nil
+}
+
+// lockCategory locks category until the end of transaction and returns its
+// name.
+func lockCategory(ctx context.Context, q querier, ledgerID, id int) (string, error) {
+	var name string
+	err := q.QueryRowContext(ctx, "SELECT name FROM categories WHERE id = $1 AND ledger_id = $2 FOR UPDATE",
+		id, ledgerID).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNoSuchCategory
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not lock category: %w", err)
+	}
+
+	return name, nil
+}
+
+// categoryType returns type category with id has, either its own or the one
+// of the nearest ancestor. Empty type fits both income and expense items.
+func categoryType(ctx context.Context, q querier, id int) (string, error) {
+	query := `WITH RECURSIVE ancestors AS (
+		SELECT id, parent_id, type, 0 AS depth FROM categories WHERE id = $1
+		UNION ALL
+		SELECT c.id, c.parent_id, c.type, a.depth + 1
+		FROM categories c
+		JOIN ancestors a ON c.id = a.parent_id
+	)
+	SELECT COALESCE((SELECT type FROM ancestors WHERE type IS NOT NULL ORDER BY depth LIMIT 1), '')`
+
+	var categoryType string
+	if err := q.QueryRowContext(ctx, query, id).Scan(&categoryType); err != nil {
+		return "", fmt.Errorf("could not get type of category: %w", err)
+	}
+
+	return categoryType, nil
+}
+
+// checkParent makes sure category with id, zero for a new one, can be a
+// subcategory of parentID: the parent exists in ledger, is not the category
+// itself or its subcategory, and type of category matches the parent's one.
+// It returns type category will have.
+func checkParent(ctx context.Context, q querier, ledgerID, id int, parentID *int, ownType string) (string, error) {
+	if parentID == nil {
+		return ownType, nil
+	}
+	if *parentID == id {
+		return "", ErrCategoryCycle
+	}
+
+	query := `WITH RECURSIVE ancestors AS (
+		SELECT id, parent_id FROM categories WHERE id = $1 AND ledger_id = $2
+		UNION ALL
+		SELECT c.id, c.parent_id
+		FROM categories c
+		JOIN ancestors a ON c.id = a.parent_id
+	)
+	SELECT COUNT(*) > 0, COALESCE(bool_or(id = $3), false) FROM ancestors`
+
+	var exists, cycle bool
+	if err := q.QueryRowContext(ctx, query, *parentID, ledgerID, id).Scan(&exists, &cycle); err != nil {
+		return "", fmt.Errorf("could not check parent category: %w", err)
+	}
+	if !exists {
+		return "", ErrNoSuchParent
+	}
+	if cycle {
+		return "", ErrCategoryCycle
+	}
+
+	parentType, err := categoryType(ctx, q, *parentID)
+	if err != nil {
+		return "", err
+	}
+	if parentType != "" && ownType != "" && ownType != parentType {
+		return "", ErrParentType
+	}
+	if ownType == "" {
+		return parentType, nil
+	}
+
+	return ownType, nil
+}
+
+// checkSubtreeType makes sure category with id can have categoryType: its
+// subcategories have no other type of their own, and items and rules of
+// category and its subcategories fit types categories will have.
+func checkSubtreeType(ctx context.Context, q querier, ledgerID, id int, categoryType string) error {
+	query := `WITH RECURSIVE tree AS (
+		SELECT id, name, NULLIF($2::text, '') AS type, false AS conflict FROM categories WHERE id = $1
+		UNION ALL
+		SELECT c.id, c.name, COALESCE(c.type, t.type)::text,
+			c.type IS NOT NULL AND t.type IS NOT NULL AND c.type <> t.type
+		FROM categories c
+		JOIN tree t ON c.parent_id = t.id
+	)
+	SELECT EXISTS (SELECT 1 FROM tree WHERE conflict)
+		OR EXISTS (SELECT 1 FROM items i JOIN tree t ON i.category = t.name
+			WHERE i.ledger_id = $3 AND t.type IS NOT NULL AND i.type <> t.type)
+		OR EXISTS (SELECT 1 FROM rules r JOIN tree t ON r.category = t.name
+			WHERE r.ledger_id = $3 AND t.type IS NOT NULL AND r.type IS NOT NULL AND r.type <> t.type)`
+
+	var conflict bool
+	if err := q.QueryRowContext(ctx, query, id, categoryType, ledgerID).Scan(&conflict); err != nil {
+		return fmt.Errorf("could not check type of category: %w", err)
+	}
+	if conflict {
+		return ErrCategoryTypeConflict
+	}
+
+	return nil
+}
+
+// checkCategoryNames makes sure none of names is a name or an alias of
+// another category of ledger, case aside.
+func checkCategoryNames(ctx context.Context, q querier, ledgerID, id int, names []string) error {
+	query := `SELECT EXISTS (SELECT 1 FROM categories
+			WHERE ledger_id = $1 AND id <> $2 AND lower(name) IN (SELECT lower(unnest($3::text[]))))
+		OR EXISTS (SELECT 1 FROM category_aliases
+			WHERE ledger_id = $1 AND category_id <> $2 AND lower(alias) IN (SELECT lower(unnest($3::text[]))))`
+
+	var taken bool
+	if err := q.QueryRowContext(ctx, query, ledgerID, id, pq.Array(names)).Scan(&taken); err != nil {
+		return fmt.Errorf("could not check names of category: %w", err)
+	}
+	if taken {
+		return ErrCategoryExists
+	}
+
+	return nil
+}
+
+func insertAliases(ctx context.Context, q querier, ledgerID, id int, aliases []string) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	query := "INSERT INTO category_aliases(category_id, ledger_id, alias) SELECT $1, $2, unnest($3::text[])"
+	_, err := q.ExecContext(ctx, query, id, ledgerID, pq.Array(aliases))
+	if isUniqueViolation(err) {
+		return ErrCategoryExists
+	}
+	if err != nil {
+		return fmt.Errorf("could not create aliases of category: %w", err)
+	}
+
+	return nil
+}
+
+// moveItems changes category of every item of ledger, including the ones in
+// trash, and records the changes in audit log. Rules assigning the category
+// assign the new one from now on.
+func moveItems(ctx context.Context, q querier, actor model.Actor, ledgerID int, from, to string) error {
+	query := `WITH moved AS (
+		UPDATE items SET category = $3, version = items.version + 1
+		FROM (SELECT id, to_jsonb(items) AS item_row FROM items WHERE ledger_id = $1 AND category = $2) AS before
+		WHERE items.id = before.id
+		RETURNING items.id, before.item_row, to_jsonb(items) AS after_row
+	)
+	INSERT INTO audit_log(ledger_id, item_id, user_id, api_key_id, operation, before, after)
+	SELECT $1, id, $4, $5, $6, item_row, after_row FROM moved`
+
+	_, err := q.ExecContext(ctx, query, ledgerID, from, to, actor.UserID, actor.APIKeyID, model.AuditUpdate)
+	if err != nil {
+		return fmt.Errorf("could not move items to category: %w", err)
+	}
+
+	_, err = q.ExecContext(ctx, "UPDATE rules SET category = $3 WHERE ledger_id = $1 AND category = $2", ledgerID, from, to)
+	if err != nil {
+		return fmt.Errorf("could not move rules to category: %w", err)
+	}
+
+	return nil
+}
+
+// resolveCategory returns name items with category are stored under in
+// ledger: name of category category is a name or an alias of, case aside.
+// Category must fit itemType. Ledgers without categories accept any
+// category as is.
+func resolveCategory(ctx context.Context, q querier, ledgerID int, category, itemType string) (string, error) {
+	query := `WITH RECURSIVE matched AS (
+		SELECT id FROM categories WHERE ledger_id = $1 AND lower(name) = lower($2)
+		UNION
+		SELECT category_id FROM category_aliases WHERE ledger_id = $1 AND lower(alias) = lower($2)
+	), ancestors AS (
+		SELECT c.id, c.parent_id, c.name, c.type, 0 AS depth
+		FROM categories c
+		JOIN matched m ON c.id = m.id
+		UNION ALL
+		SELECT c.id, c.parent_id, c.name, c.type, a.depth + 1
+		FROM categories c
+		JOIN ancestors a ON c.id = a.parent_id
+	)
+	SELECT EXISTS (SELECT 1 FROM categories WHERE ledger_id = $1),
+		(SELECT name FROM ancestors WHERE depth = 0),
+		COALESCE((SELECT type FROM ancestors WHERE type IS NOT NULL ORDER BY depth LIMIT 1), '')`
+
+	var (
+		managed      bool
+		name         sql.NullString
+		categoryType string
+	)
+	if err := q.QueryRowContext(ctx, query, ledgerID, category).Scan(&managed, &name, &categoryType); err != nil {
+		return "", fmt.Errorf("could not resolve category: %w", err)
+	}
+
+	if !managed {
+		return category, nil
+	}
+	if !name.Valid {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCategory, category)
+	}
+	if categoryType != "" && categoryType != itemType {
+		return "", fmt.Errorf("%w: %s is for %s items", ErrCategoryType, name.String, categoryType)
+	}
+
+	return name.String, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
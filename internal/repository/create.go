@@ -2,33 +2,93 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
 	"github.com/Komilov31/sales-tracker/internal/model"
 )
 
-func (r *Repository) CreateItem(ctx context.Context, item dto.CreateItem) (*model.Item, error) {
-	query := `INSERT INTO items(type, amount, date, category)
-	VALUES ($1, $2, $3, $4) RETURNING id, created_at;`
+func (r *Repository) CreateItem(ctx context.Context, actor model.Actor, ledgerID int, item dto.CreateItem) (*model.Item, error) {
+	var createdItem *model.Item
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		createdItem, err = createItem(ctx, tx, actor, ledgerID, item)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return createdItem, nil
+}
+
+// CreateItems creates all items in a single transaction: either every item
+// is created or none.
+func (r *Repository) CreateItems(ctx context.Context, actor model.Actor, ledgerID int, items []dto.CreateItem) ([]model.Item, error) {
+	createdItems := make([]model.Item, 0, len(items))
 
-	var createdItem model.Item
-	err := r.db.Master.QueryRowContext(
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		for i, item := range items {
+			createdItem, err := createItem(ctx, tx, actor, ledgerID, item)
+			if err != nil {
+				return fmt.Errorf("item %d: %w", i+1, err)
+			}
+			createdItems = append(createdItems, *createdItem)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return createdItems, nil
+}
+
+func createItem(ctx context.Context, q querier, actor model.Actor, ledgerID int, item dto.CreateItem) (*model.Item, error) {
+	category, err := resolveCategory(ctx, q, ledgerID, item.Category, item.Type)
+	if err != nil {
+		return nil, err
+	}
+	item.Category = category
+
+	query := `INSERT INTO items(ledger_id, type, amount, date, category, currency, description, counterparty)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, version, created_at, to_jsonb(items);`
+
+	var (
+		createdItem model.Item
+		after       []byte
+	)
+	err = q.QueryRowContext(
 		ctx,
 		query,
+		ledgerID,
 		item.Type,
 		item.Amount,
 		item.Date,
 		item.Category,
-	).Scan(&createdItem.ID, &createdItem.CreatedAt)
+		item.Currency,
+		item.Description,
+		item.Counterparty,
+	).Scan(&createdItem.ID, &createdItem.Version, &createdItem.CreatedAt, &after)
+	if isAmountPrecisionViolation(err) {
+		return nil, ErrAmountPrecision
+	}
 	if err != nil {
 		return nil, fmt.Errorf("could not create item in db: %w", err)
 	}
 
+	if err := writeAudit(ctx, q, actor, ledgerID, createdItem.ID, model.AuditCreate, nil, after); err != nil {
+		return nil, err
+	}
+
 	createdItem.Type = item.Type
 	createdItem.Amount = item.Amount
 	createdItem.Date = item.Date
 	createdItem.Category = item.Category
+	createdItem.Currency = item.Currency
+	createdItem.Description = item.Description
+	createdItem.Counterparty = item.Counterparty
 
 	return &createdItem, nil
 }
@@ -2,25 +2,99 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/Komilov31/sales-tracker/internal/model"
 )
 
-func (r *Repository) DeleteItem(ctx context.Context, id int) error {
-	query := "DELETE FROM items WHERE id = $1"
+func (r *Repository) DeleteItem(ctx context.Context, actor model.Actor, ledgerID, id, version int) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		return deleteItem(ctx, tx, actor, ledgerID, id, version)
+	})
+}
 
-	result, err := r.db.Master.ExecContext(ctx, query, id)
+// deleteItem moves item to trash, it stays there until restored or purged by
+// retention.
+func deleteItem(ctx context.Context, q querier, actor model.Actor, ledgerID, id, version int) error {
+	before, err := lockItem(ctx, q, ledgerID, id, version)
 	if err != nil {
+		return err
+	}
+
+	query := `UPDATE items SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+	WHERE id = $1 AND ledger_id = $2`
+
+	if _, err := q.ExecContext(ctx, query, id, ledgerID); err != nil {
 		return fmt.Errorf("could not delete item from db: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return writeAudit(ctx, q, actor, ledgerID, id, model.AuditDelete, before, nil)
+}
+
+// RestoreItem takes item out of trash.
+func (r *Repository) RestoreItem(ctx context.Context, actor model.Actor, ledgerID, id int) (*model.Item, error) {
+	var restored *model.Item
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		query := `WITH deleted AS (
+			SELECT * FROM items WHERE id = $1 AND ledger_id = $2 AND deleted_at IS NOT NULL FOR UPDATE
+		)
+		UPDATE items SET deleted_at = NULL, version = items.version + 1
+		FROM deleted
+		WHERE items.id = deleted.id
+		RETURNING items.id, items.type, items.amount, items.date, items.category, items.currency,
+			items.description, items.counterparty, items.version, items.created_at,
+			to_jsonb(deleted), to_jsonb(items)`
+
+		var (
+			item          model.Item
+			before, after []byte
+		)
+		err := tx.QueryRowContext(ctx, query, id, ledgerID).Scan(
+			&item.ID,
+			&item.Type,
+			&item.Amount,
+			&item.Date,
+			&item.Category,
+			&item.Currency,
+			&item.Description,
+			&item.Counterparty,
+			&item.Version,
+			&item.CreatedAt,
+			&before,
+			&after,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoSuchItem
+		}
+		if err != nil {
+			return fmt.Errorf("could not restore item in db: %w", err)
+		}
+		restored = &item
+
+		return writeAudit(ctx, tx, actor, ledgerID, id, model.AuditRestore, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// PurgeTrash deletes for good items of all ledgers which are in trash since
+// before. Their history stays in audit log.
+func (r *Repository) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Master.ExecContext(ctx, "DELETE FROM items WHERE deleted_at < $1", before)
 	if err != nil {
-		return fmt.Errorf("could not delete item from db: %w", err)
+		return 0, fmt.Errorf("could not purge trash in db: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return ErrNoSuchItem
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("could not purge trash in db: %w", err)
 	}
 
-	return nil
+	return purged, nil
 }
@@ -2,23 +2,80 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
 	"github.com/Komilov31/sales-tracker/internal/model"
 )
 
+// GetItem returns item of ledger unless it is in trash.
+func (r *Repository) GetItem(ctx context.Context, ledgerID, id int) (*model.Item, error) {
+	query := `SELECT id, type, amount, date, category, currency, description, counterparty, version, created_at
+	FROM items WHERE id = $1 AND ledger_id = $2 AND deleted_at IS NULL`
+
+	var item model.Item
+	err := r.db.Master.QueryRowContext(ctx, query, id, ledgerID).Scan(
+		&item.ID,
+		&item.Type,
+		&item.Amount,
+		&item.Date,
+		&item.Category,
+		&item.Currency,
+		&item.Description,
+		&item.Counterparty,
+		&item.Version,
+		&item.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoSuchItem
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get item from db: %w", err)
+	}
+
+	return &item, nil
+}
+
 func (r *Repository) GetAllItems(ctx context.Context, params dto.GetItemsParams) ([]model.Item, error) {
-	query := "SELECT * FROM items"
+	var items []model.Item
+	err := r.StreamItems(ctx, params, func(item model.Item) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
 
+// StreamItems calls fn for every item matching params as rows are read from
+// the database, without keeping them in memory. Iteration stops at the first
+// error returned by fn.
+func (r *Repository) StreamItems(ctx context.Context, params dto.GetItemsParams, fn func(model.Item) error) error {
+	query := "SELECT id, type, amount, date, category, currency, description, counterparty, version, created_at, deleted_at FROM items"
+
+	where, args := prepareFilters(params)
+	where, args, err := prepareKeyset(params, where, args)
+	if err != nil {
+		return fmt.Errorf("could not get items from db: %w", err)
+	}
 	orderBy := prepareParams(params)
 
-	rows, err := r.db.Master.QueryContext(ctx, query+orderBy)
+	limit := ""
+	if params.Limit > 0 {
+		args = append(args, params.Limit)
+		limit = fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.Master.QueryContext(ctx, query+where+orderBy+limit, args...)
 	if err != nil {
-		return nil, fmt.Errorf("could not get items from db: %w", err)
+		return fmt.Errorf("could not get items from db: %w", err)
 	}
+	defer rows.Close()
 
-	var items []model.Item
 	for rows.Next() {
 		var item model.Item
 		err := rows.Scan(
@@ -27,14 +84,38 @@ func (r *Repository) GetAllItems(ctx context.Context, params dto.GetItemsParams)
 			&item.Amount,
 			&item.Date,
 			&item.Category,
+			&item.Currency,
+			&item.Description,
+			&item.Counterparty,
+			&item.Version,
 			&item.CreatedAt,
+			&item.DeletedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("could not scan row result to model: %w", err)
+			return fmt.Errorf("could not scan row result to model: %w", err)
 		}
 
-		items = append(items, item)
+		if err := fn(item); err != nil {
+			return err
+		}
 	}
 
-	return items, nil
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not get items from db: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) CountItems(ctx context.Context, params dto.GetItemsParams) (int, error) {
+	query := "SELECT COUNT(*) FROM items"
+
+	where, args := prepareFilters(params)
+
+	var count int
+	if err := r.db.Master.QueryRowContext(ctx, query+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("could not count items in db: %w", err)
+	}
+
+	return count, nil
 }
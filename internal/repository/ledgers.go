@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Komilov31/sales-tracker/internal/model"
+)
+
+// CreateLedger creates ledger with user as its admin.
+func (r *Repository) CreateLedger(ctx context.Context, userID int, name string) (*model.Ledger, error) {
+	var ledger *model.Ledger
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		ledger, err = createLedger(ctx, tx, userID, name)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ledger, nil
+}
+
+func createLedger(ctx context.Context, q querier, userID int, name string) (*model.Ledger, error) {
+	query := "INSERT INTO ledgers(name) VALUES ($1) RETURNING id, created_at"
+
+	ledger := model.Ledger{Name: name, Role: model.RoleAdmin}
+	if err := q.QueryRowContext(ctx, query, name).Scan(&ledger.ID, &ledger.CreatedAt); err != nil {
+		return nil, fmt.Errorf("could not create ledger in db: %w", err)
+	}
+
+	query = "INSERT INTO ledger_members(ledger_id, user_id, role) VALUES ($1, $2, $3)"
+	if _, err := q.ExecContext(ctx, query, ledger.ID, userID, ledger.Role); err != nil {
+		return nil, fmt.Errorf("could not add ledger member in db: %w", err)
+	}
+
+	return &ledger, nil
+}
+
+// GetLedgers returns ledgers user is a member of with user's role, the
+// oldest first.
+func (r *Repository) GetLedgers(ctx context.Context, userID int) ([]model.Ledger, error) {
+	query := `SELECT l.id, l.name, m.role, l.created_at
+	FROM ledgers l
+	JOIN ledger_members m ON m.ledger_id = l.id
+	WHERE m.user_id = $1
+	ORDER BY l.id`
+
+	rows, err := r.db.Master.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get ledgers from db: %w", err)
+	}
+	defer rows.Close()
+
+	var ledgers []model.Ledger
+	for rows.Next() {
+		var ledger model.Ledger
+		if err := rows.Scan(&ledger.ID, &ledger.Name, &ledger.Role, &ledger.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan row result to model: %w", err)
+		}
+
+		ledgers = append(ledgers, ledger)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get ledgers from db: %w", err)
+	}
+
+	return ledgers, nil
+}
+
+// GetLedger returns ledger if user is its member, ErrNoSuchLedger otherwise,
+// so that ledgers of others can not be told from missing ones.
+func (r *Repository) GetLedger(ctx context.Context, userID, ledgerID int) (*model.Ledger, error) {
+	query := `SELECT l.id, l.name, m.role, l.created_at
+	FROM ledgers l
+	JOIN ledger_members m ON m.ledger_id = l.id
+	WHERE m.user_id = $1 AND l.id = $2`
+
+	var ledger model.Ledger
+	err := r.db.Master.QueryRowContext(ctx, query, userID, ledgerID).
+		Scan(&ledger.ID, &ledger.Name, &ledger.Role, &ledger.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchLedger
+		}
+		return nil, fmt.Errorf("could not get ledger from db: %w", err)
+	}
+
+	return &ledger, nil
+}
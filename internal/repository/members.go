@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/lib/pq"
+)
+
+func (r *Repository) GetMembers(ctx context.Context, ledgerID int) ([]model.Member, error) {
+	query := `SELECT u.id, u.login, m.role, m.created_at
+	FROM ledger_members m
+	JOIN users u ON u.id = m.user_id
+	WHERE m.ledger_id = $1
+	ORDER BY m.created_at, u.id`
+
+	rows, err := r.db.Master.QueryContext(ctx, query, ledgerID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get members from db: %w", err)
+	}
+	defer rows.Close()
+
+	var members []model.Member
+	for rows.Next() {
+		var member model.Member
+		if err := rows.Scan(&member.UserID, &member.Login, &member.Role, &member.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan row result to model: %w", err)
+		}
+
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get members from db: %w", err)
+	}
+
+	return members, nil
+}
+
+// AddMember gives existing user with login access to ledger with role.
+func (r *Repository) AddMember(ctx context.Context, ledgerID int, login, role string) (*model.Member, error) {
+	query := `INSERT INTO ledger_members(ledger_id, user_id, role)
+	SELECT $1, id, $3 FROM users WHERE login = $2
+	RETURNING user_id, created_at`
+
+	member := model.Member{Login: login, Role: role}
+	err := r.db.Master.QueryRowContext(ctx, query, ledgerID, login, role).Scan(&member.UserID, &member.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNoSuchUser
+		case errors.As(err, &pqErr) && pqErr.Code == uniqueViolation:
+			return nil, ErrMemberExists
+		}
+		return nil, fmt.Errorf("could not add member in db: %w", err)
+	}
+
+	return &member, nil
+}
+
+// UpdateMemberRole changes role of ledger member. The last admin can not be
+// demoted, so that ledger is always manageable.
+func (r *Repository) UpdateMemberRole(ctx context.Context, ledgerID, userID int, role string) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		if role != model.RoleAdmin {
+			if err := checkOtherAdmins(ctx, tx, ledgerID, userID); err != nil {
+				return err
+			}
+		}
+
+		query := "UPDATE ledger_members SET role = $3 WHERE ledger_id = $1 AND user_id = $2"
+		result, err := tx.ExecContext(ctx, query, ledgerID, userID, role)
+		if err != nil {
+			return fmt.Errorf("could not update member in db: %w", err)
+		}
+
+		return checkMemberAffected(result)
+	})
+}
+
+// RemoveMember takes access to ledger away from user, unless they are its
+// last admin.
+func (r *Repository) RemoveMember(ctx context.Context, ledgerID, userID int) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		if err := checkOtherAdmins(ctx, tx, ledgerID, userID); err != nil {
+			return err
+		}
+
+		query := "DELETE FROM ledger_members WHERE ledger_id = $1 AND user_id = $2"
+		result, err := tx.ExecContext(ctx, query, ledgerID, userID)
+		if err != nil {
+			return fmt.Errorf("could not remove member from db: %w", err)
+		}
+
+		return checkMemberAffected(result)
+	})
+}
+
+// checkOtherAdmins returns ErrLastAdmin if user is the only admin of ledger.
+// Admins of ledger are locked until the end of transaction, so that two
+// admins can not demote each other at the same time.
+func checkOtherAdmins(ctx context.Context, tx *sql.Tx, ledgerID, userID int) error {
+	query := `SELECT user_id FROM ledger_members
+	WHERE ledger_id = $1 AND role = 'admin'
+	FOR UPDATE`
+
+	rows, err := tx.QueryContext(ctx, query, ledgerID)
+	if err != nil {
+		return fmt.Errorf("could not get admins from db: %w", err)
+	}
+	defer rows.Close()
+
+	isAdmin, others := false, 0
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return fmt.Errorf("could not scan row result to model: %w", err)
+		}
+		if id == userID {
+			isAdmin = true
+		} else {
+			others++
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("could not get admins from db: %w", err)
+	}
+
+	if isAdmin && others == 0 {
+		return ErrLastAdmin
+	}
+
+	return nil
+}
+
+func checkMemberAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not change member in db: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoSuchMember
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+)
+
+func (r *Repository) CreateExchangeRate(ctx context.Context, ledgerID int, rate dto.CreateExchangeRate) (*model.ExchangeRate, error) {
+	query := `INSERT INTO exchange_rates(ledger_id, base, quote, date, rate)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (ledger_id, base, quote, date) DO UPDATE SET rate = EXCLUDED.rate
+	RETURNING id, created_at;`
+
+	var createdRate model.ExchangeRate
+	err := r.db.Master.QueryRowContext(
+		ctx,
+		query,
+		ledgerID,
+		rate.Base,
+		rate.Quote,
+		rate.Date,
+		rate.Rate,
+	).Scan(&createdRate.ID, &createdRate.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("could not create exchange rate in db: %w", err)
+	}
+
+	createdRate.Base = rate.Base
+	createdRate.Quote = rate.Quote
+	createdRate.Date = rate.Date
+	createdRate.Rate = rate.Rate
+
+	return &createdRate, nil
+}
+
+func (r *Repository) GetExchangeRates(ctx context.Context, params dto.GetExchangeRatesParams) ([]model.ExchangeRate, error) {
+	query := `SELECT id, base, quote, to_char(date, 'YYYY-MM-DD'), rate, created_at
+	FROM exchange_rates
+	WHERE ledger_id = $1 AND ($2 = '' OR base = $2) AND ($3 = '' OR quote = $3)
+	ORDER BY base, quote, date DESC`
+
+	rows, err := r.db.Master.QueryContext(ctx, query, params.LedgerID, params.Base, params.Quote)
+	if err != nil {
+		return nil, fmt.Errorf("could not get exchange rates from db: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []model.ExchangeRate
+	for rows.Next() {
+		var rate model.ExchangeRate
+		err := rows.Scan(
+			&rate.ID,
+			&rate.Base,
+			&rate.Quote,
+			&rate.Date,
+			&rate.Rate,
+			&rate.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan row result to model: %w", err)
+		}
+
+		rates = append(rates, rate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get exchange rates from db: %w", err)
+	}
+
+	return rates, nil
+}
+
+func (r *Repository) DeleteExchangeRate(ctx context.Context, ledgerID, id int) error {
+	query := "DELETE FROM exchange_rates WHERE id = $1 AND ledger_id = $2"
+
+	result, err := r.db.Master.ExecContext(ctx, query, id, ledgerID)
+	if err != nil {
+		return fmt.Errorf("could not delete exchange rate from db: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not delete exchange rate from db: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoSuchRate
+	}
+
+	return nil
+}
@@ -1,15 +1,43 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/lib/pq"
 	"github.com/wb-go/wbf/dbpg"
 )
 
 var (
-	ErrNoSuchItem = errors.New("there is no item with such id")
+	ErrNoSuchItem      = model.NewError(model.KindNotFound, "item_not_found", "there is no item with such id")
+	ErrNoSuchRate      = model.NewError(model.KindNotFound, "rate_not_found", "there is no exchange rate with such id")
+	ErrNoExchangeRate  = model.NewError(model.KindValidation, "no_exchange_rate", "no exchange rate to convert")
+	ErrAmountPrecision = model.NewValidationError("amount_precision", "amount has more decimal places than its currency allows", []model.FieldError{
+		{Field: "amount", Rule: "currency_precision", Message: "amount has more decimal places than its currency allows"},
+	})
+	ErrNoSuchUser         = model.NewError(model.KindNotFound, "user_not_found", "there is no user with such login")
+	ErrUserExists         = model.NewError(model.KindConflict, "user_exists", "user with such login already exists")
+	ErrRegistrationClosed = model.NewError(model.KindForbidden, "registration_closed", "only admins can register new users")
+	ErrNoSuchLedger       = model.NewError(model.KindNotFound, "ledger_not_found", "there is no ledger with such id available to user")
+	ErrNoSuchMember       = model.NewError(model.KindNotFound, "member_not_found", "user is not a member of ledger")
+	ErrMemberExists       = model.NewError(model.KindConflict, "member_exists", "user is already a member of ledger")
+	ErrLastAdmin          = model.NewError(model.KindConflict, "last_admin", "ledger must keep at least one admin")
+	ErrNoSuchAPIKey       = model.NewError(model.KindNotFound, "api_key_not_found", "there is no api key with such id")
+	ErrVersionConflict    = model.NewError(model.KindPreconditionFailed, "version_conflict", "item was changed since the given version")
 )
 
+// amountPrecisionConstraint guards amounts against having more decimal places
+// than exponent of their currency.
+const amountPrecisionConstraint = "items_amount_precision"
+
+func isAmountPrecisionViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Constraint == amountPrecisionConstraint
+}
+
 type Repository struct {
 	db *dbpg.DB
 }
@@ -19,3 +47,31 @@ func New(db *dbpg.DB) *Repository {
 		db: db,
 	}
 }
+
+// querier is implemented by both *sql.DB and *sql.Tx, so that the same query
+// can run either on its own or as a part of a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// withTx runs fn within a transaction which is committed if fn succeeds and
+// rolled back otherwise.
+func (r *Repository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wb-go/wbf/dbpg"
+
+	"github.com/Komilov31/sales-tracker/internal/model"
+)
+
+const testLedgerID = 1
+
+func newTestRepository(t *testing.T) (*Repository, sqlmock.Sqlmock) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return New(&dbpg.DB{Master: db}), dbMock
+}
+
+func TestDeleteCategory(t *testing.T) {
+	ctx := context.Background()
+	actor := model.Actor{UserID: 1}
+
+	t.Run("used by rules", func(t *testing.T) {
+		repo, dbMock := newTestRepository(t)
+		dbMock.ExpectBegin()
+		dbMock.ExpectQuery("SELECT name FROM categories").
+			WithArgs(2, testLedgerID).
+			WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Еда"))
+		dbMock.ExpectQuery(`FROM items WHERE ledger_id = \$2 AND category = \$3\)\s+OR EXISTS \(SELECT 1 FROM rules WHERE ledger_id = \$2 AND category = \$3\)`).
+			WithArgs(2, testLedgerID, "Еда").
+			WillReturnRows(sqlmock.NewRows([]string{"subcategories", "in_use"}).AddRow(false, true))
+		dbMock.ExpectRollback()
+
+		err := repo.DeleteCategory(ctx, actor, testLedgerID, 2, 0)
+
+		assert.ErrorIs(t, err, ErrCategoryInUse)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("unused", func(t *testing.T) {
+		repo, dbMock := newTestRepository(t)
+		dbMock.ExpectBegin()
+		dbMock.ExpectQuery("SELECT name FROM categories").
+			WithArgs(2, testLedgerID).
+			WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Еда"))
+		dbMock.ExpectQuery("FROM rules WHERE").
+			WithArgs(2, testLedgerID, "Еда").
+			WillReturnRows(sqlmock.NewRows([]string{"subcategories", "in_use"}).AddRow(false, false))
+		dbMock.ExpectExec("DELETE FROM categories").
+			WithArgs(2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectCommit()
+
+		err := repo.DeleteCategory(ctx, actor, testLedgerID, 2, 0)
+
+		assert.NoError(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestCheckSubtreeType(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rules of another type", func(t *testing.T) {
+		repo, dbMock := newTestRepository(t)
+		dbMock.ExpectQuery(`FROM rules r JOIN tree t ON r.category = t.name\s+WHERE r.ledger_id = \$3 AND t.type IS NOT NULL AND r.type IS NOT NULL AND r.type <> t.type`).
+			WithArgs(2, "расход", testLedgerID).
+			WillReturnRows(sqlmock.NewRows([]string{"conflict"}).AddRow(true))
+
+		err := checkSubtreeType(ctx, repo.db.Master, testLedgerID, 2, "расход")
+
+		assert.ErrorIs(t, err, ErrCategoryTypeConflict)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("fitting subtree", func(t *testing.T) {
+		repo, dbMock := newTestRepository(t)
+		dbMock.ExpectQuery("FROM rules r JOIN tree t").
+			WithArgs(2, "расход", testLedgerID).
+			WillReturnRows(sqlmock.NewRows([]string{"conflict"}).AddRow(false))
+
+		err := checkSubtreeType(ctx, repo.db.Master, testLedgerID, 2, "расход")
+
+		assert.NoError(t, err)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
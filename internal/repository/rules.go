@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+)
+
+var ErrNoSuchRule = model.NewError(model.KindNotFound, "rule_not_found", "there is no rule with such id")
+
+const rulesQuery = `SELECT id, priority, category, description, counterparty, regex, COALESCE(type, ''),
+		amount_min, amount_max, created_at
+	FROM rules
+	WHERE ledger_id = $1`
+
+// GetRules returns rules of ledger in the order they are tried.
+func (r *Repository) GetRules(ctx context.Context, ledgerID int) ([]model.Rule, error) {
+	return getRules(ctx, r.db.Master, " ORDER BY priority DESC, id", ledgerID)
+}
+
+func getRules(ctx context.Context, q querier, conditions string, args ...any) ([]model.Rule, error) {
+	rows, err := q.QueryContext(ctx, rulesQuery+conditions, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get rules from db: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []model.Rule
+	for rows.Next() {
+		var rule model.Rule
+		err := rows.Scan(
+			&rule.ID,
+			&rule.Priority,
+			&rule.Category,
+			&rule.Description,
+			&rule.Counterparty,
+			&rule.Regex,
+			&rule.Type,
+			&rule.AmountMin,
+			&rule.AmountMax,
+			&rule.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not scan row result to model: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not get rules from db: %w", err)
+	}
+
+	return rules, nil
+}
+
+func getRule(ctx context.Context, q querier, ledgerID, id int) (*model.Rule, error) {
+	rules, err := getRules(ctx, q, " AND id = $2", ledgerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, ErrNoSuchRule
+	}
+
+	return &rules[0], nil
+}
+
+// CreateRule creates rule of ledger. Category of rule is resolved as the one
+// of an item of rule type would be, so it is stored under the name of
+// category and fits the type.
+func (r *Repository) CreateRule(ctx context.Context, ledgerID int, rule dto.CreateRule) (*model.Rule, error) {
+	var created *model.Rule
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		category, err := resolveCategory(ctx, tx, ledgerID, rule.Category, rule.Type)
+		if err != nil {
+			return err
+		}
+
+		query := `INSERT INTO rules(ledger_id, priority, category, description, counterparty, regex, type, amount_min, amount_max)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9) RETURNING id`
+
+		var id int
+		err = tx.QueryRowContext(
+			ctx,
+			query,
+			ledgerID,
+			rule.Priority,
+			category,
+			rule.Description,
+			rule.Counterparty,
+			rule.Regex,
+			rule.Type,
+			rule.AmountMin,
+			rule.AmountMax,
+		).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("could not create rule in db: %w", err)
+		}
+
+		created, err = getRule(ctx, tx, ledgerID, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// UpdateRule replaces rule with id, its category is resolved as in
+// CreateRule.
+func (r *Repository) UpdateRule(ctx context.Context, ledgerID, id int, rule dto.UpdateRule) (*model.Rule, error) {
+	var updated *model.Rule
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		category, err := resolveCategory(ctx, tx, ledgerID, rule.Category, rule.Type)
+		if err != nil {
+			return err
+		}
+
+		query := `UPDATE rules SET priority = $3, category = $4, description = $5, counterparty = $6,
+			regex = $7, type = NULLIF($8, ''), amount_min = $9, amount_max = $10
+		WHERE id = $1 AND ledger_id = $2`
+
+		result, err := tx.ExecContext(
+			ctx,
+			query,
+			id,
+			ledgerID,
+			rule.Priority,
+			category,
+			rule.Description,
+			rule.Counterparty,
+			rule.Regex,
+			rule.Type,
+			rule.AmountMin,
+			rule.AmountMax,
+		)
+		if err != nil {
+			return fmt.Errorf("could not update rule in db: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("could not update rule in db: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return ErrNoSuchRule
+		}
+
+		updated, err = getRule(ctx, tx, ledgerID, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (r *Repository) DeleteRule(ctx context.Context, ledgerID, id int) error {
+	result, err := r.db.Master.ExecContext(ctx, "DELETE FROM rules WHERE id = $1 AND ledger_id = $2", id, ledgerID)
+	if err != nil {
+		return fmt.Errorf("could not delete rule from db: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("could not delete rule from db: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNoSuchRule
+	}
+
+	return nil
+}
@@ -2,40 +2,112 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
 )
 
-func (r *Repository) UpdateItem(ctx context.Context, id int, item dto.UpdateItem) error {
+func (r *Repository) UpdateItem(ctx context.Context, actor model.Actor, ledgerID, id, version int, item dto.UpdateItem) error {
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		return updateItem(ctx, tx, actor, ledgerID, id, version, item)
+	})
+}
+
+// lockItem locks item until the end of transaction, so that concurrent change
+// can not slip in, and returns its row as JSON. Unless version is zero it
+// must be the current version of item, otherwise ErrVersionConflict is
+// returned.
+func lockItem(ctx context.Context, q querier, ledgerID, id, version int) ([]byte, error) {
+	var (
+		current int
+		row     []byte
+	)
+	err := q.QueryRowContext(ctx, `SELECT version, to_jsonb(items) FROM items
+		WHERE id = $1 AND ledger_id = $2 AND deleted_at IS NULL FOR UPDATE`,
+		id, ledgerID).Scan(&current, &row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoSuchItem
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not lock item: %w", err)
+	}
+
+	if version != 0 && version != current {
+		return nil, ErrVersionConflict
+	}
+
+	return row, nil
+}
+
+// updateItem changes item and records it in audit log with item row before
+// and after the change.
+func updateItem(ctx context.Context, q querier, actor model.Actor, ledgerID, id, version int, item dto.UpdateItem) error {
+	before, err := lockItem(ctx, q, ledgerID, id, version)
+	if err != nil {
+		return err
+	}
+
+	if item.Type != nil || item.Category != nil {
+		// Category must fit type of item whichever of them changes.
+		var current struct {
+			Type     string `json:"type"`
+			Category string `json:"category"`
+		}
+		if err := json.Unmarshal(before, &current); err != nil {
+			return fmt.Errorf("could not read item: %w", err)
+		}
+		if item.Type != nil {
+			current.Type = *item.Type
+		}
+		if item.Category != nil {
+			current.Category = *item.Category
+		}
+
+		category, err := resolveCategory(ctx, q, ledgerID, current.Category, current.Type)
+		if err != nil {
+			return err
+		}
+		if item.Category != nil {
+			item.Category = &category
+		}
+	}
+
 	query := `UPDATE items
 	SET type = COALESCE($1, type),
 		amount = COALESCE($2, amount),
 		date = COALESCE($3, date),
-		category = COALESCE($4, category)
-	WHERE id = $5`
+		category = COALESCE($4, category),
+		currency = COALESCE($5, currency),
+		description = COALESCE($6, description),
+		counterparty = COALESCE($7, counterparty),
+		version = version + 1
+	WHERE id = $8 AND ledger_id = $9
+	RETURNING to_jsonb(items)`
 
-	result, err := r.db.Master.ExecContext(
+	var after []byte
+	err = q.QueryRowContext(
 		ctx,
 		query,
 		item.Type,
 		item.Amount,
 		item.Date,
 		item.Category,
+		item.Currency,
+		item.Description,
+		item.Counterparty,
 		id,
-	)
-	if err != nil {
-		return fmt.Errorf("could not update item: %w", err)
+		ledgerID,
+	).Scan(&after)
+	if isAmountPrecisionViolation(err) {
+		return ErrAmountPrecision
 	}
-
-	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("could not update item: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return ErrNoSuchItem
-	}
-
-	return nil
+	return writeAudit(ctx, q, actor, ledgerID, id, model.AuditUpdate, before, after)
 }
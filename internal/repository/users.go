@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the Postgres error code of unique constraint violation.
+const uniqueViolation = "23505"
+
+// CreateUser creates user together with personal ledger named after login.
+// Users are registered by admins, anonymously only the first one, who
+// becomes admin and member of ledgers nobody is a member of, e.g. the one
+// holding items created before accounts existed. Registrations are
+// serialised by lock of users table, so that only one user can be the
+// first.
+func (r *Repository) CreateUser(ctx context.Context, user dto.CreateUser) (*model.User, error) {
+	createdUser := model.User{
+		Login:        user.Login,
+		PasswordHash: user.PasswordHash,
+	}
+
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+			return fmt.Errorf("could not lock users in db: %w", err)
+		}
+
+		var first, byAdmin bool
+		err := tx.QueryRowContext(ctx, `SELECT NOT EXISTS (SELECT 1 FROM users),
+			EXISTS (SELECT 1 FROM users WHERE id = $1 AND is_admin)`, user.RegisteredBy).Scan(&first, &byAdmin)
+		if err != nil {
+			return fmt.Errorf("could not check users in db: %w", err)
+		}
+		if user.RegisteredBy == 0 && !first || user.RegisteredBy != 0 && !byAdmin {
+			return ErrRegistrationClosed
+		}
+
+		query := `INSERT INTO users(login, password_hash, is_admin)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at;`
+
+		err = tx.QueryRowContext(ctx, query, user.Login, user.PasswordHash, first).
+			Scan(&createdUser.ID, &createdUser.CreatedAt)
+		if err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+				return ErrUserExists
+			}
+			return fmt.Errorf("could not create user in db: %w", err)
+		}
+
+		if first {
+			query := `INSERT INTO ledger_members(ledger_id, user_id, role)
+			SELECT id, $1, 'admin' FROM ledgers l
+			WHERE NOT EXISTS (SELECT 1 FROM ledger_members m WHERE m.ledger_id = l.id)`
+			if _, err := tx.ExecContext(ctx, query, createdUser.ID); err != nil {
+				return fmt.Errorf("could not add ledger member in db: %w", err)
+			}
+		}
+
+		_, err = createLedger(ctx, tx, createdUser.ID, user.Login)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &createdUser, nil
+}
+
+func (r *Repository) GetUserByLogin(ctx context.Context, login string) (*model.User, error) {
+	query := "SELECT id, login, password_hash, created_at FROM users WHERE login = $1"
+
+	var user model.User
+	err := r.db.Master.QueryRowContext(ctx, query, login).Scan(
+		&user.ID,
+		&user.Login,
+		&user.PasswordHash,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoSuchUser
+		}
+		return nil, fmt.Errorf("could not get user from db: %w", err)
+	}
+
+	return &user, nil
+}
@@ -1,22 +1,152 @@
 package repository
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
 )
 
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// sortColumnTypes holds SQL types of sortable columns, used to cast keyset
+// values which come from cursor as strings.
+var sortColumnTypes = map[string]string{
+	"id":         "int",
+	"type":       "text",
+	"amount":     "numeric",
+	"date":       "date",
+	"category":   "text",
+	"created_at": "timestamp",
+}
+
+// sortColumns returns sorting fields with id appended as a tie-breaker, so
+// that order of items is always total and keyset pagination is stable.
+func sortColumns(params dto.GetItemsParams) []string {
+	columns := make([]string, 0, len(params.SortBy)+1)
+	for _, field := range params.SortBy {
+		columns = append(columns, field)
+		if field == "id" {
+			return columns
+		}
+	}
+
+	return append(columns, "id")
+}
+
 func prepareParams(params dto.GetItemsParams) string {
 	var orderByBuilder strings.Builder
 
-	if len(params.SortBy) > 0 {
-		orderByBuilder.WriteString(" ORDER BY ")
-		for i, field := range params.SortBy {
-			orderByBuilder.WriteString(field)
-			if i != len(params.SortBy)-1 {
-				orderByBuilder.WriteString(", ")
-			}
+	direction := ""
+	if params.Keyset != nil && params.Keyset.Backward {
+		direction = " DESC"
+	}
+
+	orderByBuilder.WriteString(" ORDER BY ")
+	columns := sortColumns(params)
+	for i, field := range columns {
+		orderByBuilder.WriteString(field + direction)
+		if i != len(columns)-1 {
+			orderByBuilder.WriteString(", ")
 		}
 	}
+
 	return orderByBuilder.String()
 }
+
+// prepareKeyset extends WHERE clause built by prepareFilters with a row
+// comparison selecting items after (or before) the cursor position.
+func prepareKeyset(params dto.GetItemsParams, where string, args []any) (string, []any, error) {
+	if params.Keyset == nil {
+		return where, args, nil
+	}
+
+	columns := sortColumns(params)
+	if len(columns) != len(params.Keyset.Values) {
+		return "", nil, fmt.Errorf("cursor does not match sorting fields")
+	}
+
+	placeholders := make([]string, len(columns))
+	for i, column := range columns {
+		args = append(args, params.Keyset.Values[i])
+		placeholders[i] = fmt.Sprintf("$%d::%s", len(args), sortColumnTypes[column])
+	}
+
+	operator := ">"
+	if params.Keyset.Backward {
+		operator = "<"
+	}
+
+	condition := fmt.Sprintf("(%s) %s (%s)",
+		strings.Join(columns, ", "), operator, strings.Join(placeholders, ", "))
+
+	if where == "" {
+		return " WHERE " + condition, args, nil
+	}
+
+	return where + " AND " + condition, args, nil
+}
+
+// prepareFilters builds WHERE clause for items listing, it always limits
+// items to the ledger and either to regular items or to ones in trash. All
+// values are passed as query arguments, so returned
+// clause is safe to concatenate with query. Placeholders are numbered after
+// args already used by the query.
+func prepareFilters(params dto.GetItemsParams, args ...any) (string, []any) {
+	var conditions []string
+
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	add("ledger_id = $%d", params.LedgerID)
+	if params.Deleted {
+		conditions = append(conditions, "deleted_at IS NOT NULL")
+	} else {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if params.Type != "" {
+		add("type = $%d", params.Type)
+	}
+	if params.Category != "" {
+		add("category = $%d", params.Category)
+	}
+	if params.CategoryContains != "" {
+		add(`category ILIKE '%%' || $%d || '%%'`, likeEscaper.Replace(params.CategoryContains))
+	}
+	if params.Currency != "" {
+		add("currency = $%d", params.Currency)
+	}
+	if params.DateFrom != "" {
+		add("date >= $%d::date", params.DateFrom)
+	}
+	if params.DateTo != "" {
+		add("date <= $%d::date", params.DateTo)
+	}
+	if params.AmountMin != nil {
+		add("amount >= $%d", *params.AmountMin)
+	}
+	if params.AmountMax != nil {
+		add("amount <= $%d", *params.AmountMax)
+	}
+	if params.CreatedFrom != nil {
+		add("created_at >= $%d", *params.CreatedFrom)
+	}
+	if params.CreatedTo != nil {
+		add("created_at <= $%d", *params.CreatedTo)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// prepareAnalyticsFilters builds WHERE clause limiting items to ledger and
+// dates range of analytics, any of bounds may be empty. Items in trash are
+// never included.
+func prepareAnalyticsFilters(params dto.AnalyticsParams, args ...any) (string, []any) {
+	return prepareFilters(dto.GetItemsParams{
+		LedgerID: params.LedgerID,
+		DateFrom: params.From,
+		DateTo:   params.To,
+	}, args...)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/Komilov31/sales-tracker/internal/repository"
+)
+
+const (
+	// apiKeyBytes is the number of random bytes in API key. With 256 bits of
+	// entropy keys can not be guessed, so plain sha256 is enough to store
+	// them, unlike passwords.
+	apiKeyBytes = 32
+	// apiKeyPrefixLength is the number of characters of key kept in clear
+	// to recognize it in the list of keys.
+	apiKeyPrefixLength = 8
+)
+
+// CreateAPIKey generates new key of user. The key itself is returned only
+// here, afterwards it can not be recovered.
+func (s *Service) CreateAPIKey(ctx context.Context, userID int, key dto.CreateAPIKey) (*model.CreatedAPIKey, error) {
+	secret := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("could not generate api key: %w", err)
+	}
+	plain := model.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	created, err := s.storage.CreateAPIKey(ctx, model.APIKey{
+		UserID:    userID,
+		Name:      key.Name,
+		Prefix:    plain[:len(model.APIKeyPrefix)+apiKeyPrefixLength],
+		Scopes:    key.Scopes,
+		ExpiresAt: key.ExpiresAt,
+	}, hashAPIKey(plain))
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.CreatedAPIKey{APIKey: *created, Key: plain}, nil
+}
+
+func (s *Service) GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error) {
+	return s.storage.GetAPIKeys(ctx, userID)
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	return s.storage.RevokeAPIKey(ctx, userID, id)
+}
+
+// AuthenticateAPIKey returns key and its owner, unknown, revoked and expired
+// keys are all reported as invalid token.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (*model.User, *model.APIKey, error) {
+	if !strings.HasPrefix(key, model.APIKeyPrefix) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	apiKey, user, err := s.storage.UseAPIKey(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, repository.ErrNoSuchAPIKey) {
+			return nil, nil, ErrInvalidToken
+		}
+		return nil, nil, fmt.Errorf("could not get api key: %w", err)
+	}
+
+	return user, apiKey, nil
+}
+
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+)
+
+// GetItemHistory returns changes of item, the oldest first. Items created
+// before audit log was introduced have history starting with their first
+// change.
+func (s *Service) GetItemHistory(ctx context.Context, ledgerID, itemID int) ([]model.AuditEntry, error) {
+	return s.storage.GetItemHistory(ctx, ledgerID, itemID)
+}
+
+func (s *Service) GetAudit(ctx context.Context, params dto.AuditParams) (*model.AuditPage, error) {
+	return s.storage.GetAudit(ctx, params)
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/Komilov31/sales-tracker/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidCredentials = model.NewError(model.KindUnauthorized, "invalid_credentials", "invalid login or password")
+	ErrInvalidToken       = model.NewError(model.KindUnauthorized, "invalid_token", "invalid or expired token")
+)
+
+// dummyPasswordHash is compared against on login with unknown user, so that
+// response time does not tell whether the login exists.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+type tokenClaims struct {
+	Login string `json:"login"`
+	jwt.RegisteredClaims
+}
+
+// Register creates user with given credentials. Anonymous registration is
+// open only while there are no users, so that the first account can be
+// created; after that new users are registered by admins. Storage checks
+// both together with creating user, see repository.ErrRegistrationClosed.
+func (s *Service) Register(ctx context.Context, credentials dto.Credentials, registeredBy *model.User) (*model.User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(credentials.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("could not hash password: %w", err)
+	}
+
+	user := dto.CreateUser{
+		Login:        credentials.Login,
+		PasswordHash: string(hash),
+	}
+	if registeredBy != nil {
+		user.RegisteredBy = registeredBy.ID
+	}
+
+	return s.storage.CreateUser(ctx, user)
+}
+
+// Login checks credentials and issues signed token valid for token TTL.
+func (s *Service) Login(ctx context.Context, credentials dto.Credentials) (*model.Token, error) {
+	user, err := s.storage.GetUserByLogin(ctx, credentials.Login)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoSuchUser) {
+			bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(credentials.Password))
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("could not get user: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(credentials.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.tokenTTL)
+	claims := tokenClaims{
+		Login: user.Login,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.tokenSecret)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign token: %w", err)
+	}
+
+	return &model.Token{Token: token, ExpiresAt: expiresAt.Truncate(time.Second)}, nil
+}
+
+// Authenticate checks signature and expiration of token and returns user it
+// was issued to. Users are not looked up in storage, token alone is trusted
+// until it expires.
+func (s *Service) Authenticate(token string) (*model.User, error) {
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return s.tokenSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &model.User{ID: id, Login: claims.Login}, nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/Komilov31/sales-tracker/internal/repository"
+)
+
+// ExecuteBatch runs operations atomically: either all of them succeed or
+// nothing is changed. Items created without category are categorised by
+// rules as in CreateItem, updates changing nothing are rejected as in
+// UpdateItem.
+func (s *Service) ExecuteBatch(ctx context.Context, actor model.Actor, ledgerID int, operations []dto.BatchOperation) ([]model.BatchResult, error) {
+	ruleMatchers := s.lazyRuleMatchers(ctx, ledgerID)
+	operations = slices.Clone(operations)
+	for i, operation := range operations {
+		if operation.Changes != nil && *operation.Changes == (dto.UpdateItem{}) {
+			return nil, &repository.BatchError{Index: i, Err: ErrEmptyUpdate}
+		}
+		if operation.Item == nil {
+			continue
+		}
+
+		item := *operation.Item
+		if item.Currency == "" {
+			item.Currency = DefaultCurrency
+		}
+		if item.Category == "" {
+			matchers, err := ruleMatchers()
+			if err != nil {
+				return nil, err
+			}
+
+			category, err := categorise(matchers, item)
+			if err != nil {
+				return nil, &repository.BatchError{Index: i, Err: err}
+			}
+			item.Category = category
+		}
+		operations[i].Item = &item
+	}
+
+	return s.storage.ExecuteBatch(ctx, actor, ledgerID, operations)
+}
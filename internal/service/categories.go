@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+)
+
+var ErrInvalidReplacement = model.NewError(model.KindInvalid, "invalid_replacement", "category can not be replaced with itself")
+
+func (s *Service) GetCategories(ctx context.Context, ledgerID int) ([]model.Category, error) {
+	return s.storage.GetCategories(ctx, ledgerID)
+}
+
+func (s *Service) CreateCategory(ctx context.Context, ledgerID int, category dto.CreateCategory) (*model.Category, error) {
+	return s.storage.CreateCategory(ctx, ledgerID, trimCategory(category))
+}
+
+func (s *Service) UpdateCategory(ctx context.Context, actor model.Actor, ledgerID, id int, category dto.UpdateCategory) (*model.Category, error) {
+	return s.storage.UpdateCategory(ctx, actor, ledgerID, id, dto.UpdateCategory(trimCategory(dto.CreateCategory(category))))
+}
+
+// DeleteCategory deletes category with id, moving its items to category with
+// replacementID unless it is zero.
+func (s *Service) DeleteCategory(ctx context.Context, actor model.Actor, ledgerID, id, replacementID int) error {
+	if replacementID == id {
+		return ErrInvalidReplacement
+	}
+
+	return s.storage.DeleteCategory(ctx, actor, ledgerID, id, replacementID)
+}
+
+// trimCategory drops spaces around name and aliases of category, so that
+// items are not split between names differing only in them.
+func trimCategory(category dto.CreateCategory) dto.CreateCategory {
+	category.Name = strings.TrimSpace(category.Name)
+
+	aliases := make([]string, len(category.Aliases))
+	for i, alias := range category.Aliases {
+		aliases[i] = strings.TrimSpace(alias)
+	}
+	category.Aliases = aliases
+
+	return category
+}
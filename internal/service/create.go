@@ -7,6 +7,25 @@ import (
 	"github.com/Komilov31/sales-tracker/internal/model"
 )
 
-func (r *Service) CreateItem(ctx context.Context, item dto.CreateItem) (*model.Item, error) {
-	return r.storage.CreateItem(ctx, item)
+// DefaultCurrency is used for items created without currency.
+const DefaultCurrency = "RUB"
+
+// CreateItem creates item in ledger. Item without category gets the one of
+// the first rule matching it.
+func (r *Service) CreateItem(ctx context.Context, actor model.Actor, ledgerID int, item dto.CreateItem) (*model.Item, error) {
+	if item.Currency == "" {
+		item.Currency = DefaultCurrency
+	}
+
+	if item.Category == "" {
+		matchers, err := r.ruleMatchers(ctx, ledgerID)
+		if err != nil {
+			return nil, err
+		}
+		if item.Category, err = categorise(matchers, item); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.storage.CreateItem(ctx, actor, ledgerID, item)
 }
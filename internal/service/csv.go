@@ -4,86 +4,334 @@ import (
 	"context"
 	"encoding/csv"
 	"fmt"
-	"os"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
 	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/shopspring/decimal"
 )
 
-func (s *Service) CSVAggregated(ctx context.Context, from, to string) (string, error) {
-	file, err := os.CreateTemp(s.folderName, "csv*.csv")
-	if err != nil {
-		return "", fmt.Errorf("could not create csv file: %w", err)
+var (
+	ErrInvalidExportOptions = model.NewError(model.KindInvalid, "invalid_export_options", "invalid export options")
+)
+
+// Supported values of export options.
+const (
+	DateFormatISO = "iso"
+	DateFormatRU  = "ru"
+
+	LanguageEN = "en"
+	LanguageRU = "ru"
+)
+
+// csvColumn is a column of CSV export: its name used in options, header in
+// every language and value of item.
+type csvColumn struct {
+	name   string
+	header map[string]string
+	value  func(f csvFormatter, item model.Item) string
+}
+
+// itemColumns are columns of items export, statistics columns are added to
+// them in analytics export.
+var itemColumns = []csvColumn{
+	{"id", headers("id", "ID"), func(f csvFormatter, item model.Item) string { return strconv.Itoa(item.ID) }},
+	{"type", headers("type", "Тип"), func(f csvFormatter, item model.Item) string { return item.Type }},
+	{"amount", headers("amount", "Сумма"), func(f csvFormatter, item model.Item) string {
+		return f.amount(item.Amount, model.CurrencyExponent(item.Currency))
+	}},
+	{"date", headers("date", "Дата"), func(f csvFormatter, item model.Item) string { return f.date(item.Date) }},
+	{"category", headers("category", "Категория"), func(f csvFormatter, item model.Item) string { return item.Category }},
+	{"currency", headers("currency", "Валюта"), func(f csvFormatter, item model.Item) string { return item.Currency }},
+	{"created_at", headers("created_at", "Создано"), func(f csvFormatter, item model.Item) string { return f.timestamp(item.CreatedAt) }},
+}
+
+var aggregatedColumns = buildAggregatedColumns()
+
+func buildAggregatedColumns() []csvColumn {
+	all := func(aggregated model.Aggregated) model.TypeStats {
+		return model.TypeStats{
+			Sum:           aggregated.Sum,
+			Average:       aggregated.Average,
+			Count:         aggregated.Count,
+			Median:        aggregated.Median,
+			Percentile_90: aggregated.Percentile_90,
+		}
+	}
+	income := func(aggregated model.Aggregated) model.TypeStats { return aggregated.Income }
+	expense := func(aggregated model.Aggregated) model.TypeStats { return aggregated.Expense }
+
+	columns := append([]csvColumn{}, itemColumns...)
+	columns = append(columns, statsColumns("", "", all)...)
+	columns = append(columns, statsColumns("income_", "Доходы: ", income)...)
+	columns = append(columns, statsColumns("expense_", "Расходы: ", expense)...)
+	columns = append(columns, csvColumn{"net", headers("net", "Баланс"), func(f csvFormatter, item model.Item) string {
+		return f.amount(item.Aggregated.Net, model.CurrencyExponent(item.Currency))
+	}})
+
+	return columns
+}
+
+// statsColumns returns columns of statistics picked by stats, with names
+// and Russian headers prefixed.
+func statsColumns(prefix, ruPrefix string, stats func(model.Aggregated) model.TypeStats) []csvColumn {
+	amount := func(value func(model.TypeStats) decimal.Decimal, isStatistic bool) func(csvFormatter, model.Item) string {
+		return func(f csvFormatter, item model.Item) string {
+			exponent := model.CurrencyExponent(item.Currency)
+			if isStatistic {
+				exponent = statsExponent(exponent)
+			}
+			return f.amount(value(stats(item.Aggregated)), exponent)
+		}
 	}
-	defer file.Close()
 
-	writer := csv.NewWriter(file)
-	data, err := s.storage.GetAggregated(ctx, from, to)
+	ruHeader := func(header string) string {
+		if ruPrefix == "" {
+			return header
+		}
+		return ruPrefix + strings.ToLower(header)
+	}
+
+	return []csvColumn{
+		{prefix + "sum", headers(prefix+"sum", ruHeader("Итого")),
+			amount(func(stats model.TypeStats) decimal.Decimal { return stats.Sum }, false)},
+		{prefix + "average", headers(prefix+"average", ruHeader("Среднее")),
+			amount(func(stats model.TypeStats) decimal.Decimal { return stats.Average }, true)},
+		{prefix + "count", headers(prefix+"count", ruHeader("Количество")), func(f csvFormatter, item model.Item) string {
+			return strconv.Itoa(stats(item.Aggregated).Count)
+		}},
+		{prefix + "median", headers(prefix+"median", ruHeader("Медиана")),
+			amount(func(stats model.TypeStats) decimal.Decimal { return stats.Median }, true)},
+		{prefix + "percentile_90", headers(prefix+"percentile_90", ruHeader("90-й перцентиль")),
+			amount(func(stats model.TypeStats) decimal.Decimal { return stats.Percentile_90 }, true)},
+	}
+}
+
+func headers(en, ru string) map[string]string {
+	return map[string]string{LanguageEN: en, LanguageRU: ru}
+}
+
+// CSVAggregated writes items within dates range with statistics attached to
+// w as CSV. Rows are written as they are read from storage, so memory usage
+// does not depend on number of items.
+func (s *Service) CSVAggregated(ctx context.Context, w io.Writer, params dto.AnalyticsParams, options dto.ExportOptions) error {
+	writer, err := newCSVWriter(w, aggregatedColumns, options)
 	if err != nil {
-		return "", fmt.Errorf("could not get aggregated data: %w", err)
+		return err
 	}
 
-	records := [][]string{{"id", "type", "amount",
-		"date", "category", "created_at", "sum",
-		"avarage", "count", "median", "percentile_90",
-	}}
+	if err := s.storage.StreamAggregated(ctx, params, writer.write); err != nil {
+		return fmt.Errorf("could not get aggregated data: %w", err)
+	}
 
-	for _, record := range getRecords(data, true) {
-		records = append(records, record)
+	return writer.flush()
+}
+
+// CSVAllItems writes items matching params to w as CSV, streaming them from
+// storage.
+func (s *Service) CSVAllItems(ctx context.Context, w io.Writer, params dto.GetItemsParams, options dto.ExportOptions) error {
+	writer, err := newCSVWriter(w, itemColumns, options)
+	if err != nil {
+		return err
 	}
 
-	if err := writer.WriteAll(records); err != nil {
-		return "", fmt.Errorf("could not create csv file: %w", err)
+	if err := s.storage.StreamItems(ctx, params, writer.write); err != nil {
+		return fmt.Errorf("could not get filtered data: %w", err)
 	}
 
-	return file.Name(), nil
+	return writer.flush()
 }
 
-func (s *Service) CSVAllItems(ctx context.Context, params dto.GetItemsParams) (string, error) {
-	file, err := os.CreateTemp(s.folderName, "csv*.csv")
+// csvWriter writes selected columns of items in dialect set by options.
+type csvWriter struct {
+	out       io.Writer
+	writer    *csv.Writer
+	formatter csvFormatter
+	columns   []csvColumn
+	language  string
+	bom       bool
+	started   bool
+}
+
+// newCSVWriter checks options against available columns. Nothing is
+// written until the first item or flush, so invalid options are reported
+// before any output.
+func newCSVWriter(w io.Writer, available []csvColumn, options dto.ExportOptions) (*csvWriter, error) {
+	formatter, err := newCSVFormatter(options)
 	if err != nil {
-		return "", fmt.Errorf("could not create csv file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
 
-	writer := csv.NewWriter(file)
-	data, err := s.storage.GetAllItems(ctx, params)
+	language := options.Language
+	switch language {
+	case "":
+		language = LanguageEN
+	case LanguageEN, LanguageRU:
+	default:
+		return nil, fmt.Errorf("%w: unknown language %q", ErrInvalidExportOptions, options.Language)
+	}
+
+	columns, err := selectColumns(available, options.Columns)
 	if err != nil {
-		return "", fmt.Errorf("could not get filtered data: %w", err)
+		return nil, err
 	}
 
-	records := [][]string{{"id", "type", "amount", "date", "category", "created_at"}}
+	writer := csv.NewWriter(w)
+	switch options.Delimiter {
+	case 0:
+	case ',', ';', '\t', '|':
+		writer.Comma = options.Delimiter
+	default:
+		return nil, fmt.Errorf("%w: unsupported delimiter %q", ErrInvalidExportOptions, options.Delimiter)
+	}
 
-	for _, record := range getRecords(data, false) {
-		records = append(records, record)
+	return &csvWriter{
+		out:       w,
+		writer:    writer,
+		formatter: formatter,
+		columns:   columns,
+		language:  language,
+		bom:       options.BOM,
+	}, nil
+}
+
+// selectColumns returns columns with names in given order, all available
+// ones if names are empty.
+func selectColumns(available []csvColumn, names []string) ([]csvColumn, error) {
+	if len(names) == 0 {
+		return available, nil
 	}
 
-	if err := writer.WriteAll(records); err != nil {
-		return "", fmt.Errorf("could not create csv file: %w", err)
+	columns := make([]csvColumn, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(available, func(column csvColumn) bool { return column.name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidExportOptions, name)
+		}
+		columns = append(columns, available[i])
 	}
 
-	return file.Name(), nil
+	return columns, nil
 }
 
-func getRecords(items []model.Item, isAggregated bool) [][]string {
-	records := [][]string{}
-	for _, item := range items {
-		id := fmt.Sprintf("%d", item.ID)
-		amount := fmt.Sprintf("%d", item.Amount)
-		createdAt := item.CreatedAt.String()
-		record := []string{id, item.Type, amount, item.Date, item.Category, createdAt}
+// start writes BOM and header once.
+func (w *csvWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
 
-		if isAggregated {
-			sum := fmt.Sprintf("%d", item.Aggregated.Sum)
-			average := fmt.Sprintf("%.2f", item.Aggregated.Average)
-			count := fmt.Sprintf("%d", item.Aggregated.Count)
-			median := fmt.Sprintf("%.2f", item.Aggregated.Median)
-			percentile := fmt.Sprintf("%.2f", item.Aggregated.Percentile_90)
-			record = append(record, sum, average, count, median, percentile)
+	if w.bom {
+		if _, err := io.WriteString(w.out, utf8BOM); err != nil {
+			return fmt.Errorf("could not write csv: %w", err)
 		}
+	}
+
+	header := make([]string, len(w.columns))
+	for i, column := range w.columns {
+		header[i] = column.header[w.language]
+	}
+
+	if err := w.writer.Write(header); err != nil {
+		return fmt.Errorf("could not write csv: %w", err)
+	}
+
+	return nil
+}
+
+func (w *csvWriter) write(item model.Item) error {
+	if err := w.start(); err != nil {
+		return err
+	}
+
+	record := make([]string, len(w.columns))
+	for i, column := range w.columns {
+		record[i] = column.value(w.formatter, item)
+	}
+
+	return w.writer.Write(record)
+}
+
+func (w *csvWriter) flush() error {
+	if err := w.start(); err != nil {
+		return err
+	}
 
-		records = append(records, record)
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		return fmt.Errorf("could not write csv: %w", err)
 	}
 
-	return records
+	return nil
+}
+
+// csvFormatter renders values of CSV cells.
+type csvFormatter struct {
+	decimalSeparator string
+	dateLayout       string
+	timestampLayout  string
+}
+
+func newCSVFormatter(options dto.ExportOptions) (csvFormatter, error) {
+	formatter := csvFormatter{decimalSeparator: options.DecimalSeparator}
+
+	switch options.DecimalSeparator {
+	case "":
+		formatter.decimalSeparator = "."
+	case ".", ",":
+	default:
+		return csvFormatter{}, fmt.Errorf("%w: unknown decimal separator %q", ErrInvalidExportOptions, options.DecimalSeparator)
+	}
+
+	switch options.DateFormat {
+	case "", DateFormatISO:
+		formatter.dateLayout, formatter.timestampLayout = time.DateOnly, time.RFC3339
+	case DateFormatRU:
+		formatter.dateLayout, formatter.timestampLayout = "02.01.2006", "02.01.2006 15:04:05"
+	default:
+		return csvFormatter{}, fmt.Errorf("%w: unknown date format %q", ErrInvalidExportOptions, options.DateFormat)
+	}
+
+	return formatter, nil
+}
+
+func (f csvFormatter) amount(value decimal.Decimal, exponent int32) string {
+	return strings.Replace(value.StringFixed(exponent), ".", f.decimalSeparator, 1)
+}
+
+// date reformats item date, kept as is if it can not be parsed.
+func (f csvFormatter) date(value string) string {
+	date, ok := parseItemDate(value)
+	if !ok {
+		return value
+	}
+
+	return date.Format(f.dateLayout)
+}
+
+func (f csvFormatter) timestamp(value time.Time) string {
+	return value.Format(f.timestampLayout)
+}
+
+// parseItemDate accepts item date both as plain date and as RFC 3339
+// timestamp database driver may return it as.
+func parseItemDate(value string) (time.Time, bool) {
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// statsExponent returns number of decimal places averages and percentiles of
+// amounts with exponent are rendered with: at least two, as they are not
+// amounts themselves and are usually fractional.
+func statsExponent(exponent int32) int32 {
+	return max(exponent, 2)
 }
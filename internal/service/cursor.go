@@ -0,0 +1,96 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+)
+
+var (
+	ErrInvalidCursor = model.NewError(model.KindInvalid, "invalid_cursor", "invalid or tampered cursor")
+)
+
+type cursorPayload struct {
+	SortBy   []string `json:"s"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// encodeCursor produces opaque token: base64 encoded payload followed by its
+// HMAC signature, so clients can not forge positions or sorting fields.
+func (s *Service) encodeCursor(sortBy []string, item model.Item, backward bool) string {
+	payload, _ := json.Marshal(cursorPayload{
+		SortBy:   sortBy,
+		Values:   keysetValues(sortBy, item),
+		Backward: backward,
+	})
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded))
+}
+
+func (s *Service) decodeCursor(token string, sortBy []string) (*dto.Keyset, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	decodedSignature, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(decodedSignature, s.sign(encoded)) {
+		return nil, ErrInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if !slices.Equal(payload.SortBy, sortBy) {
+		return nil, ErrInvalidCursor
+	}
+
+	return &dto.Keyset{Values: payload.Values, Backward: payload.Backward}, nil
+}
+
+func (s *Service) sign(data string) []byte {
+	mac := hmac.New(sha256.New, s.cursorSecret)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// keysetValues returns values of sorting fields followed by id, matching
+// columns order used by repository for keyset comparison.
+func keysetValues(sortBy []string, item model.Item) []string {
+	values := make([]string, 0, len(sortBy)+1)
+	for _, field := range sortBy {
+		switch field {
+		case "id":
+			return append(values, strconv.Itoa(item.ID))
+		case "type":
+			values = append(values, item.Type)
+		case "amount":
+			values = append(values, item.Amount.String())
+		case "date":
+			values = append(values, item.Date)
+		case "category":
+			values = append(values, item.Category)
+		case "created_at":
+			values = append(values, item.CreatedAt.Format(time.RFC3339Nano))
+		}
+	}
+
+	return append(values, strconv.Itoa(item.ID))
+}
@@ -1,7 +1,24 @@
 package service
 
-import "context"
+import (
+	"context"
+	"time"
 
-func (s *Service) DeleteItem(ctx context.Context, id int) error {
-	return s.storage.DeleteItem(ctx, id)
+	"github.com/Komilov31/sales-tracker/internal/model"
+)
+
+// DeleteItem moves item to trash, from where it can be restored until
+// retention purges it. Item must still have version unless it is zero.
+func (s *Service) DeleteItem(ctx context.Context, actor model.Actor, ledgerID, id, version int) error {
+	return s.storage.DeleteItem(ctx, actor, ledgerID, id, version)
+}
+
+func (s *Service) RestoreItem(ctx context.Context, actor model.Actor, ledgerID, id int) (*model.Item, error) {
+	return s.storage.RestoreItem(ctx, actor, ledgerID, id)
+}
+
+// PurgeTrash deletes for good items which are in trash longer than retention
+// and returns their number.
+func (s *Service) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.storage.PurgeTrash(ctx, time.Now().Add(-retention))
 }
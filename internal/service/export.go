@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+)
+
+var (
+	ErrUnknownFormat = model.NewError(model.KindInvalid, "unknown_format", "unknown export format")
+)
+
+// Exporter writes items and analytics in one format. Every registered
+// exporter is available on items and analytics endpoints by its name or
+// content type, so adding a format needs neither new routes nor handlers.
+type Exporter struct {
+	model.ExportFormat
+	Items      func(s *Service, ctx context.Context, w io.Writer, params dto.GetItemsParams, options dto.ExportOptions) error
+	Aggregated func(s *Service, ctx context.Context, w io.Writer, params dto.AnalyticsParams, options dto.ExportOptions) error
+}
+
+// defaultExporters lists formats supported out of the box.
+func defaultExporters() []Exporter {
+	return []Exporter{
+		{
+			ExportFormat: model.ExportFormat{Name: "csv", ContentType: "text/csv; charset=utf-8", Extension: ".csv"},
+			Items:        (*Service).CSVAllItems,
+			Aggregated:   (*Service).CSVAggregated,
+		},
+		{
+			ExportFormat: model.ExportFormat{Name: "ndjson", ContentType: "application/x-ndjson", Extension: ".ndjson"},
+			Items: func(s *Service, ctx context.Context, w io.Writer, params dto.GetItemsParams, _ dto.ExportOptions) error {
+				return s.NDJSONAllItems(ctx, w, params)
+			},
+			Aggregated: func(s *Service, ctx context.Context, w io.Writer, params dto.AnalyticsParams, _ dto.ExportOptions) error {
+				return s.NDJSONAggregated(ctx, w, params)
+			},
+		},
+		{
+			ExportFormat: model.ExportFormat{
+				Name:        "xlsx",
+				ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+				Extension:   ".xlsx",
+			},
+			Items: func(s *Service, ctx context.Context, w io.Writer, params dto.GetItemsParams, _ dto.ExportOptions) error {
+				return s.XLSXAllItems(ctx, w, params)
+			},
+			Aggregated: func(s *Service, ctx context.Context, w io.Writer, params dto.AnalyticsParams, _ dto.ExportOptions) error {
+				return s.XLSXAggregated(ctx, w, params)
+			},
+		},
+	}
+}
+
+// RegisterExporter adds export format or replaces one with the same name.
+func (s *Service) RegisterExporter(exporter Exporter) {
+	for i := range s.exporters {
+		if s.exporters[i].Name == exporter.Name {
+			s.exporters[i] = exporter
+			return
+		}
+	}
+
+	s.exporters = append(s.exporters, exporter)
+}
+
+// Exporters returns registered export formats in order of registration.
+func (s *Service) Exporters() []model.ExportFormat {
+	formats := make([]model.ExportFormat, len(s.exporters))
+	for i, exporter := range s.exporters {
+		formats[i] = exporter.ExportFormat
+	}
+
+	return formats
+}
+
+// ExportItems writes items matching params to w in format with given name.
+// Options are applied by formats which support them, CSV for now.
+func (s *Service) ExportItems(ctx context.Context, w io.Writer, format string, params dto.GetItemsParams, options dto.ExportOptions) error {
+	exporter, err := s.exporter(format)
+	if err != nil {
+		return err
+	}
+
+	return exporter.Items(s, ctx, w, params, options)
+}
+
+// ExportAggregated writes items within dates range with statistics to w in
+// format with given name.
+func (s *Service) ExportAggregated(ctx context.Context, w io.Writer, format string, params dto.AnalyticsParams, options dto.ExportOptions) error {
+	exporter, err := s.exporter(format)
+	if err != nil {
+		return err
+	}
+
+	return exporter.Aggregated(s, ctx, w, params, options)
+}
+
+func (s *Service) exporter(name string) (Exporter, error) {
+	for _, exporter := range s.exporters {
+		if exporter.Name == name {
+			return exporter, nil
+		}
+	}
+
+	return Exporter{}, fmt.Errorf("%w: %s", ErrUnknownFormat, name)
+}
@@ -2,15 +2,98 @@ package service
 
 import (
 	"context"
+	"slices"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
 	"github.com/Komilov31/sales-tracker/internal/model"
 )
 
+func (s *Service) GetItem(ctx context.Context, ledgerID, id int) (*model.Item, error) {
+	return s.storage.GetItem(ctx, ledgerID, id)
+}
+
 func (s *Service) GetAllItems(ctx context.Context, params dto.GetItemsParams) ([]model.Item, error) {
 	return s.storage.GetAllItems(ctx, params)
 }
 
-func (s *Service) GetAggregated(ctx context.Context, from, to string) ([]model.Item, error) {
-	return s.storage.GetAggregated(ctx, from, to)
+// GetItemsPage returns at most params.Limit items starting from params.Cursor
+// along with cursors of adjacent pages and total number of matching items.
+func (s *Service) GetItemsPage(ctx context.Context, params dto.GetItemsParams) (*model.ItemsPage, error) {
+	if params.Cursor != "" {
+		keyset, err := s.decodeCursor(params.Cursor, params.SortBy)
+		if err != nil {
+			return nil, err
+		}
+		params.Keyset = keyset
+	}
+
+	limit := params.Limit
+	params.Limit = limit + 1
+
+	items, err := s.storage.GetAllItems(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.storage.CountItems(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+
+	backward := params.Keyset != nil && params.Keyset.Backward
+	if backward {
+		slices.Reverse(items)
+	}
+
+	page := &model.ItemsPage{Items: items, Total: total}
+	if len(items) == 0 {
+		return page, nil
+	}
+
+	if hasMore || backward {
+		page.NextCursor = s.encodeCursor(params.SortBy, items[len(items)-1], false)
+	}
+	if (params.Keyset != nil && !backward) || (backward && hasMore) {
+		page.PrevCursor = s.encodeCursor(params.SortBy, items[0], true)
+	}
+
+	return page, nil
+}
+
+func (s *Service) GetAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.Item, error) {
+	return s.storage.GetAggregated(ctx, params)
+}
+
+func (s *Service) GetGroupedAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.GroupedAggregated, error) {
+	return s.storage.GetGroupedAggregated(ctx, params)
+}
+
+// GetSummary returns statistics over items within dates range, optionally
+// together with the items themselves.
+func (s *Service) GetSummary(ctx context.Context, params dto.AnalyticsParams, includeItems bool) (*model.Summary, error) {
+	aggregated, err := s.storage.GetSummary(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &model.Summary{Aggregated: *aggregated}
+	if !includeItems {
+		return summary, nil
+	}
+
+	summary.Items, err = s.storage.GetAllItems(ctx, dto.GetItemsParams{
+		LedgerID: params.LedgerID,
+		DateFrom: params.From,
+		DateTo:   params.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
 }
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	validate "github.com/Komilov31/sales-tracker/internal/validator"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidImport = model.NewError(model.KindValidation, "invalid_import", "invalid import file")
+)
+
+// ImportFields lists item fields read from CSV, the same columns CSV export
+// writes along with description and counterparty of bank statements. Only
+// type, amount and date columns are required.
+var ImportFields = []string{"type", "amount", "date", "category", "currency", "description", "counterparty"}
+
+var requiredImportFields = []string{"type", "amount", "date"}
+
+// importHeaders are headers fields are also looked up by: the ones of
+// Russian CSV export and Russian names of fields export does not write.
+var importHeaders = buildImportHeaders()
+
+func buildImportHeaders() map[string]string {
+	headers := map[string]string{
+		"description":  "Описание",
+		"counterparty": "Контрагент",
+	}
+	for _, column := range itemColumns {
+		if slices.Contains(ImportFields, column.name) {
+			headers[column.name] = column.header[LanguageRU]
+		}
+	}
+
+	return headers
+}
+
+// importDelimiters are delimiters CSV export may use, in order of preference
+// when detected.
+var importDelimiters = []rune{',', ';', '\t', '|'}
+
+const utf8BOM = "\ufeff"
+
+// ImportItems reads items from CSV with a header row and creates them in
+// ledger in a single transaction. Every row is validated first and nothing is created if
+// any of them is invalid or options.DryRun is set. Rows without category are
+// categorised by rules of ledger, rows no rule matches are invalid. Files of
+// every CSV export dialect are accepted.
+func (s *Service) ImportItems(ctx context.Context, actor model.Actor, ledgerID int, r io.Reader, options dto.ImportOptions) (*model.ImportReport, error) {
+	buffered := bufio.NewReader(r)
+	delimiter := options.Delimiter
+	switch delimiter {
+	case 0:
+		// Header row is left in buffer to be read by CSV reader.
+		line, err := buffered.Peek(buffered.Size())
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: could not read header: %v", ErrInvalidImport, err)
+		}
+		delimiter = detectDelimiter(line)
+	case ',', ';', '\t', '|':
+	default:
+		return nil, fmt.Errorf("%w: unsupported delimiter %q", ErrInvalidImport, delimiter)
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not read header: %v", ErrInvalidImport, err)
+	}
+
+	columns, err := importColumns(header, options.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.ImportReport{DryRun: options.DryRun, Errors: []model.ImportRowError{}}
+	var items []dto.CreateItem
+	ruleMatchers := s.lazyRuleMatchers(ctx, ledgerID)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		report.Total++
+
+		item, err := parseImportRecord(record, columns)
+		if err != nil {
+			report.Errors = append(report.Errors, model.ImportRowError{Line: line, Error: err.Error()})
+			continue
+		}
+
+		if item.Category == "" {
+			matchers, err := ruleMatchers()
+			if err != nil {
+				return nil, err
+			}
+			if item.Category, err = categorise(matchers, item); err != nil {
+				report.Errors = append(report.Errors, model.ImportRowError{Line: line, Error: err.Error()})
+				continue
+			}
+		}
+
+		items = append(items, item)
+	}
+
+	report.Valid = len(items)
+	if len(report.Errors) > 0 || options.DryRun || len(items) == 0 {
+		return report, nil
+	}
+
+	created, err := s.storage.CreateItems(ctx, actor, ledgerID, items)
+	if err != nil {
+		return nil, fmt.Errorf("could not import items: %w", err)
+	}
+	report.Imported = len(created)
+
+	return report, nil
+}
+
+// detectDelimiter returns delimiter occurring most often in the first line of
+// data, comma if there is none.
+func detectDelimiter(data []byte) rune {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+
+	delimiter, count := importDelimiters[0], 0
+	for _, candidate := range importDelimiters {
+		if n := bytes.Count(line, []byte(string(candidate))); n > count {
+			delimiter, count = candidate, n
+		}
+	}
+
+	return delimiter
+}
+
+// importColumns returns positions of item fields in CSV header. Header names
+// are matched case-insensitively, fields not mapped are looked up both by
+// their own name and by importHeaders.
+func importColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], utf8BOM)
+	}
+
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		positions[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	columns := make(map[string]int, len(ImportFields))
+	for _, field := range ImportFields {
+		names := []string{field, importHeaders[field]}
+		if mapped, ok := mapping[field]; ok {
+			names = []string{mapped}
+		}
+
+		var (
+			position int
+			ok       bool
+		)
+		for _, name := range names {
+			if position, ok = positions[strings.ToLower(strings.TrimSpace(name))]; ok {
+				break
+			}
+		}
+		if !ok {
+			if !slices.Contains(requiredImportFields, field) {
+				continue
+			}
+			return nil, fmt.Errorf("%w: missing column %q for field %s", ErrInvalidImport, names[0], field)
+		}
+		columns[field] = position
+	}
+
+	return columns, nil
+}
+
+// parseImportRecord converts CSV record into item and validates it with the
+// same rules as items created through API.
+func parseImportRecord(record []string, columns map[string]int) (dto.CreateItem, error) {
+	value := func(field string) string {
+		position, ok := columns[field]
+		if !ok || position >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[position])
+	}
+
+	item := dto.CreateItem{
+		Type:         value("type"),
+		Date:         parseImportDate(value("date")),
+		Category:     value("category"),
+		Currency:     strings.ToUpper(value("currency")),
+		Description:  value("description"),
+		Counterparty: value("counterparty"),
+	}
+
+	amount, err := parseImportAmount(value("amount"))
+	if err != nil {
+		return dto.CreateItem{}, err
+	}
+	item.Amount = amount
+
+	if err := validate.Validator.Struct(item); err != nil {
+		return dto.CreateItem{}, errors.New(validate.Message(validate.Fields(err)))
+	}
+
+	if item.Currency == "" {
+		item.Currency = DefaultCurrency
+	}
+
+	return item, nil
+}
+
+// parseImportDate converts date in Russian format of CSV export into the one
+// of API, other values are left for validation.
+func parseImportDate(value string) string {
+	date, err := time.Parse("02.01.2006", value)
+	if err != nil {
+		return value
+	}
+
+	return date.Format(time.DateOnly)
+}
+
+// parseImportAmount accepts both point and comma as decimal separator, as
+// spreadsheets with Russian locale use the latter.
+func parseImportAmount(value string) (decimal.Decimal, error) {
+	if value == "" {
+		return decimal.Decimal{}, fmt.Errorf("amount is empty")
+	}
+
+	amount, err := decimal.NewFromString(strings.Replace(value, ",", ".", 1))
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("invalid amount %q", value)
+	}
+
+	return amount, nil
+}
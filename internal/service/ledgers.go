@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/Komilov31/sales-tracker/internal/repository"
+)
+
+func (s *Service) CreateLedger(ctx context.Context, userID int, name string) (*model.Ledger, error) {
+	return s.storage.CreateLedger(ctx, userID, name)
+}
+
+func (s *Service) GetLedgers(ctx context.Context, userID int) ([]model.Ledger, error) {
+	return s.storage.GetLedgers(ctx, userID)
+}
+
+// GetLedger returns ledger user works with: the one with ledgerID or, if it
+// is zero, the oldest ledger of user, which is normally their personal one.
+func (s *Service) GetLedger(ctx context.Context, userID, ledgerID int) (*model.Ledger, error) {
+	if ledgerID != 0 {
+		return s.storage.GetLedger(ctx, userID, ledgerID)
+	}
+
+	ledgers, err := s.storage.GetLedgers(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get ledgers: %w", err)
+	}
+	if len(ledgers) == 0 {
+		return nil, repository.ErrNoSuchLedger
+	}
+
+	return &ledgers[0], nil
+}
+
+func (s *Service) GetMembers(ctx context.Context, ledgerID int) ([]model.Member, error) {
+	return s.storage.GetMembers(ctx, ledgerID)
+}
+
+// AddMember invites registered user with login into ledger.
+func (s *Service) AddMember(ctx context.Context, ledgerID int, member dto.AddMember) (*model.Member, error) {
+	return s.storage.AddMember(ctx, ledgerID, member.Login, member.Role)
+}
+
+func (s *Service) UpdateMemberRole(ctx context.Context, ledgerID, userID int, role string) error {
+	return s.storage.UpdateMemberRole(ctx, ledgerID, userID, role)
+}
+
+func (s *Service) RemoveMember(ctx context.Context, ledgerID, userID int) error {
+	return s.storage.RemoveMember(ctx, ledgerID, userID)
+}
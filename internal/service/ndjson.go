@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+)
+
+// NDJSONAggregated writes items within dates range with statistics attached
+// to w as newline delimited JSON, one item per line in the same shape GET
+// /analytics returns them.
+func (s *Service) NDJSONAggregated(ctx context.Context, w io.Writer, params dto.AnalyticsParams) error {
+	encoder := json.NewEncoder(w)
+
+	err := s.storage.StreamAggregated(ctx, params, func(item model.Item) error {
+		return encoder.Encode(item)
+	})
+	if err != nil {
+		return fmt.Errorf("could not get aggregated data: %w", err)
+	}
+
+	return nil
+}
+
+// NDJSONAllItems writes items matching params to w as newline delimited
+// JSON, one item per line in the same shape GET /items returns them.
+func (s *Service) NDJSONAllItems(ctx context.Context, w io.Writer, params dto.GetItemsParams) error {
+	encoder := json.NewEncoder(w)
+
+	err := s.storage.StreamItems(ctx, params, func(item model.Item) error {
+		return encoder.Encode(dto.ConvertWithoutAggregated(&item))
+	})
+	if err != nil {
+		return fmt.Errorf("could not get filtered data: %w", err)
+	}
+
+	return nil
+}
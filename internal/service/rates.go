@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+)
+
+func (s *Service) CreateExchangeRate(ctx context.Context, ledgerID int, rate dto.CreateExchangeRate) (*model.ExchangeRate, error) {
+	return s.storage.CreateExchangeRate(ctx, ledgerID, rate)
+}
+
+func (s *Service) GetExchangeRates(ctx context.Context, params dto.GetExchangeRatesParams) ([]model.ExchangeRate, error) {
+	return s.storage.GetExchangeRates(ctx, params)
+}
+
+func (s *Service) DeleteExchangeRate(ctx context.Context, ledgerID, id int) error {
+	return s.storage.DeleteExchangeRate(ctx, ledgerID, id)
+}
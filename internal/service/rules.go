@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	validate "github.com/Komilov31/sales-tracker/internal/validator"
+)
+
+var ErrUncategorised = model.NewValidationError("uncategorised", "category is required as no rule matches item", []model.FieldError{
+	{Field: "category", Rule: "required", Message: "category is required as no rule matches item"},
+})
+
+func (s *Service) GetRules(ctx context.Context, ledgerID int) ([]model.Rule, error) {
+	return s.storage.GetRules(ctx, ledgerID)
+}
+
+func (s *Service) CreateRule(ctx context.Context, ledgerID int, rule dto.CreateRule) (*model.Rule, error) {
+	rule.Category = strings.TrimSpace(rule.Category)
+	if err := checkRule(rule); err != nil {
+		return nil, err
+	}
+
+	return s.storage.CreateRule(ctx, ledgerID, rule)
+}
+
+func (s *Service) UpdateRule(ctx context.Context, ledgerID, id int, rule dto.UpdateRule) (*model.Rule, error) {
+	rule.Category = strings.TrimSpace(rule.Category)
+	if err := checkRule(dto.CreateRule(rule)); err != nil {
+		return nil, err
+	}
+
+	return s.storage.UpdateRule(ctx, ledgerID, id, rule)
+}
+
+func (s *Service) DeleteRule(ctx context.Context, ledgerID, id int) error {
+	return s.storage.DeleteRule(ctx, ledgerID, id)
+}
+
+// TestRules tells which rule of ledger would fire for sample.
+func (s *Service) TestRules(ctx context.Context, ledgerID int, sample dto.RuleSample) (*model.RuleMatch, error) {
+	matchers, err := s.ruleMatchers(ctx, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+
+	match := &model.RuleMatch{}
+	if rule := firstMatch(matchers, sample); rule != nil {
+		match.Rule = rule
+		match.Category = rule.Category
+	}
+
+	return match, nil
+}
+
+// ApplyRules recategorises items of ledger selected by params with the first
+// rule matching every item. Items no rule matches keep their category. All
+// items are changed in a single batch, so that either all of them are changed
+// or none, and the batch fails if any item changes meanwhile.
+func (s *Service) ApplyRules(ctx context.Context, actor model.Actor, params dto.ApplyRulesParams) (*model.RulesReport, error) {
+	matchers, err := s.ruleMatchers(ctx, params.LedgerID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.RulesReport{DryRun: params.DryRun, Changes: []model.RuleChange{}}
+	var operations []dto.BatchOperation
+
+	itemsParams := dto.GetItemsParams{
+		LedgerID: params.LedgerID,
+		DateFrom: params.DateFrom,
+		DateTo:   params.DateTo,
+	}
+	err = s.storage.StreamItems(ctx, itemsParams, func(item model.Item) error {
+		report.Checked++
+
+		rule := firstMatch(matchers, sampleOfItem(item))
+		if rule == nil || strings.EqualFold(rule.Category, item.Category) {
+			return nil
+		}
+
+		report.Changes = append(report.Changes, model.RuleChange{
+			ItemID: item.ID,
+			RuleID: rule.ID,
+			From:   item.Category,
+			To:     rule.Category,
+		})
+		operations = append(operations, dto.BatchOperation{
+			Op:      "update",
+			ID:      item.ID,
+			Version: item.Version,
+			Changes: &dto.UpdateItem{Category: &rule.Category},
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get items to apply rules to: %w", err)
+	}
+
+	if params.DryRun || len(operations) == 0 {
+		return report, nil
+	}
+
+	if _, err := s.storage.ExecuteBatch(ctx, actor, params.LedgerID, operations); err != nil {
+		return nil, fmt.Errorf("could not apply rules: %w", err)
+	}
+	report.Updated = len(operations)
+
+	return report, nil
+}
+
+// categorise returns category of the first rule matching item, or
+// ErrUncategorised if there is no such rule.
+func categorise(matchers []ruleMatcher, item dto.CreateItem) (string, error) {
+	rule := firstMatch(matchers, dto.RuleSample{
+		Type:         item.Type,
+		Amount:       item.Amount,
+		Description:  item.Description,
+		Counterparty: item.Counterparty,
+	})
+	if rule == nil {
+		return "", ErrUncategorised
+	}
+
+	return rule.Category, nil
+}
+
+func sampleOfItem(item model.Item) dto.RuleSample {
+	return dto.RuleSample{
+		Type:         item.Type,
+		Amount:       item.Amount,
+		Description:  item.Description,
+		Counterparty: item.Counterparty,
+	}
+}
+
+// ruleMatcher is a rule with its patterns compiled, nil pattern matches
+// everything.
+type ruleMatcher struct {
+	rule         model.Rule
+	description  *regexp.Regexp
+	counterparty *regexp.Regexp
+}
+
+// ruleMatchers returns matchers of ledger rules in the order rules are tried.
+func (s *Service) ruleMatchers(ctx context.Context, ledgerID int) ([]ruleMatcher, error) {
+	rules, err := s.storage.GetRules(ctx, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+
+	matchers := make([]ruleMatcher, len(rules))
+	for i, rule := range rules {
+		matchers[i].rule = rule
+		if matchers[i].description, err = compilePattern(rule.Description, rule.Regex); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", rule.ID, err)
+		}
+		if matchers[i].counterparty, err = compilePattern(rule.Counterparty, rule.Regex); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", rule.ID, err)
+		}
+	}
+
+	return matchers, nil
+}
+
+// lazyRuleMatchers returns function loading matchers of ledger rules on the
+// first call and returning the same ones afterwards, even if ledger has no
+// rules, so that rules are queried once at most and not at all if every item
+// has category.
+func (s *Service) lazyRuleMatchers(ctx context.Context, ledgerID int) func() ([]ruleMatcher, error) {
+	var (
+		matchers []ruleMatcher
+		loaded   bool
+	)
+
+	return func() ([]ruleMatcher, error) {
+		if !loaded {
+			var err error
+			if matchers, err = s.ruleMatchers(ctx, ledgerID); err != nil {
+				return nil, err
+			}
+			loaded = true
+		}
+
+		return matchers, nil
+	}
+}
+
+func firstMatch(matchers []ruleMatcher, sample dto.RuleSample) *model.Rule {
+	for _, matcher := range matchers {
+		if matcher.matches(sample) {
+			rule := matcher.rule
+			return &rule
+		}
+	}
+
+	return nil
+}
+
+func (m ruleMatcher) matches(sample dto.RuleSample) bool {
+	rule := m.rule
+	switch {
+	case rule.Type != "" && rule.Type != sample.Type:
+		return false
+	case rule.AmountMin != nil && sample.Amount.LessThan(*rule.AmountMin):
+		return false
+	case rule.AmountMax != nil && sample.Amount.GreaterThan(*rule.AmountMax):
+		return false
+	case m.description != nil && !m.description.MatchString(sample.Description):
+		return false
+	case m.counterparty != nil && !m.counterparty.MatchString(sample.Counterparty):
+		return false
+	}
+
+	return true
+}
+
+// compilePattern compiles case-insensitive pattern, which is a regular
+// expression if regex is set and a substring otherwise. Empty pattern gives
+// nil.
+func compilePattern(pattern string, regex bool) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	if !regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// checkRule reports rules matching every item, patterns which are not valid
+// regular expressions and amount bounds in the wrong order.
+func checkRule(rule dto.CreateRule) error {
+	var fields []model.FieldError
+
+	if rule.Description == "" && rule.Counterparty == "" && rule.Type == "" && rule.AmountMin == nil && rule.AmountMax == nil {
+		fields = append(fields, model.FieldError{
+			Field:   "description",
+			Rule:    "condition",
+			Message: "rule must have at least one of description, counterparty, type, amount_min and amount_max",
+		})
+	}
+
+	patterns := []struct{ field, pattern string }{
+		{"description", rule.Description},
+		{"counterparty", rule.Counterparty},
+	}
+	for _, p := range patterns {
+		if _, err := compilePattern(p.pattern, rule.Regex); err != nil {
+			fields = append(fields, model.FieldError{
+				Field:   p.field,
+				Rule:    "regexp",
+				Message: p.field + " must be a valid regular expression",
+			})
+		}
+	}
+
+	if rule.AmountMin != nil && rule.AmountMax != nil && rule.AmountMin.GreaterThan(*rule.AmountMax) {
+		fields = append(fields, model.FieldError{
+			Field:   "amount_max",
+			Rule:    "gtefield",
+			Message: "amount_max must be at least amount_min",
+		})
+	}
+
+	if len(fields) > 0 {
+		return model.NewValidationError("validation_failed", "invalid payload: "+validate.Message(fields), fields)
+	}
+
+	return nil
+}
@@ -2,34 +2,70 @@ package service
 
 import (
 	"context"
-	"log"
-	"os"
+	"time"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
 	"github.com/Komilov31/sales-tracker/internal/model"
 )
 
 type Storage interface {
-	CreateItem(ctx context.Context, item dto.CreateItem) (*model.Item, error)
+	CreateItem(ctx context.Context, actor model.Actor, ledgerID int, item dto.CreateItem) (*model.Item, error)
+	CreateItems(ctx context.Context, actor model.Actor, ledgerID int, items []dto.CreateItem) ([]model.Item, error)
+	GetItem(ctx context.Context, ledgerID, id int) (*model.Item, error)
 	GetAllItems(ctx context.Context, params dto.GetItemsParams) ([]model.Item, error)
-	UpdateItem(ctx context.Context, id int, item dto.UpdateItem) error
-	DeleteItem(ctx context.Context, id int) error
-	GetAggregated(ctx context.Context, from, to string) ([]model.Item, error)
+	StreamItems(ctx context.Context, params dto.GetItemsParams, fn func(model.Item) error) error
+	CountItems(ctx context.Context, params dto.GetItemsParams) (int, error)
+	UpdateItem(ctx context.Context, actor model.Actor, ledgerID, id, version int, item dto.UpdateItem) error
+	DeleteItem(ctx context.Context, actor model.Actor, ledgerID, id, version int) error
+	RestoreItem(ctx context.Context, actor model.Actor, ledgerID, id int) (*model.Item, error)
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
+	ExecuteBatch(ctx context.Context, actor model.Actor, ledgerID int, operations []dto.BatchOperation) ([]model.BatchResult, error)
+	GetAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.Item, error)
+	StreamAggregated(ctx context.Context, params dto.AnalyticsParams, fn func(model.Item) error) error
+	GetGroupedAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.GroupedAggregated, error)
+	GetSummary(ctx context.Context, params dto.AnalyticsParams) (*model.Aggregated, error)
+	CreateExchangeRate(ctx context.Context, ledgerID int, rate dto.CreateExchangeRate) (*model.ExchangeRate, error)
+	GetExchangeRates(ctx context.Context, params dto.GetExchangeRatesParams) ([]model.ExchangeRate, error)
+	DeleteExchangeRate(ctx context.Context, ledgerID, id int) error
+	CreateUser(ctx context.Context, user dto.CreateUser) (*model.User, error)
+	GetUserByLogin(ctx context.Context, login string) (*model.User, error)
+	CreateLedger(ctx context.Context, userID int, name string) (*model.Ledger, error)
+	GetLedgers(ctx context.Context, userID int) ([]model.Ledger, error)
+	GetLedger(ctx context.Context, userID, ledgerID int) (*model.Ledger, error)
+	GetMembers(ctx context.Context, ledgerID int) ([]model.Member, error)
+	AddMember(ctx context.Context, ledgerID int, login, role string) (*model.Member, error)
+	UpdateMemberRole(ctx context.Context, ledgerID, userID int, role string) error
+	RemoveMember(ctx context.Context, ledgerID, userID int) error
+	CreateAPIKey(ctx context.Context, key model.APIKey, hash string) (*model.APIKey, error)
+	GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error)
+	UseAPIKey(ctx context.Context, hash string) (*model.APIKey, *model.User, error)
+	RevokeAPIKey(ctx context.Context, userID, id int) error
+	GetItemHistory(ctx context.Context, ledgerID, itemID int) ([]model.AuditEntry, error)
+	GetAudit(ctx context.Context, params dto.AuditParams) (*model.AuditPage, error)
+	GetCategories(ctx context.Context, ledgerID int) ([]model.Category, error)
+	CreateCategory(ctx context.Context, ledgerID int, category dto.CreateCategory) (*model.Category, error)
+	UpdateCategory(ctx context.Context, actor model.Actor, ledgerID, id int, category dto.UpdateCategory) (*model.Category, error)
+	DeleteCategory(ctx context.Context, actor model.Actor, ledgerID, id, replacementID int) error
+	GetRules(ctx context.Context, ledgerID int) ([]model.Rule, error)
+	CreateRule(ctx context.Context, ledgerID int, rule dto.CreateRule) (*model.Rule, error)
+	UpdateRule(ctx context.Context, ledgerID, id int, rule dto.UpdateRule) (*model.Rule, error)
+	DeleteRule(ctx context.Context, ledgerID, id int) error
 }
 
 type Service struct {
-	storage    Storage
-	folderName string
+	storage      Storage
+	cursorSecret []byte
+	tokenSecret  []byte
+	tokenTTL     time.Duration
+	exporters    []Exporter
 }
 
-func New(storage Storage) *Service {
-	folderName, err := os.MkdirTemp(".", "csv")
-	if err != nil {
-		log.Fatal("could not create folder to store csv files: ", err)
-	}
-
+func New(storage Storage, cursorSecret, tokenSecret []byte, tokenTTL time.Duration) *Service {
 	return &Service{
-		storage:    storage,
-		folderName: folderName,
+		storage:      storage,
+		cursorSecret: cursorSecret,
+		tokenSecret:  tokenSecret,
+		tokenTTL:     tokenTTL,
+		exporters:    defaultExporters(),
 	}
 }
@@ -1,64 +1,287 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/csv"
-	"os"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/crypto/bcrypt"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
 	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/Komilov31/sales-tracker/internal/repository"
 )
 
+var (
+	testCursorSecret = []byte("secret")
+	testTokenSecret  = []byte("token secret")
+)
+
+const testLedgerID = 1
+
+var testActor = model.Actor{UserID: 1}
+
 type mockStorage struct {
 	mock.Mock
 }
 
-func (m *mockStorage) CreateItem(ctx context.Context, item dto.CreateItem) (*model.Item, error) {
-	args := m.Called(ctx, item)
+func (m *mockStorage) CreateItem(ctx context.Context, actor model.Actor, ledgerID int, item dto.CreateItem) (*model.Item, error) {
+	args := m.Called(ctx, actor, ledgerID, item)
 	return args.Get(0).(*model.Item), args.Error(1)
 }
 
+func (m *mockStorage) CreateItems(ctx context.Context, actor model.Actor, ledgerID int, items []dto.CreateItem) ([]model.Item, error) {
+	args := m.Called(ctx, actor, ledgerID, items)
+	return args.Get(0).([]model.Item), args.Error(1)
+}
+
 func (m *mockStorage) GetAllItems(ctx context.Context, params dto.GetItemsParams) ([]model.Item, error) {
 	args := m.Called(ctx, params)
 	return args.Get(0).([]model.Item), args.Error(1)
 }
 
-func (m *mockStorage) UpdateItem(ctx context.Context, id int, item dto.UpdateItem) error {
-	args := m.Called(ctx, id, item)
+// StreamItems passes items returned by the mock to fn one by one.
+func (m *mockStorage) StreamItems(ctx context.Context, params dto.GetItemsParams, fn func(model.Item) error) error {
+	args := m.Called(ctx, params)
+	for _, item := range args.Get(0).([]model.Item) {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *mockStorage) CountItems(ctx context.Context, params dto.GetItemsParams) (int, error) {
+	args := m.Called(ctx, params)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockStorage) UpdateItem(ctx context.Context, actor model.Actor, ledgerID, id, version int, item dto.UpdateItem) error {
+	args := m.Called(ctx, actor, ledgerID, id, version, item)
 	return args.Error(0)
 }
 
-func (m *mockStorage) DeleteItem(ctx context.Context, id int) error {
-	args := m.Called(ctx, id)
+func (m *mockStorage) DeleteItem(ctx context.Context, actor model.Actor, ledgerID, id, version int) error {
+	args := m.Called(ctx, actor, ledgerID, id, version)
 	return args.Error(0)
 }
 
-func (m *mockStorage) GetAggregated(ctx context.Context, from, to string) ([]model.Item, error) {
-	args := m.Called(ctx, from, to)
+func (m *mockStorage) GetItem(ctx context.Context, ledgerID, id int) (*model.Item, error) {
+	args := m.Called(ctx, ledgerID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Item), args.Error(1)
+}
+
+func (m *mockStorage) ExecuteBatch(ctx context.Context, actor model.Actor, ledgerID int, operations []dto.BatchOperation) ([]model.BatchResult, error) {
+	args := m.Called(ctx, actor, ledgerID, operations)
+	return args.Get(0).([]model.BatchResult), args.Error(1)
+}
+
+func (m *mockStorage) GetAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.Item, error) {
+	args := m.Called(ctx, params)
 	return args.Get(0).([]model.Item), args.Error(1)
 }
 
+func (m *mockStorage) StreamAggregated(ctx context.Context, params dto.AnalyticsParams, fn func(model.Item) error) error {
+	args := m.Called(ctx, params)
+	for _, item := range args.Get(0).([]model.Item) {
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *mockStorage) GetGroupedAggregated(ctx context.Context, params dto.AnalyticsParams) ([]model.GroupedAggregated, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).([]model.GroupedAggregated), args.Error(1)
+}
+
+func (m *mockStorage) GetSummary(ctx context.Context, params dto.AnalyticsParams) (*model.Aggregated, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*model.Aggregated), args.Error(1)
+}
+
+func (m *mockStorage) CreateExchangeRate(ctx context.Context, ledgerID int, rate dto.CreateExchangeRate) (*model.ExchangeRate, error) {
+	args := m.Called(ctx, ledgerID, rate)
+	return args.Get(0).(*model.ExchangeRate), args.Error(1)
+}
+
+func (m *mockStorage) GetExchangeRates(ctx context.Context, params dto.GetExchangeRatesParams) ([]model.ExchangeRate, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).([]model.ExchangeRate), args.Error(1)
+}
+
+func (m *mockStorage) DeleteExchangeRate(ctx context.Context, ledgerID, id int) error {
+	args := m.Called(ctx, ledgerID, id)
+	return args.Error(0)
+}
+
+func (m *mockStorage) CreateUser(ctx context.Context, user dto.CreateUser) (*model.User, error) {
+	args := m.Called(ctx, user)
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *mockStorage) GetUserByLogin(ctx context.Context, login string) (*model.User, error) {
+	args := m.Called(ctx, login)
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *mockStorage) CreateLedger(ctx context.Context, userID int, name string) (*model.Ledger, error) {
+	args := m.Called(ctx, userID, name)
+	return args.Get(0).(*model.Ledger), args.Error(1)
+}
+
+func (m *mockStorage) GetLedgers(ctx context.Context, userID int) ([]model.Ledger, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]model.Ledger), args.Error(1)
+}
+
+func (m *mockStorage) GetLedger(ctx context.Context, userID, ledgerID int) (*model.Ledger, error) {
+	args := m.Called(ctx, userID, ledgerID)
+	return args.Get(0).(*model.Ledger), args.Error(1)
+}
+
+func (m *mockStorage) GetMembers(ctx context.Context, ledgerID int) ([]model.Member, error) {
+	args := m.Called(ctx, ledgerID)
+	return args.Get(0).([]model.Member), args.Error(1)
+}
+
+func (m *mockStorage) AddMember(ctx context.Context, ledgerID int, login, role string) (*model.Member, error) {
+	args := m.Called(ctx, ledgerID, login, role)
+	return args.Get(0).(*model.Member), args.Error(1)
+}
+
+func (m *mockStorage) UpdateMemberRole(ctx context.Context, ledgerID, userID int, role string) error {
+	args := m.Called(ctx, ledgerID, userID, role)
+	return args.Error(0)
+}
+
+func (m *mockStorage) RemoveMember(ctx context.Context, ledgerID, userID int) error {
+	args := m.Called(ctx, ledgerID, userID)
+	return args.Error(0)
+}
+
+func (m *mockStorage) CreateAPIKey(ctx context.Context, key model.APIKey, hash string) (*model.APIKey, error) {
+	args := m.Called(ctx, key, hash)
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
+
+func (m *mockStorage) GetAPIKeys(ctx context.Context, userID int) ([]model.APIKey, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *mockStorage) UseAPIKey(ctx context.Context, hash string) (*model.APIKey, *model.User, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(*model.APIKey), args.Get(1).(*model.User), args.Error(2)
+}
+
+func (m *mockStorage) RevokeAPIKey(ctx context.Context, userID, id int) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *mockStorage) GetItemHistory(ctx context.Context, ledgerID, itemID int) ([]model.AuditEntry, error) {
+	args := m.Called(ctx, ledgerID, itemID)
+	return args.Get(0).([]model.AuditEntry), args.Error(1)
+}
+
+func (m *mockStorage) GetAudit(ctx context.Context, params dto.AuditParams) (*model.AuditPage, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*model.AuditPage), args.Error(1)
+}
+
+func (m *mockStorage) GetCategories(ctx context.Context, ledgerID int) ([]model.Category, error) {
+	args := m.Called(ctx, ledgerID)
+	return args.Get(0).([]model.Category), args.Error(1)
+}
+
+func (m *mockStorage) CreateCategory(ctx context.Context, ledgerID int, category dto.CreateCategory) (*model.Category, error) {
+	args := m.Called(ctx, ledgerID, category)
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *mockStorage) UpdateCategory(ctx context.Context, actor model.Actor, ledgerID, id int, category dto.UpdateCategory) (*model.Category, error) {
+	args := m.Called(ctx, actor, ledgerID, id, category)
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *mockStorage) DeleteCategory(ctx context.Context, actor model.Actor, ledgerID, id, replacementID int) error {
+	args := m.Called(ctx, actor, ledgerID, id, replacementID)
+	return args.Error(0)
+}
+
+func (m *mockStorage) GetRules(ctx context.Context, ledgerID int) ([]model.Rule, error) {
+	args := m.Called(ctx, ledgerID)
+	return args.Get(0).([]model.Rule), args.Error(1)
+}
+
+func (m *mockStorage) CreateRule(ctx context.Context, ledgerID int, rule dto.CreateRule) (*model.Rule, error) {
+	args := m.Called(ctx, ledgerID, rule)
+	return args.Get(0).(*model.Rule), args.Error(1)
+}
+
+func (m *mockStorage) UpdateRule(ctx context.Context, ledgerID, id int, rule dto.UpdateRule) (*model.Rule, error) {
+	args := m.Called(ctx, ledgerID, id, rule)
+	return args.Get(0).(*model.Rule), args.Error(1)
+}
+
+func (m *mockStorage) DeleteRule(ctx context.Context, ledgerID, id int) error {
+	args := m.Called(ctx, ledgerID, id)
+	return args.Error(0)
+}
+
+func (m *mockStorage) RestoreItem(ctx context.Context, actor model.Actor, ledgerID, id int) (*model.Item, error) {
+	args := m.Called(ctx, actor, ledgerID, id)
+	return args.Get(0).(*model.Item), args.Error(1)
+}
+
+func (m *mockStorage) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func TestNew(t *testing.T) {
 	storage := &mockStorage{}
-	s := New(storage)
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
 	assert.NotNil(t, s)
 	assert.Equal(t, storage, s.storage)
-	assert.NotEmpty(t, s.folderName)
 }
 
 func TestCreateItem(t *testing.T) {
 	storage := &mockStorage{}
-	s := New(storage)
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	item := dto.CreateItem{Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test", Currency: "USD"}
+	expected := &model.Item{ID: 1, Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test", Currency: "USD", CreatedAt: time.Now()}
+	storage.On("CreateItem", ctx, mock.Anything, testLedgerID, item).Return(expected, nil)
+	result, err := s.CreateItem(ctx, testActor, testLedgerID, item)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+	storage.AssertExpectations(t)
+}
+
+func TestCreateItemDefaultCurrency(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
 	ctx := context.Background()
-	item := dto.CreateItem{Type: "доход", Amount: 100, Date: "2023-01-01", Category: "test"}
-	expected := &model.Item{ID: 1, Type: "доход", Amount: 100, Date: "2023-01-01", Category: "test", CreatedAt: time.Now()}
-	storage.On("CreateItem", ctx, item).Return(expected, nil)
-	result, err := s.CreateItem(ctx, item)
+	item := dto.CreateItem{Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test"}
+	withCurrency := item
+	withCurrency.Currency = DefaultCurrency
+	expected := &model.Item{ID: 1, Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test", Currency: DefaultCurrency}
+	storage.On("CreateItem", ctx, mock.Anything, testLedgerID, withCurrency).Return(expected, nil)
+	result, err := s.CreateItem(ctx, testActor, testLedgerID, item)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 	storage.AssertExpectations(t)
@@ -66,10 +289,10 @@ func TestCreateItem(t *testing.T) {
 
 func TestGetAllItems(t *testing.T) {
 	storage := &mockStorage{}
-	s := New(storage)
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
 	ctx := context.Background()
 	params := dto.GetItemsParams{SortBy: []string{"date"}}
-	expected := []model.Item{{ID: 1, Type: "расход", Amount: 50, Date: "2023-01-01", Category: "test", CreatedAt: time.Now()}}
+	expected := []model.Item{{ID: 1, Type: "расход", Amount: decimal.NewFromInt(50), Date: "2023-01-01", Category: "test", CreatedAt: time.Now()}}
 	storage.On("GetAllItems", ctx, params).Return(expected, nil)
 	result, err := s.GetAllItems(ctx, params)
 	assert.NoError(t, err)
@@ -77,88 +300,1062 @@ func TestGetAllItems(t *testing.T) {
 	storage.AssertExpectations(t)
 }
 
+func TestGetItemsPage(t *testing.T) {
+	ctx := context.Background()
+	createdAt := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	items := []model.Item{
+		{ID: 1, Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test", CreatedAt: createdAt},
+		{ID: 2, Type: "расход", Amount: decimal.NewFromInt(50), Date: "2023-01-02", Category: "test", CreatedAt: createdAt},
+		{ID: 3, Type: "расход", Amount: decimal.NewFromInt(70), Date: "2023-01-03", Category: "test", CreatedAt: createdAt},
+	}
+
+	t.Run("first page", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		params := dto.GetItemsParams{SortBy: []string{"date"}, Limit: 2}
+		storage.On("GetAllItems", ctx, dto.GetItemsParams{SortBy: []string{"date"}, Limit: 3}).Return(items, nil)
+		storage.On("CountItems", ctx, mock.Anything).Return(3, nil)
+
+		page, err := s.GetItemsPage(ctx, params)
+		assert.NoError(t, err)
+		assert.Equal(t, items[:2], page.Items)
+		assert.Equal(t, 3, page.Total)
+		assert.NotEmpty(t, page.NextCursor)
+		assert.Empty(t, page.PrevCursor)
+
+		keyset, err := s.decodeCursor(page.NextCursor, params.SortBy)
+		assert.NoError(t, err)
+		assert.Equal(t, &dto.Keyset{Values: []string{"2023-01-02", "2"}}, keyset)
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("next page", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		cursor := s.encodeCursor([]string{"date"}, items[1], false)
+		params := dto.GetItemsParams{SortBy: []string{"date"}, Limit: 2, Cursor: cursor}
+		expected := dto.GetItemsParams{
+			SortBy: []string{"date"}, Limit: 3, Cursor: cursor,
+			Keyset: &dto.Keyset{Values: []string{"2023-01-02", "2"}},
+		}
+		storage.On("GetAllItems", ctx, expected).Return(items[2:], nil)
+		storage.On("CountItems", ctx, mock.Anything).Return(3, nil)
+
+		page, err := s.GetItemsPage(ctx, params)
+		assert.NoError(t, err)
+		assert.Equal(t, items[2:], page.Items)
+		assert.Empty(t, page.NextCursor)
+		assert.NotEmpty(t, page.PrevCursor)
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("previous page", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		cursor := s.encodeCursor(nil, items[2], true)
+		params := dto.GetItemsParams{Limit: 1, Cursor: cursor}
+		expected := dto.GetItemsParams{
+			Limit: 2, Cursor: cursor,
+			Keyset: &dto.Keyset{Values: []string{"3"}, Backward: true},
+		}
+		storage.On("GetAllItems", ctx, expected).Return([]model.Item{items[1], items[0]}, nil)
+		storage.On("CountItems", ctx, mock.Anything).Return(3, nil)
+
+		page, err := s.GetItemsPage(ctx, params)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Item{items[1]}, page.Items)
+		assert.NotEmpty(t, page.NextCursor)
+		assert.NotEmpty(t, page.PrevCursor)
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("tampered cursor", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		cursor := s.encodeCursor(nil, items[0], false)
+
+		other := New(storage, []byte("other secret"), testTokenSecret, time.Hour)
+		_, err := other.GetItemsPage(ctx, dto.GetItemsParams{Limit: 1, Cursor: cursor})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, err = s.GetItemsPage(ctx, dto.GetItemsParams{Limit: 1, Cursor: "x" + cursor})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+
+		_, err = s.GetItemsPage(ctx, dto.GetItemsParams{SortBy: []string{"amount"}, Limit: 1, Cursor: cursor})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+		storage.AssertNotCalled(t, "GetAllItems")
+	})
+}
+
 func TestGetAggregated(t *testing.T) {
 	storage := &mockStorage{}
-	s := New(storage)
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
 	ctx := context.Background()
-	from, to := "2023-01-01", "2023-12-31"
-	expected := []model.Item{{ID: 1, Type: "доход", Amount: 100, Date: "2023-01-01", Category: "test", CreatedAt: time.Now(), Aggregated: model.Aggregated{Sum: 100, Average: 100.0, Count: 1, Median: 100.0, Percentile_90: 100.0}}}
-	storage.On("GetAggregated", ctx, from, to).Return(expected, nil)
-	result, err := s.GetAggregated(ctx, from, to)
+	params := dto.AnalyticsParams{From: "2023-01-01", To: "2023-12-31", Currency: "USD"}
+	expected := []model.Item{{ID: 1, Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test", Currency: "USD", CreatedAt: time.Now(), Aggregated: model.Aggregated{Sum: decimal.NewFromInt(100), Average: decimal.NewFromInt(100), Count: 1, Median: decimal.NewFromInt(100), Percentile_90: decimal.NewFromInt(100)}}}
+	storage.On("GetAggregated", ctx, params).Return(expected, nil)
+	result, err := s.GetAggregated(ctx, params)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 	storage.AssertExpectations(t)
 }
 
+func TestGetGroupedAggregated(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	params := dto.AnalyticsParams{From: "2023-01-01", To: "2023-12-31", GroupBy: []string{"type", "quarter"}}
+	expected := []model.GroupedAggregated{{
+		Group:      map[string]string{"type": "доход", "quarter": "2023-Q1"},
+		Aggregated: model.Aggregated{Sum: decimal.NewFromInt(100), Average: decimal.NewFromInt(100), Count: 1, Median: decimal.NewFromInt(100), Percentile_90: decimal.NewFromInt(100)},
+	}}
+	storage.On("GetGroupedAggregated", ctx, params).Return(expected, nil)
+	result, err := s.GetGroupedAggregated(ctx, params)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+	storage.AssertExpectations(t)
+}
+
+func TestGetSummary(t *testing.T) {
+	ctx := context.Background()
+	params := dto.AnalyticsParams{LedgerID: testLedgerID, From: "2023-01-01", To: "2023-12-31"}
+	aggregated := &model.Aggregated{Sum: decimal.NewFromInt(50), Count: 2, Income: model.TypeStats{Sum: decimal.NewFromInt(100), Count: 1}, Expense: model.TypeStats{Sum: decimal.NewFromInt(50), Count: 1}, Net: decimal.NewFromInt(50)}
+
+	t.Run("without items", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		storage.On("GetSummary", ctx, params).Return(aggregated, nil)
+		result, err := s.GetSummary(ctx, params, false)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Summary{Aggregated: *aggregated}, result)
+		storage.AssertExpectations(t)
+		storage.AssertNotCalled(t, "GetAllItems")
+	})
+
+	t.Run("with items", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		items := []model.Item{{ID: 1, Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test"}}
+		storage.On("GetSummary", ctx, params).Return(aggregated, nil)
+		storage.On("GetAllItems", ctx, dto.GetItemsParams{LedgerID: testLedgerID, DateFrom: params.From, DateTo: params.To}).Return(items, nil)
+		result, err := s.GetSummary(ctx, params, true)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.Summary{Aggregated: *aggregated, Items: items}, result)
+		storage.AssertExpectations(t)
+	})
+}
+
+func TestGetLedger(t *testing.T) {
+	ctx := context.Background()
+	ledgers := []model.Ledger{{ID: 3, Name: "admin"}, {ID: 5, Name: "Семья"}}
+
+	t.Run("selected", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		storage.On("GetLedger", ctx, 1, 5).Return(&ledgers[1], nil)
+
+		ledger, err := s.GetLedger(ctx, 1, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, &ledgers[1], ledger)
+		storage.AssertNotCalled(t, "GetLedgers", mock.Anything, mock.Anything)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		storage.On("GetLedgers", ctx, 1).Return(ledgers, nil)
+
+		ledger, err := s.GetLedger(ctx, 1, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, &ledgers[0], ledger)
+	})
+
+	t.Run("no ledgers", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		storage.On("GetLedgers", ctx, 1).Return([]model.Ledger(nil), nil)
+
+		_, err := s.GetLedger(ctx, 1, 0)
+		assert.ErrorIs(t, err, repository.ErrNoSuchLedger)
+	})
+}
+
+func TestAddMember(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+
+	member := &model.Member{UserID: 2, Login: "guest", Role: model.RoleViewer}
+	storage.On("AddMember", ctx, testLedgerID, "guest", model.RoleViewer).Return(member, nil)
+
+	result, err := s.AddMember(ctx, testLedgerID, dto.AddMember{Login: "guest", Role: model.RoleViewer})
+	assert.NoError(t, err)
+	assert.Equal(t, member, result)
+	storage.AssertExpectations(t)
+}
+
 func TestUpdateItem(t *testing.T) {
 	storage := &mockStorage{}
-	s := New(storage)
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
 	ctx := context.Background()
 	id := 1
 	item := dto.UpdateItem{Type: stringPtr("расход")}
-	storage.On("UpdateItem", ctx, id, item).Return(nil)
-	err := s.UpdateItem(ctx, id, item)
+	storage.On("UpdateItem", ctx, mock.Anything, testLedgerID, id, 2, item).Return(nil)
+	err := s.UpdateItem(ctx, testActor, testLedgerID, id, 2, item)
 	assert.NoError(t, err)
 	storage.AssertExpectations(t)
+
+	err = s.UpdateItem(ctx, testActor, testLedgerID, id, 2, dto.UpdateItem{})
+	assert.ErrorIs(t, err, ErrEmptyUpdate)
+	storage.AssertNumberOfCalls(t, "UpdateItem", 1)
+}
+
+func TestUpdateItemVersionConflict(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	item := dto.UpdateItem{Category: stringPtr("food")}
+	storage.On("UpdateItem", ctx, mock.Anything, testLedgerID, 1, 2, item).Return(repository.ErrVersionConflict)
+	err := s.UpdateItem(ctx, testActor, testLedgerID, 1, 2, item)
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+	storage.AssertExpectations(t)
 }
 
 func TestDeleteItem(t *testing.T) {
 	storage := &mockStorage{}
-	s := New(storage)
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
 	ctx := context.Background()
 	id := 1
-	storage.On("DeleteItem", ctx, id).Return(nil)
-	err := s.DeleteItem(ctx, id)
+	storage.On("DeleteItem", ctx, mock.Anything, testLedgerID, id, 2).Return(nil)
+	err := s.DeleteItem(ctx, testActor, testLedgerID, id, 2)
 	assert.NoError(t, err)
 	storage.AssertExpectations(t)
 }
 
 func TestCSVAggregated(t *testing.T) {
 	storage := &mockStorage{}
-	s := New(storage)
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
 	ctx := context.Background()
-	from, to := "2023-01-01", "2023-12-31"
-	data := []model.Item{{ID: 1, Type: "доход", Amount: 100, Date: "2023-01-01", Category: "test", CreatedAt: time.Now(), Aggregated: model.Aggregated{Sum: 100, Average: 100.0, Count: 1, Median: 100.0, Percentile_90: 100.0}}}
-	storage.On("GetAggregated", ctx, from, to).Return(data, nil)
-	fileName, err := s.CSVAggregated(ctx, from, to)
+	params := dto.AnalyticsParams{From: "2023-01-01", To: "2023-12-31"}
+	data := []model.Item{{ID: 1, Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test", Currency: "RUB", CreatedAt: time.Now(), Aggregated: model.Aggregated{
+		Sum: decimal.NewFromInt(100), Average: decimal.NewFromInt(100), Count: 1, Median: decimal.NewFromInt(100), Percentile_90: decimal.NewFromInt(100),
+		Income: model.TypeStats{Sum: decimal.NewFromInt(100), Average: decimal.NewFromInt(100), Count: 1, Median: decimal.NewFromInt(100), Percentile_90: decimal.NewFromInt(100)},
+		Net:    decimal.NewFromInt(100),
+	}}}
+	storage.On("StreamAggregated", ctx, params).Return(data, nil)
+	var buf bytes.Buffer
+	err := s.CSVAggregated(ctx, &buf, params, dto.ExportOptions{})
 	assert.NoError(t, err)
-	assert.NotEmpty(t, fileName)
-	defer os.Remove(fileName)
-	file, err := os.Open(fileName)
-	assert.NoError(t, err)
-	defer file.Close()
-	reader := csv.NewReader(file)
+	reader := csv.NewReader(&buf)
 	records, err := reader.ReadAll()
 	assert.NoError(t, err)
 	assert.Len(t, records, 2)
-	assert.Equal(t, []string{"id", "type", "amount", "date", "category", "created_at", "sum", "avarage", "count", "median", "percentile_90"}, records[0])
-	assert.Equal(t, []string{"1", "доход", "100", "2023-01-01", "test", data[0].CreatedAt.String(), "100", "100.00", "1", "100.00", "100.00"}, records[1])
+	assert.Equal(t, []string{"id", "type", "amount", "date", "category", "currency", "created_at", "sum", "average", "count", "median", "percentile_90",
+		"income_sum", "income_average", "income_count", "income_median", "income_percentile_90",
+		"expense_sum", "expense_average", "expense_count", "expense_median", "expense_percentile_90",
+		"net"}, records[0])
+	assert.Equal(t, []string{"1", "доход", "100.00", "2023-01-01", "test", "RUB", data[0].CreatedAt.Format(time.RFC3339), "100.00", "100.00", "1", "100.00", "100.00",
+		"100.00", "100.00", "1", "100.00", "100.00",
+		"0.00", "0.00", "0", "0.00", "0.00",
+		"100.00"}, records[1])
 	storage.AssertExpectations(t)
 }
 
 func TestCSVAllItems(t *testing.T) {
 	storage := &mockStorage{}
-	s := New(storage)
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	params := dto.GetItemsParams{}
+	data := []model.Item{
+		{ID: 1, Type: "расход", Amount: decimal.RequireFromString("149.9"), Date: "2023-01-01", Category: "test", Currency: "EUR", CreatedAt: time.Now()},
+		{ID: 2, Type: "расход", Amount: decimal.NewFromInt(1500), Date: "2023-01-02", Category: "test", Currency: "JPY", CreatedAt: time.Now()},
+	}
+	storage.On("StreamItems", ctx, params).Return(data, nil)
+	var buf bytes.Buffer
+	err := s.CSVAllItems(ctx, &buf, params, dto.ExportOptions{})
+	assert.NoError(t, err)
+	reader := csv.NewReader(&buf)
+	records, err := reader.ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, []string{"id", "type", "amount", "date", "category", "currency", "created_at"}, records[0])
+	assert.Equal(t, []string{"1", "расход", "149.90", "2023-01-01", "test", "EUR", data[0].CreatedAt.Format(time.RFC3339)}, records[1])
+	assert.Equal(t, []string{"2", "расход", "1500", "2023-01-02", "test", "JPY", data[1].CreatedAt.Format(time.RFC3339)}, records[2])
+	storage.AssertExpectations(t)
+}
+
+func TestCSVAllItemsOptions(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	params := dto.GetItemsParams{}
+	data := []model.Item{
+		{ID: 1, Type: "расход", Amount: decimal.RequireFromString("1149.9"), Date: "2023-01-02T00:00:00Z", Category: "кафе; бар", Currency: "EUR",
+			CreatedAt: time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)},
+	}
+	storage.On("StreamItems", ctx, params).Return(data, nil)
+	options := dto.ExportOptions{
+		Delimiter:        ';',
+		BOM:              true,
+		DecimalSeparator: ",",
+		DateFormat:       DateFormatRU,
+		Language:         LanguageRU,
+		Columns:          []string{"date", "category", "amount", "created_at"},
+	}
+
+	var buf bytes.Buffer
+	err := s.CSVAllItems(ctx, &buf, params, options)
+	assert.NoError(t, err)
+	assert.Equal(t, "\ufeffДата;Категория;Сумма;Создано\n02.01.2023;\"кафе; бар\";1149,90;02.01.2023 15:04:05\n", buf.String())
+	storage.AssertExpectations(t)
+}
+
+func TestCSVInvalidOptions(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+
+	for _, options := range []dto.ExportOptions{
+		{Delimiter: '"'},
+		{DecimalSeparator: " "},
+		{DateFormat: "us"},
+		{Language: "de"},
+		{Columns: []string{"id", "sum"}},
+	} {
+		var buf bytes.Buffer
+		err := s.CSVAllItems(ctx, &buf, dto.GetItemsParams{}, options)
+		assert.ErrorIs(t, err, ErrInvalidExportOptions)
+		assert.Zero(t, buf.Len())
+	}
+	storage.AssertNotCalled(t, "StreamItems")
+
+	storage.On("StreamAggregated", ctx, dto.AnalyticsParams{}).Return([]model.Item{}, nil)
+	var buf bytes.Buffer
+	err := s.CSVAggregated(ctx, &buf, dto.AnalyticsParams{}, dto.ExportOptions{Columns: []string{"category", "net"}, Language: LanguageRU})
+	assert.NoError(t, err)
+	assert.Equal(t, "Категория,Баланс\n", buf.String())
+}
+
+func TestCSVAllItemsStorageError(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	params := dto.GetItemsParams{}
+	storage.On("StreamItems", ctx, params).Return([]model.Item{}, assert.AnError)
+	var buf bytes.Buffer
+	err := s.CSVAllItems(ctx, &buf, params, dto.ExportOptions{})
+	assert.ErrorIs(t, err, assert.AnError)
+	storage.AssertExpectations(t)
+}
+
+func TestXLSXAllItems(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
 	ctx := context.Background()
 	params := dto.GetItemsParams{}
-	data := []model.Item{{ID: 1, Type: "расход", Amount: 50, Date: "2023-01-01", Category: "test", CreatedAt: time.Now()}}
-	storage.On("GetAllItems", ctx, params).Return(data, nil)
-	fileName, err := s.CSVAllItems(ctx, params)
+	createdAt := time.Date(2023, 1, 1, 12, 30, 0, 0, time.UTC)
+	data := []model.Item{
+		{ID: 1, Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "зарплата", Currency: "RUB", CreatedAt: createdAt},
+		{ID: 2, Type: "расход", Amount: decimal.RequireFromString("149.9"), Date: "2023-01-02T00:00:00Z", Category: "еда/кафе", Currency: "RUB", CreatedAt: createdAt},
+		{ID: 3, Type: "расход", Amount: decimal.NewFromInt(50), Date: "2023-01-03", Category: "Зарплата", Currency: "RUB", CreatedAt: createdAt},
+		{ID: 4, Type: "расход", Amount: decimal.NewFromInt(1500), Date: "2023-01-04", Category: "еда/кафе", Currency: "JPY", CreatedAt: createdAt},
+	}
+	storage.On("StreamItems", ctx, params).Return(data, nil)
+	var buf bytes.Buffer
+	err := s.XLSXAllItems(ctx, &buf, params)
 	assert.NoError(t, err)
-	assert.NotEmpty(t, fileName)
-	defer os.Remove(fileName)
-	file, err := os.Open(fileName)
+	storage.AssertExpectations(t)
+
+	file, err := excelize.OpenReader(&buf)
 	assert.NoError(t, err)
 	defer file.Close()
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+	assert.Equal(t, []string{"Items", "Totals", "зарплата", "еда_кафе", "Зарплата (2)"}, file.GetSheetList())
+
+	rows, err := file.GetRows("Items", excelize.Options{RawCellValue: true})
 	assert.NoError(t, err)
-	assert.Len(t, records, 2)
-	assert.Equal(t, []string{"id", "type", "amount", "date", "category", "created_at"}, records[0])
-	assert.Equal(t, []string{"1", "расход", "50", "2023-01-01", "test", data[0].CreatedAt.String()}, records[1])
+	assert.Len(t, rows, 5)
+	assert.Equal(t, []string{"id", "type", "amount", "date", "category", "currency", "created_at"}, rows[0])
+	assert.Equal(t, "доход", rows[1][1])
+	assert.Equal(t, "149.9", rows[2][2])
+
+	amount, err := file.GetCellValue("Items", "C3")
+	assert.NoError(t, err)
+	assert.Equal(t, "149.90", amount)
+	date, err := file.GetCellValue("Items", "D3")
+	assert.NoError(t, err)
+	assert.Equal(t, "2023-01-02", date)
+	amount, err = file.GetCellValue("Items", "C5")
+	assert.NoError(t, err)
+	assert.Equal(t, "1,500", amount)
+
+	rows, err = file.GetRows("еда_кафе")
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+
+	rows, err = file.GetRows("Totals", excelize.Options{RawCellValue: true})
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, []string{"all", "JPY", "1", "-1500", "-1500", "-1500", "-1500",
+		"0", "0", "0", "0", "0",
+		"1", "1500", "1500", "1500", "1500",
+		"-1500"}, rows[1])
+	assert.Equal(t, []string{"all", "RUB", "3", "-99.9", "-33.3", "-50", "70",
+		"1", "100", "100", "100", "100",
+		"2", "199.9", "99.95", "99.95", "139.91",
+		"-99.9"}, rows[2])
+}
+
+func TestXLSXAggregated(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	params := dto.AnalyticsParams{From: "2023-01-01", To: "2023-12-31", Currency: "USD"}
+	aggregated := model.Aggregated{
+		Sum: decimal.NewFromInt(100), Average: decimal.NewFromInt(100), Count: 1, Median: decimal.NewFromInt(100), Percentile_90: decimal.NewFromInt(100),
+		Income: model.TypeStats{Sum: decimal.NewFromInt(100), Average: decimal.NewFromInt(100), Count: 1, Median: decimal.NewFromInt(100), Percentile_90: decimal.NewFromInt(100)},
+		Net:    decimal.NewFromInt(100),
+	}
+	data := []model.Item{{ID: 1, Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test", Currency: "USD", CreatedAt: time.Now(), Aggregated: aggregated}}
+	groupParams := params
+	groupParams.GroupBy = []string{"category"}
+	groups := []model.GroupedAggregated{{Group: map[string]string{"category": "test"}, Aggregated: aggregated}}
+	storage.On("StreamAggregated", ctx, params).Return(data, nil)
+	storage.On("GetGroupedAggregated", ctx, groupParams).Return(groups, nil)
+	var buf bytes.Buffer
+	err := s.XLSXAggregated(ctx, &buf, params)
+	assert.NoError(t, err)
+	storage.AssertExpectations(t)
+
+	file, err := excelize.OpenReader(&buf)
+	assert.NoError(t, err)
+	defer file.Close()
+	assert.Equal(t, []string{"Items", "Totals", "test"}, file.GetSheetList())
+
+	rows, err := file.GetRows("Totals")
+	assert.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, []string{"all", "USD", "1", "100.00", "100.00", "100.00", "100.00",
+		"1", "100.00", "100.00", "100.00", "100.00",
+		"0", "0.00", "0.00", "0.00", "0.00",
+		"100.00"}, rows[1])
+	assert.Equal(t, "test", rows[2][0])
+}
+
+func TestXLSXAggregatedStorageError(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	params := dto.AnalyticsParams{}
+	storage.On("StreamAggregated", ctx, params).Return([]model.Item{}, assert.AnError)
+	var buf bytes.Buffer
+	err := s.XLSXAggregated(ctx, &buf, params)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Zero(t, buf.Len())
+	storage.AssertExpectations(t)
+}
+
+func TestGetStatement(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	before := &model.Aggregated{Net: decimal.NewFromInt(1000)}
+	month := &model.Aggregated{
+		Count:   2,
+		Income:  model.TypeStats{Sum: decimal.NewFromInt(500), Count: 1},
+		Expense: model.TypeStats{Sum: decimal.RequireFromString("149.9"), Count: 1},
+		Net:     decimal.RequireFromString("350.1"),
+	}
+	groups := []model.GroupedAggregated{{Group: map[string]string{"category": "test"}, Aggregated: *month}}
+	storage.On("GetSummary", ctx, dto.AnalyticsParams{LedgerID: testLedgerID, To: "2024-01-31", Currency: "RUB"}).Return(before, nil)
+	storage.On("GetSummary", ctx, dto.AnalyticsParams{LedgerID: testLedgerID, From: "2024-02-01", To: "2024-02-29", Currency: "RUB"}).Return(month, nil)
+	storage.On("GetGroupedAggregated", ctx, dto.AnalyticsParams{LedgerID: testLedgerID, From: "2024-02-01", To: "2024-02-29", GroupBy: []string{"category"}, Currency: "RUB"}).Return(groups, nil)
+
+	statement, err := s.GetStatement(ctx, dto.StatementParams{LedgerID: testLedgerID, Month: "2024-02", Currency: "RUB"})
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-02-01", statement.From)
+	assert.Equal(t, "2024-02-29", statement.To)
+	assert.Equal(t, "1000", statement.Opening.String())
+	assert.Equal(t, "1350.1", statement.Closing.String())
+	assert.Equal(t, groups, statement.Categories)
+	storage.AssertExpectations(t)
+}
+
+func TestStatementPDF(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	stats := model.TypeStats{Sum: decimal.NewFromInt(1234567), Average: decimal.RequireFromString("1234.5"), Count: 3,
+		Median: decimal.NewFromInt(1000), Percentile_90: decimal.NewFromInt(2000)}
+	month := &model.Aggregated{Count: 6, Income: stats, Expense: stats}
+	// Enough categories for tables to span several pages.
+	var groups []model.GroupedAggregated
+	for i := range 60 {
+		groups = append(groups, model.GroupedAggregated{
+			Group:      map[string]string{"category": strings.Repeat("Категория ", i%8+1)},
+			Aggregated: model.Aggregated{Income: stats, Expense: stats},
+		})
+	}
+	storage.On("GetSummary", ctx, dto.AnalyticsParams{To: "2023-12-31"}).Return(&model.Aggregated{}, nil)
+	storage.On("GetSummary", ctx, dto.AnalyticsParams{From: "2024-01-01", To: "2024-01-31"}).Return(month, nil)
+	storage.On("GetGroupedAggregated", ctx, dto.AnalyticsParams{From: "2024-01-01", To: "2024-01-31", GroupBy: []string{"category"}}).Return(groups, nil)
+
+	var buf bytes.Buffer
+	err := s.StatementPDF(ctx, &buf, dto.StatementParams{Month: "2024-01"})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(buf.String(), "%PDF-"))
+	assert.Regexp(t, `/Count [2-9]\b`, buf.String())
+	storage.AssertExpectations(t)
+}
+
+func TestStatementPDFStorageError(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	storage.On("GetSummary", ctx, dto.AnalyticsParams{To: "2023-12-31"}).Return(&model.Aggregated{}, assert.AnError)
+
+	var buf bytes.Buffer
+	err := s.StatementPDF(ctx, &buf, dto.StatementParams{Month: "2024-01"})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Zero(t, buf.Len())
 	storage.AssertExpectations(t)
 }
 
+func TestFormatStatementAmount(t *testing.T) {
+	assert.Equal(t, "1 234 567,50", formatStatementAmount(decimal.RequireFromString("1234567.5"), 2))
+	assert.Equal(t, "-999,00", formatStatementAmount(decimal.NewFromInt(-999), 2))
+	assert.Equal(t, "-1 000", formatStatementAmount(decimal.NewFromInt(-1000), 0))
+	assert.Equal(t, "0,000", formatStatementAmount(decimal.Zero, 3))
+}
+
+func TestExportItems(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	params := dto.GetItemsParams{Category: "test"}
+	createdAt := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	data := []model.Item{
+		{ID: 1, Type: "доход", Amount: decimal.RequireFromString("100.5"), Date: "2023-01-01", Category: "test", Currency: "RUB", Version: 1, CreatedAt: createdAt},
+		{ID: 2, Type: "расход", Amount: decimal.NewFromInt(20), Date: "2023-01-02", Category: "test", Currency: "RUB", Version: 3, CreatedAt: createdAt},
+	}
+	storage.On("StreamItems", ctx, params).Return(data, nil)
+
+	var buf bytes.Buffer
+	err := s.ExportItems(ctx, &buf, "ndjson", params, dto.ExportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":1,"type":"доход","amount":"100.5","date":"2023-01-01","category":"test","currency":"RUB","version":1,"created_at":"2023-01-01T12:00:00Z"}
+{"id":2,"type":"расход","amount":"20","date":"2023-01-02","category":"test","currency":"RUB","version":3,"created_at":"2023-01-01T12:00:00Z"}
+`, buf.String())
+	storage.AssertExpectations(t)
+
+	err = s.ExportItems(ctx, &buf, "pdf", params, dto.ExportOptions{})
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestRegisterExporter(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	assert.Equal(t, []string{"csv", "ndjson", "xlsx"}, formatNames(s.Exporters()))
+
+	s.RegisterExporter(Exporter{
+		ExportFormat: model.ExportFormat{Name: "txt", ContentType: "text/plain", Extension: ".txt"},
+		Items: func(s *Service, ctx context.Context, w io.Writer, params dto.GetItemsParams, options dto.ExportOptions) error {
+			_, err := io.WriteString(w, "items")
+			return err
+		},
+		Aggregated: func(s *Service, ctx context.Context, w io.Writer, params dto.AnalyticsParams, options dto.ExportOptions) error {
+			_, err := io.WriteString(w, "aggregated")
+			return err
+		},
+	})
+	s.RegisterExporter(Exporter{
+		ExportFormat: model.ExportFormat{Name: "csv", ContentType: "text/csv", Extension: ".csv"},
+		Items:        (*Service).CSVAllItems,
+		Aggregated:   (*Service).CSVAggregated,
+	})
+	assert.Equal(t, []string{"csv", "ndjson", "xlsx", "txt"}, formatNames(s.Exporters()))
+	assert.Equal(t, "text/csv", s.Exporters()[0].ContentType)
+
+	var buf bytes.Buffer
+	err := s.ExportAggregated(ctx, &buf, "txt", dto.AnalyticsParams{}, dto.ExportOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "aggregated", buf.String())
+}
+
+func formatNames(formats []model.ExportFormat) []string {
+	names := make([]string, len(formats))
+	for i, format := range formats {
+		names[i] = format.Name
+	}
+	return names
+}
+
+func TestExecuteBatch(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	item := dto.CreateItem{Type: "доход", Amount: decimal.NewFromInt(100), Date: "2023-01-01", Category: "test"}
+	operations := []dto.BatchOperation{
+		{Op: "create", Item: &item},
+		{Op: "delete", ID: 2, Version: 1},
+	}
+	withCurrency := item
+	withCurrency.Currency = DefaultCurrency
+	expected := []model.BatchResult{
+		{Index: 0, Op: "create", ID: 3, Status: model.BatchCreated},
+		{Index: 1, Op: "delete", ID: 2, Status: model.BatchDeleted},
+	}
+	storage.On("ExecuteBatch", ctx, mock.Anything, testLedgerID, []dto.BatchOperation{{Op: "create", Item: &withCurrency}, operations[1]}).Return(expected, nil)
+
+	results, err := s.ExecuteBatch(ctx, testActor, testLedgerID, operations)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, results)
+	assert.Empty(t, item.Currency)
+	storage.AssertExpectations(t)
+
+	empty := append(operations, dto.BatchOperation{Op: "update", ID: 3, Version: 1, Changes: &dto.UpdateItem{}})
+	_, err = s.ExecuteBatch(ctx, testActor, testLedgerID, empty)
+	var batchErr *repository.BatchError
+	if assert.ErrorAs(t, err, &batchErr) {
+		assert.Equal(t, 2, batchErr.Index)
+		assert.ErrorIs(t, batchErr.Err, ErrEmptyUpdate)
+	}
+	storage.AssertNumberOfCalls(t, "ExecuteBatch", 1)
+}
+
+func TestImportItems(t *testing.T) {
+	ctx := context.Background()
+	valid := []dto.CreateItem{
+		{Type: "доход", Amount: decimal.RequireFromString("1000.50"), Date: "2023-01-01", Category: "Зарплата", Currency: "RUB"},
+		{Type: "расход", Amount: decimal.NewFromInt(20), Date: "2023-01-02", Category: "Еда", Currency: "USD"},
+	}
+
+	t.Run("export layout", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		file := "\ufeffid,type,amount,date,category,currency,created_at\n" +
+			"1,доход,\"1000,50\",2023-01-01,Зарплата,,2023-01-01 00:00:00 +0000 UTC\n" +
+			"2,расход,20,2023-01-02,Еда,usd,2023-01-02 00:00:00 +0000 UTC\n"
+		storage.On("CreateItems", ctx, mock.Anything, testLedgerID, valid).Return(make([]model.Item, 2), nil)
+
+		report, err := s.ImportItems(ctx, testActor, testLedgerID, strings.NewReader(file), dto.ImportOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, &model.ImportReport{Total: 2, Valid: 2, Imported: 2, Errors: []model.ImportRowError{}}, report)
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("russian export", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		file := "\ufeffID;Тип;Сумма;Дата;Категория;Валюта;Создано\n" +
+			"1;доход;1000,50;01.01.2023;Зарплата;RUB;01.01.2023 00:00:00\n" +
+			"2;расход;20;02.01.2023;Еда;USD;02.01.2023 00:00:00\n"
+		storage.On("CreateItems", ctx, mock.Anything, testLedgerID, valid).Return(make([]model.Item, 2), nil)
+
+		report, err := s.ImportItems(ctx, testActor, testLedgerID, strings.NewReader(file), dto.ImportOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, &model.ImportReport{Total: 2, Valid: 2, Imported: 2, Errors: []model.ImportRowError{}}, report)
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("given delimiter", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		// Detection would take comma of amount for delimiter.
+		file := "type\tamount\tdate\tcategory\tcurrency\n" +
+			"доход\t1000,50\t2023-01-01\tЗарплата,бонус\tRUB\n"
+		expected := []dto.CreateItem{valid[0]}
+		expected[0].Category = "Зарплата,бонус"
+		storage.On("CreateItems", ctx, mock.Anything, testLedgerID, expected).Return(make([]model.Item, 1), nil)
+
+		report, err := s.ImportItems(ctx, testActor, testLedgerID, strings.NewReader(file), dto.ImportOptions{Delimiter: '\t'})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Imported)
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("column mapping", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		file := "Дата,Тип,Сумма,Категория,Валюта\n" +
+			"2023-01-01,доход,1000.50,Зарплата,RUB\n" +
+			"2023-01-02,расход,20,Еда,USD\n"
+		options := dto.ImportOptions{Columns: map[string]string{
+			"type": "Тип", "amount": "Сумма", "date": "Дата", "category": "Категория", "currency": "Валюта",
+		}}
+		storage.On("CreateItems", ctx, mock.Anything, testLedgerID, valid).Return(make([]model.Item, 2), nil)
+
+		report, err := s.ImportItems(ctx, testActor, testLedgerID, strings.NewReader(file), options)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Imported)
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("invalid rows", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		file := "type,amount,date,category\n" +
+			"доход,100,2023-01-01,Зарплата\n" +
+			"подарок,100,2023-01-01,Зарплата\n" +
+			"расход,abc,2023-01-01,Еда\n" +
+			"расход,10.001,2023-01-01,Еда\n"
+
+		report, err := s.ImportItems(ctx, testActor, testLedgerID, strings.NewReader(file), dto.ImportOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, report.Total)
+		assert.Equal(t, 1, report.Valid)
+		assert.Equal(t, 0, report.Imported)
+		assert.Len(t, report.Errors, 3)
+		assert.Equal(t, []int{3, 4, 5}, []int{report.Errors[0].Line, report.Errors[1].Line, report.Errors[2].Line})
+		assert.Equal(t, "type must be one of: доход, расход", report.Errors[0].Error)
+		assert.Equal(t, "amount has more decimal places than its currency allows", report.Errors[2].Error)
+		storage.AssertNotCalled(t, "CreateItems")
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		file := "type,amount,date,category\nдоход,100,2023-01-01,Зарплата\n"
+
+		report, err := s.ImportItems(ctx, testActor, testLedgerID, strings.NewReader(file), dto.ImportOptions{DryRun: true})
+		assert.NoError(t, err)
+		assert.Equal(t, &model.ImportReport{Total: 1, Valid: 1, DryRun: true, Errors: []model.ImportRowError{}}, report)
+		storage.AssertNotCalled(t, "CreateItems")
+	})
+
+	t.Run("missing column", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		file := "type,amount,category\nдоход,100,Зарплата\n"
+
+		_, err := s.ImportItems(ctx, testActor, testLedgerID, strings.NewReader(file), dto.ImportOptions{})
+		assert.ErrorIs(t, err, ErrInvalidImport)
+		storage.AssertNotCalled(t, "CreateItems")
+	})
+
+	t.Run("categorised by rules", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		file := "type,amount,date,description,counterparty\n" +
+			"расход,350,2023-01-01,Оплата покупки,ООО Пятёрочка\n" +
+			"расход,120,2023-01-02,Перевод,Иван\n" +
+			"доход,5000,2023-01-03,Зарплата за январь,ООО Ромашка\n"
+
+		rules := []model.Rule{
+			{ID: 2, Priority: 10, Category: "Продукты", Counterparty: "пятёрочка", Type: "расход"},
+			{ID: 1, Category: "Зарплата", Description: `^зарплата`, Regex: true},
+		}
+		storage.On("GetRules", ctx, testLedgerID).Return(rules, nil).Once()
+
+		report, err := s.ImportItems(ctx, testActor, testLedgerID, strings.NewReader(file), dto.ImportOptions{DryRun: true})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Valid)
+		assert.Equal(t, []model.ImportRowError{{Line: 3, Error: ErrUncategorised.Error()}}, report.Errors)
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("ledger without rules", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		file := "type,amount,date\n" +
+			"расход,350,2023-01-01\n" +
+			"расход,120,2023-01-02\n"
+		storage.On("GetRules", ctx, testLedgerID).Return([]model.Rule(nil), nil)
+
+		report, err := s.ImportItems(ctx, testActor, testLedgerID, strings.NewReader(file), dto.ImportOptions{})
+		assert.NoError(t, err)
+		assert.Len(t, report.Errors, 2)
+		storage.AssertNumberOfCalls(t, "GetRules", 1)
+	})
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestRegister(t *testing.T) {
+	credentials := dto.Credentials{Login: "admin", Password: "password123"}
+	created := &model.User{ID: 1, Login: "admin"}
+	matchesCredentials := func(registeredBy int) any {
+		return mock.MatchedBy(func(user dto.CreateUser) bool {
+			return user.Login == "admin" && user.RegisteredBy == registeredBy &&
+				bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("password123")) == nil
+		})
+	}
+
+	t.Run("first user", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		storage.On("CreateUser", mock.Anything, matchesCredentials(0)).Return(created, nil)
+
+		user, err := s.Register(context.Background(), credentials, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, created, user)
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		storage.On("CreateUser", mock.Anything, matchesCredentials(0)).Return((*model.User)(nil), repository.ErrRegistrationClosed)
+
+		_, err := s.Register(context.Background(), credentials, nil)
+		assert.ErrorIs(t, err, repository.ErrRegistrationClosed)
+	})
+
+	t.Run("by existing user", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		storage.On("CreateUser", mock.Anything, matchesCredentials(7)).Return(created, nil)
+
+		_, err := s.Register(context.Background(), credentials, &model.User{ID: 7, Login: "owner"})
+		assert.NoError(t, err)
+		storage.AssertExpectations(t)
+	})
+}
+
+func TestLoginAndAuthenticate(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	stored := &model.User{ID: 3, Login: "admin", PasswordHash: string(hash)}
+
+	storage := &mockStorage{}
+	storage.On("GetUserByLogin", mock.Anything, "admin").Return(stored, nil)
+	storage.On("GetUserByLogin", mock.Anything, "nobody").Return((*model.User)(nil), repository.ErrNoSuchUser)
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+
+	token, err := s.Login(context.Background(), dto.Credentials{Login: "admin", Password: "password123"})
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, time.Minute)
+
+	user, err := s.Authenticate(token.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.User{ID: 3, Login: "admin"}, user)
+
+	_, err = s.Login(context.Background(), dto.Credentials{Login: "admin", Password: "wrong password"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = s.Login(context.Background(), dto.Credentials{Login: "nobody", Password: "password123"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	other := New(storage, testCursorSecret, []byte("other secret"), time.Hour)
+	_, err = other.Authenticate(token.Token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	expired := New(storage, testCursorSecret, testTokenSecret, -time.Minute)
+	expiredToken, err := expired.Login(context.Background(), dto.Credentials{Login: "admin", Password: "password123"})
+	assert.NoError(t, err)
+	_, err = s.Authenticate(expiredToken.Token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = s.Authenticate("not a token")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+
+	var hash string
+	storage.On("CreateAPIKey", ctx, mock.Anything, mock.Anything).Return(&model.APIKey{ID: 1, UserID: 3, Name: "bank sync"}, nil).
+		Run(func(args mock.Arguments) {
+			key := args.Get(1).(model.APIKey)
+			assert.Equal(t, 3, key.UserID)
+			assert.Equal(t, []string{model.ScopeItemsWrite}, key.Scopes)
+			hash = args.String(2)
+		})
+
+	created, err := s.CreateAPIKey(ctx, 3, dto.CreateAPIKey{Name: "bank sync", Scopes: []string{model.ScopeItemsWrite}})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Key, model.APIKeyPrefix))
+	assert.Len(t, hash, 64)
+	assert.NotContains(t, hash, created.Key)
+
+	user := &model.User{ID: 3, Login: "admin"}
+	storage.On("UseAPIKey", ctx, hash).Return(&created.APIKey, user, nil)
+	storage.On("UseAPIKey", ctx, mock.Anything).Return((*model.APIKey)(nil), (*model.User)(nil), repository.ErrNoSuchAPIKey)
+
+	authenticated, key, err := s.AuthenticateAPIKey(ctx, created.Key)
+	assert.NoError(t, err)
+	assert.Equal(t, user, authenticated)
+	assert.Equal(t, 1, key.ID)
+
+	_, _, err = s.AuthenticateAPIKey(ctx, model.APIKeyPrefix+"revoked")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, _, err = s.AuthenticateAPIKey(ctx, "not a key")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestGetItemHistory(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+
+	history := []model.AuditEntry{
+		{ID: 1, ItemID: 3, Operation: model.AuditCreate, After: []byte(`{"id": 3}`)},
+		{ID: 2, ItemID: 3, Operation: model.AuditDelete, Before: []byte(`{"id": 3}`)},
+	}
+	storage.On("GetItemHistory", ctx, testLedgerID, 3).Return(history, nil)
+
+	result, err := s.GetItemHistory(ctx, testLedgerID, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, history, result)
+}
+
+func TestPurgeTrash(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+
+	retention := 30 * 24 * time.Hour
+	storage.On("PurgeTrash", ctx, mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before)-retention < time.Minute && time.Since(before) >= retention
+	})).Return(int64(2), nil)
+
+	purged, err := s.PurgeTrash(ctx, retention)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	storage.AssertExpectations(t)
+}
+
+func TestCategories(t *testing.T) {
+	storage := &mockStorage{}
+	s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+	ctx := context.Background()
+	actor := model.Actor{UserID: 1}
+
+	trimmed := dto.CreateCategory{Name: "Кафе", Type: "расход", Aliases: []string{"Cafe", "Кофейни"}}
+	created := &model.Category{ID: 2, Name: "Кафе", Type: "расход", Aliases: []string{"Cafe", "Кофейни"}}
+	storage.On("CreateCategory", ctx, testLedgerID, trimmed).Return(created, nil)
+
+	category, err := s.CreateCategory(ctx, testLedgerID, dto.CreateCategory{Name: " Кафе ", Type: "расход", Aliases: []string{"Cafe ", "\tКофейни"}})
+	assert.NoError(t, err)
+	assert.Equal(t, created, category)
+
+	storage.On("UpdateCategory", ctx, actor, testLedgerID, 2, dto.UpdateCategory(trimmed)).Return(created, nil)
+
+	category, err = s.UpdateCategory(ctx, actor, testLedgerID, 2, dto.UpdateCategory{Name: "Кафе  ", Type: "расход", Aliases: []string{" Cafe", "Кофейни"}})
+	assert.NoError(t, err)
+	assert.Equal(t, created, category)
+
+	storage.On("DeleteCategory", ctx, actor, testLedgerID, 2, 1).Return(nil)
+
+	assert.NoError(t, s.DeleteCategory(ctx, actor, testLedgerID, 2, 1))
+	assert.ErrorIs(t, s.DeleteCategory(ctx, actor, testLedgerID, 2, 2), ErrInvalidReplacement)
+	storage.AssertNumberOfCalls(t, "DeleteCategory", 1)
+}
+
+func TestCategorisationRules(t *testing.T) {
+	ctx := context.Background()
+	amount := func(value int64) *decimal.Decimal {
+		d := decimal.NewFromInt(value)
+		return &d
+	}
+	rules := []model.Rule{
+		{ID: 3, Priority: 10, Category: "Кафе", Description: `кофе|coffee`, Regex: true, AmountMax: amount(1000)},
+		{ID: 1, Priority: 10, Category: "Продукты", Counterparty: "Пятёрочка", Type: "расход"},
+		{ID: 2, Category: "Покупки", Type: "расход", AmountMin: amount(100)},
+	}
+
+	t.Run("test", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			sample dto.RuleSample
+			ruleID int
+		}{
+			{"regex description", dto.RuleSample{Type: "расход", Amount: decimal.NewFromInt(250), Description: "Starbucks Coffee"}, 3},
+			{"amount above bound", dto.RuleSample{Type: "расход", Amount: decimal.NewFromInt(1500), Description: "Кофемашина"}, 2},
+			{"counterparty case aside", dto.RuleSample{Type: "расход", Amount: decimal.NewFromInt(50), Counterparty: "ООО ПЯТЁРОЧКА"}, 1},
+			{"no match", dto.RuleSample{Type: "доход", Amount: decimal.NewFromInt(50), Counterparty: "Пятёрочка"}, 0},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				storage := &mockStorage{}
+				s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+				storage.On("GetRules", ctx, testLedgerID).Return(rules, nil)
+
+				match, err := s.TestRules(ctx, testLedgerID, tt.sample)
+				assert.NoError(t, err)
+				if tt.ruleID == 0 {
+					assert.Nil(t, match.Rule)
+					return
+				}
+				assert.Equal(t, tt.ruleID, match.Rule.ID)
+				assert.Equal(t, match.Rule.Category, match.Category)
+			})
+		}
+	})
+
+	t.Run("create item without category", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		storage.On("GetRules", ctx, testLedgerID).Return(rules, nil)
+
+		item := dto.CreateItem{Type: "расход", Amount: decimal.NewFromInt(300), Date: "2023-01-01", Currency: "RUB", Counterparty: "Пятёрочка"}
+		categorised := item
+		categorised.Category = "Продукты"
+		created := &model.Item{ID: 1, Category: "Продукты"}
+		storage.On("CreateItem", ctx, testActor, testLedgerID, categorised).Return(created, nil)
+
+		result, err := s.CreateItem(ctx, testActor, testLedgerID, item)
+		assert.NoError(t, err)
+		assert.Equal(t, created, result)
+
+		_, err = s.CreateItem(ctx, testActor, testLedgerID, dto.CreateItem{Type: "доход", Amount: decimal.NewFromInt(10), Date: "2023-01-01"})
+		assert.ErrorIs(t, err, ErrUncategorised)
+		storage.AssertNumberOfCalls(t, "CreateItem", 1)
+	})
+
+	t.Run("invalid rule", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+
+		_, err := s.CreateRule(ctx, testLedgerID, dto.CreateRule{Category: "Кафе"})
+		var typed *model.Error
+		if assert.ErrorAs(t, err, &typed) {
+			assert.Equal(t, "description", typed.Fields[0].Field)
+		}
+
+		_, err = s.CreateRule(ctx, testLedgerID, dto.CreateRule{Category: "Кафе", Description: "(кофе", Regex: true, AmountMin: amount(10), AmountMax: amount(5)})
+		if assert.ErrorAs(t, err, &typed) {
+			assert.Equal(t, []string{"description", "amount_max"}, []string{typed.Fields[0].Field, typed.Fields[1].Field})
+		}
+		storage.AssertNotCalled(t, "CreateRule", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		storage := &mockStorage{}
+		s := New(storage, testCursorSecret, testTokenSecret, time.Hour)
+		storage.On("GetRules", ctx, testLedgerID).Return(rules, nil)
+
+		items := []model.Item{
+			{ID: 1, Type: "расход", Amount: decimal.NewFromInt(200), Category: "Прочее", Description: "Кофе с собой", Version: 2},
+			{ID: 2, Type: "расход", Amount: decimal.NewFromInt(90), Category: "продукты", Counterparty: "Пятёрочка", Version: 1},
+			{ID: 3, Type: "доход", Amount: decimal.NewFromInt(5000), Category: "Зарплата", Version: 1},
+		}
+		params := dto.ApplyRulesParams{LedgerID: testLedgerID, DateFrom: "2023-01-01"}
+		storage.On("StreamItems", ctx, dto.GetItemsParams{LedgerID: testLedgerID, DateFrom: "2023-01-01"}).Return(items, nil)
+
+		category := "Кафе"
+		operations := []dto.BatchOperation{{Op: "update", ID: 1, Version: 2, Changes: &dto.UpdateItem{Category: &category}}}
+		storage.On("ExecuteBatch", ctx, testActor, testLedgerID, operations).Return([]model.BatchResult{}, nil).Once()
+
+		report, err := s.ApplyRules(ctx, testActor, params)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.RulesReport{
+			Checked: 3,
+			Updated: 1,
+			Changes: []model.RuleChange{{ItemID: 1, RuleID: 3, From: "Прочее", To: "Кафе"}},
+		}, report)
+
+		params.DryRun = true
+		report, err = s.ApplyRules(ctx, testActor, params)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, report.Updated)
+		assert.Len(t, report.Changes, 1)
+		storage.AssertNumberOfCalls(t, "ExecuteBatch", 1)
+	})
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/go-pdf/fpdf"
+	"github.com/shopspring/decimal"
+)
+
+// StatementMonthLayout is the format of statement month.
+const StatementMonthLayout = "2006-01"
+
+// Fonts are embedded so that statements with Cyrillic text are rendered
+// without relying on fonts installed on the host.
+var (
+	//go:embed fonts/DejaVuSans.ttf
+	regularFont []byte
+	//go:embed fonts/DejaVuSans-Bold.ttf
+	boldFont []byte
+)
+
+var monthNames = [...]string{
+	"январь", "февраль", "март", "апрель", "май", "июнь",
+	"июль", "август", "сентябрь", "октябрь", "ноябрь", "декабрь",
+}
+
+const (
+	statementFont   = "DejaVu"
+	statementMargin = 15.0
+	rowHeight       = 7.0
+)
+
+// statementColumns are widths of category table columns in millimetres,
+// together they fill A4 page between margins.
+var statementColumns = []float64{56, 16, 27, 27, 27, 27}
+
+// GetStatement collects figures of monthly statement: balances before and
+// after the month and statistics of the month overall and per category.
+func (s *Service) GetStatement(ctx context.Context, params dto.StatementParams) (*model.Statement, error) {
+	month, err := time.Parse(StatementMonthLayout, params.Month)
+	if err != nil {
+		return nil, fmt.Errorf("invalid month %q: %w", params.Month, err)
+	}
+
+	statement := &model.Statement{
+		From:     month.Format(time.DateOnly),
+		To:       month.AddDate(0, 1, -1).Format(time.DateOnly),
+		Currency: params.Currency,
+	}
+
+	before, err := s.storage.GetSummary(ctx, dto.AnalyticsParams{
+		LedgerID: params.LedgerID,
+		To:       month.AddDate(0, 0, -1).Format(time.DateOnly),
+		Currency: params.Currency,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get opening balance: %w", err)
+	}
+
+	monthParams := dto.AnalyticsParams{
+		LedgerID: params.LedgerID,
+		From:     statement.From,
+		To:       statement.To,
+		Currency: params.Currency,
+	}
+	aggregated, err := s.storage.GetSummary(ctx, monthParams)
+	if err != nil {
+		return nil, fmt.Errorf("could not get month statistics: %w", err)
+	}
+
+	monthParams.GroupBy = []string{"category"}
+	statement.Categories, err = s.storage.GetGroupedAggregated(ctx, monthParams)
+	if err != nil {
+		return nil, fmt.Errorf("could not get category statistics: %w", err)
+	}
+
+	statement.Opening = before.Net
+	statement.Aggregated = *aggregated
+	statement.Closing = statement.Opening.Add(aggregated.Net)
+
+	return statement, nil
+}
+
+// StatementPDF renders monthly statement as PDF document and writes it to w.
+func (s *Service) StatementPDF(ctx context.Context, w io.Writer, params dto.StatementParams) error {
+	statement, err := s.GetStatement(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	pdf := renderStatement(statement, time.Now())
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("could not write pdf: %w", err)
+	}
+
+	return nil
+}
+
+func renderStatement(statement *model.Statement, generatedAt time.Time) *fpdf.Fpdf {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(statementFont, "", regularFont)
+	pdf.AddUTF8FontFromBytes(statementFont, "B", boldFont)
+	pdf.SetMargins(statementMargin, statementMargin, statementMargin)
+	pdf.SetAutoPageBreak(true, statementMargin)
+	pdf.SetCreationDate(generatedAt)
+	pdf.AliasNbPages("")
+
+	from, _ := time.Parse(time.DateOnly, statement.From)
+	to, _ := time.Parse(time.DateOnly, statement.To)
+	title := fmt.Sprintf("Финансовая выписка за %s %d", monthNames[from.Month()-1], from.Year())
+	pdf.SetTitle(title, true)
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-statementMargin + 3)
+		pdf.SetFont(statementFont, "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(90, 5, "Сформировано "+generatedAt.Format("02.01.2006 15:04"), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("Стр. %d из {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont(statementFont, "B", 16)
+	pdf.CellFormat(0, 10, title, "", 1, "L", false, 0, "")
+
+	currency := statement.Currency
+	if currency == "" {
+		currency = "без пересчёта, суммы сложены как есть"
+	}
+	pdf.SetFont(statementFont, "", 10)
+	pdf.CellFormat(0, 6, fmt.Sprintf("Период: %s — %s", from.Format("02.01.2006"), to.Format("02.01.2006")), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Валюта: "+currency, "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	exponent := int32(2)
+	if statement.Currency != "" {
+		exponent = model.CurrencyExponent(statement.Currency)
+	}
+	aggregated := statement.Aggregated
+
+	statementHeading(pdf, "Остатки")
+	balances := [][2]string{
+		{"Входящий остаток", formatStatementAmount(statement.Opening, exponent)},
+		{"Доходы", formatStatementAmount(aggregated.Income.Sum, exponent)},
+		{"Расходы", formatStatementAmount(aggregated.Expense.Sum, exponent)},
+		{"Исходящий остаток", formatStatementAmount(statement.Closing, exponent)},
+	}
+	for i, balance := range balances {
+		style := ""
+		if i == 0 || i == len(balances)-1 {
+			style = "B"
+		}
+		pdf.SetFont(statementFont, style, 10)
+		pdf.CellFormat(100, rowHeight, balance[0], "B", 0, "L", false, 0, "")
+		pdf.CellFormat(80, rowHeight, balance[1], "B", 1, "R", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	statementCategories(pdf, "Доходы по категориям", statement.Categories, aggregated.Income, exponent,
+		func(aggregated model.Aggregated) model.TypeStats { return aggregated.Income })
+	statementCategories(pdf, "Расходы по категориям", statement.Categories, aggregated.Expense, exponent,
+		func(aggregated model.Aggregated) model.TypeStats { return aggregated.Expense })
+
+	return pdf
+}
+
+func statementHeading(pdf *fpdf.Fpdf, heading string) {
+	pdf.SetFont(statementFont, "B", 12)
+	pdf.CellFormat(0, 8, heading, "", 1, "L", false, 0, "")
+}
+
+// statementCategories draws table of categories with items of one type,
+// stats picks statistics of that type. Table header is repeated on every
+// page the table spans.
+func statementCategories(pdf *fpdf.Fpdf, heading string, categories []model.GroupedAggregated, total model.TypeStats,
+	exponent int32, stats func(model.Aggregated) model.TypeStats) {
+	_, pageHeight := pdf.GetPageSize()
+	fits := func(rows float64) bool {
+		return pdf.GetY()+rows*rowHeight <= pageHeight-statementMargin
+	}
+
+	header := func() {
+		pdf.SetFont(statementFont, "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		titles := []string{"Категория", "Кол-во", "Сумма", "Среднее", "Медиана", "90-й перц."}
+		for i, title := range titles {
+			align := "R"
+			if i == 0 {
+				align = "L"
+			}
+			pdf.CellFormat(statementColumns[i], rowHeight, title, "1", 0, align, true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont(statementFont, "", 9)
+	}
+
+	row := func(name string, stats model.TypeStats) {
+		values := []string{
+			fitText(pdf, name, statementColumns[0]-2),
+			fmt.Sprintf("%d", stats.Count),
+			formatStatementAmount(stats.Sum, exponent),
+			formatStatementAmount(stats.Average, statsExponent(exponent)),
+			formatStatementAmount(stats.Median, statsExponent(exponent)),
+			formatStatementAmount(stats.Percentile_90, statsExponent(exponent)),
+		}
+		for i, value := range values {
+			align := "R"
+			if i == 0 {
+				align = "L"
+			}
+			pdf.CellFormat(statementColumns[i], rowHeight, value, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	// Heading is kept on the same page with the table header and its first
+	// row.
+	if !fits(3) {
+		pdf.AddPage()
+	}
+	statementHeading(pdf, heading)
+
+	if total.Count == 0 {
+		pdf.SetFont(statementFont, "", 10)
+		pdf.CellFormat(0, rowHeight, "Нет операций", "", 1, "L", false, 0, "")
+		pdf.Ln(6)
+		return
+	}
+
+	header()
+	for _, category := range categories {
+		categoryStats := stats(category.Aggregated)
+		if categoryStats.Count == 0 {
+			continue
+		}
+		if !fits(1) {
+			pdf.AddPage()
+			header()
+		}
+		row(category.Group["category"], categoryStats)
+	}
+
+	if !fits(1) {
+		pdf.AddPage()
+		header()
+	}
+	pdf.SetFont(statementFont, "B", 9)
+	row("Итого", total)
+	pdf.Ln(6)
+}
+
+// fitText shortens text with ellipsis until it fits width.
+func fitText(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+
+	return string(runes) + "…"
+}
+
+// formatStatementAmount renders amount the Russian way: digits grouped by
+// spaces and comma as decimal separator, e.g. "-1 234,50".
+func formatStatementAmount(amount decimal.Decimal, exponent int32) string {
+	value := amount.StringFixed(exponent)
+
+	sign := ""
+	if strings.HasPrefix(value, "-") {
+		sign, value = "-", value[1:]
+	}
+
+	integer, fraction, hasFraction := strings.Cut(value, ".")
+	var grouped strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteRune(' ')
+		}
+		grouped.WriteRune(digit)
+	}
+
+	if hasFraction {
+		return sign + grouped.String() + "," + fraction
+	}
+
+	return sign + grouped.String()
+}
@@ -4,8 +4,19 @@ import (
 	"context"
 
 	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
 )
 
-func (s *Service) UpdateItem(ctx context.Context, id int, item dto.UpdateItem) error {
-	return s.storage.UpdateItem(ctx, id, item)
+// ErrEmptyUpdate is returned for updates changing no field, which would
+// only bump version of item and record nothing in audit log.
+var ErrEmptyUpdate = model.NewValidationError("empty_update", "update must change at least one field", nil)
+
+// UpdateItem changes item provided it still has version, zero version
+// changes item whatever its version is.
+func (s *Service) UpdateItem(ctx context.Context, actor model.Actor, ledgerID, id, version int, item dto.UpdateItem) error {
+	if item == (dto.UpdateItem{}) {
+		return ErrEmptyUpdate
+	}
+
+	return s.storage.UpdateItem(ctx, actor, ledgerID, id, version, item)
 }
@@ -0,0 +1,482 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	itemsSheet  = "Items"
+	totalsSheet = "Totals"
+
+	// maxSheetName is the longest sheet name Excel accepts.
+	maxSheetName = 31
+)
+
+var (
+	itemsHeader  = []string{"id", "type", "amount", "date", "category", "currency", "created_at"}
+	totalsHeader = []string{"group", "currency", "count", "sum", "average", "median", "percentile_90",
+		"income_count", "income_sum", "income_average", "income_median", "income_percentile_90",
+		"expense_count", "expense_sum", "expense_average", "expense_median", "expense_percentile_90",
+		"net",
+	}
+	itemsColumnWidths = []float64{8, 10, 14, 12, 24, 10, 20}
+
+	// sheetNameReplacer replaces characters Excel does not allow in sheet
+	// names.
+	sheetNameReplacer = strings.NewReplacer(":", "_", "\\", "_", "/", "_", "?", "_", "*", "_", "[", "_", "]", "_")
+)
+
+// XLSXAggregated writes items within dates range to w as XLSX workbook: all
+// items on the first sheet, statistics of the whole range and of every
+// category on the totals sheet and items of every category on a sheet of its
+// own.
+func (s *Service) XLSXAggregated(ctx context.Context, w io.Writer, params dto.AnalyticsParams) error {
+	book, err := newWorkbook()
+	if err != nil {
+		return fmt.Errorf("could not create xlsx: %w", err)
+	}
+	defer book.file.Close()
+
+	var aggregated model.Aggregated
+	err = s.storage.StreamAggregated(ctx, params, func(item model.Item) error {
+		aggregated = item.Aggregated
+		return book.writeItem(item)
+	})
+	if err != nil {
+		return fmt.Errorf("could not get aggregated data: %w", err)
+	}
+
+	groupParams := params
+	groupParams.GroupBy = []string{"category"}
+	groups, err := s.storage.GetGroupedAggregated(ctx, groupParams)
+	if err != nil {
+		return fmt.Errorf("could not get aggregated data: %w", err)
+	}
+
+	totals := []totalsRow{{group: "all", currency: params.Currency, aggregated: aggregated}}
+	for _, group := range groups {
+		totals = append(totals, totalsRow{group: group.Group["category"], currency: params.Currency, aggregated: group.Aggregated})
+	}
+
+	if err := book.writeTotals(totals); err != nil {
+		return fmt.Errorf("could not write xlsx: %w", err)
+	}
+
+	if err := book.write(w); err != nil {
+		return fmt.Errorf("could not write xlsx: %w", err)
+	}
+
+	return nil
+}
+
+// XLSXAllItems writes items matching params to w as XLSX workbook: all items
+// on the first sheet, statistics for every currency on the totals sheet and
+// items of every category on a sheet of its own. Statistics are computed
+// while items are streamed, so amounts are kept in memory until the end.
+func (s *Service) XLSXAllItems(ctx context.Context, w io.Writer, params dto.GetItemsParams) error {
+	book, err := newWorkbook()
+	if err != nil {
+		return fmt.Errorf("could not create xlsx: %w", err)
+	}
+	defer book.file.Close()
+
+	collector := newStatsCollector()
+	err = s.storage.StreamItems(ctx, params, func(item model.Item) error {
+		collector.add(item)
+		return book.writeItem(item)
+	})
+	if err != nil {
+		return fmt.Errorf("could not get filtered data: %w", err)
+	}
+
+	if err := book.writeTotals(collector.totals()); err != nil {
+		return fmt.Errorf("could not write xlsx: %w", err)
+	}
+
+	if err := book.write(w); err != nil {
+		return fmt.Errorf("could not write xlsx: %w", err)
+	}
+
+	return nil
+}
+
+// workbook writes items into XLSX file sheet by sheet with stream writers.
+// Category sheets are added as categories are met.
+type workbook struct {
+	file       *excelize.File
+	items      *sheet
+	categories map[string]*sheet
+	ordered    []*sheet
+	names      map[string]bool
+
+	headerStyle   int
+	dateStyle     int
+	dateTimeStyle int
+	amountStyles  map[int32]int
+}
+
+type sheet struct {
+	writer *excelize.StreamWriter
+	rows   int
+}
+
+type totalsRow struct {
+	group      string
+	currency   string
+	aggregated model.Aggregated
+}
+
+func newWorkbook() (*workbook, error) {
+	book := &workbook{
+		file:         excelize.NewFile(),
+		categories:   make(map[string]*sheet),
+		names:        make(map[string]bool),
+		amountStyles: make(map[int32]int),
+	}
+
+	var err error
+	if book.headerStyle, err = book.file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}}); err != nil {
+		book.file.Close()
+		return nil, err
+	}
+
+	dateFormat, dateTimeFormat := "yyyy-mm-dd", "yyyy-mm-dd hh:mm:ss"
+	if book.dateStyle, err = book.file.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat}); err != nil {
+		book.file.Close()
+		return nil, err
+	}
+	if book.dateTimeStyle, err = book.file.NewStyle(&excelize.Style{CustomNumFmt: &dateTimeFormat}); err != nil {
+		book.file.Close()
+		return nil, err
+	}
+
+	// Totals sheet is created empty right away to keep it second, it is
+	// written after all items are.
+	book.names[strings.ToLower(itemsSheet)] = true
+	book.names[strings.ToLower(totalsSheet)] = true
+	if err := book.file.SetSheetName("Sheet1", itemsSheet); err != nil {
+		book.file.Close()
+		return nil, err
+	}
+	if _, err := book.file.NewSheet(totalsSheet); err != nil {
+		book.file.Close()
+		return nil, err
+	}
+
+	if book.items, err = book.newSheet(itemsSheet, itemsHeader, itemsColumnWidths); err != nil {
+		book.file.Close()
+		return nil, err
+	}
+
+	return book, nil
+}
+
+// newSheet starts writing sheet with a frozen header row.
+func (b *workbook) newSheet(name string, header []string, widths []float64) (*sheet, error) {
+	writer, err := b.file.NewStreamWriter(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, width := range widths {
+		if err := writer.SetColWidth(i+1, i+1, width); err != nil {
+			return nil, err
+		}
+	}
+
+	err = writer.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &sheet{writer: writer}
+	values := make([]any, len(header))
+	for i, name := range header {
+		values[i] = excelize.Cell{StyleID: b.headerStyle, Value: name}
+	}
+	if err := s.append(values); err != nil {
+		return nil, err
+	}
+
+	b.ordered = append(b.ordered, s)
+	return s, nil
+}
+
+func (s *sheet) append(values []any) error {
+	s.rows++
+	cell, err := excelize.CoordinatesToCellName(1, s.rows)
+	if err != nil {
+		return err
+	}
+
+	return s.writer.SetRow(cell, values)
+}
+
+// writeItem appends item to the items sheet and to the sheet of its category.
+func (b *workbook) writeItem(item model.Item) error {
+	category, ok := b.categories[item.Category]
+	if !ok {
+		name := b.sheetName(item.Category)
+		if _, err := b.file.NewSheet(name); err != nil {
+			return fmt.Errorf("could not write xlsx: %w", err)
+		}
+
+		var err error
+		if category, err = b.newSheet(name, itemsHeader, itemsColumnWidths); err != nil {
+			return fmt.Errorf("could not write xlsx: %w", err)
+		}
+		b.categories[item.Category] = category
+	}
+
+	exponent := model.CurrencyExponent(item.Currency)
+	amountStyle, err := b.amountStyle(exponent)
+	if err != nil {
+		return fmt.Errorf("could not write xlsx: %w", err)
+	}
+
+	values := []any{
+		item.ID,
+		item.Type,
+		excelize.Cell{StyleID: amountStyle, Value: item.Amount.InexactFloat64()},
+		b.dateCell(item.Date),
+		item.Category,
+		item.Currency,
+		excelize.Cell{StyleID: b.dateTimeStyle, Value: item.CreatedAt},
+	}
+
+	if err := b.items.append(values); err != nil {
+		return fmt.Errorf("could not write xlsx: %w", err)
+	}
+	if err := category.append(values); err != nil {
+		return fmt.Errorf("could not write xlsx: %w", err)
+	}
+
+	return nil
+}
+
+// dateCell returns item date as date cell, or as text if it can not be
+// parsed.
+func (b *workbook) dateCell(value string) any {
+	if date, ok := parseItemDate(value); ok {
+		return excelize.Cell{StyleID: b.dateStyle, Value: date}
+	}
+
+	return value
+}
+
+// writeTotals fills the totals sheet with statistics, one row per group.
+func (b *workbook) writeTotals(rows []totalsRow) error {
+	totals, err := b.newSheet(totalsSheet, totalsHeader, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		exponent := int32(2)
+		if row.currency != "" {
+			exponent = model.CurrencyExponent(row.currency)
+		}
+
+		amountStyle, err := b.amountStyle(exponent)
+		if err != nil {
+			return err
+		}
+		statsStyle, err := b.amountStyle(statsExponent(exponent))
+		if err != nil {
+			return err
+		}
+
+		amount := func(value decimal.Decimal, style int) excelize.Cell {
+			return excelize.Cell{StyleID: style, Value: value.InexactFloat64()}
+		}
+		stats := func(stats model.TypeStats) []any {
+			return []any{
+				stats.Count,
+				amount(stats.Sum, amountStyle),
+				amount(stats.Average, statsStyle),
+				amount(stats.Median, statsStyle),
+				amount(stats.Percentile_90, statsStyle),
+			}
+		}
+
+		aggregated := row.aggregated
+		values := []any{row.group, row.currency}
+		values = append(values, stats(model.TypeStats{
+			Sum:           aggregated.Sum,
+			Average:       aggregated.Average,
+			Count:         aggregated.Count,
+			Median:        aggregated.Median,
+			Percentile_90: aggregated.Percentile_90,
+		})...)
+		values = append(values, stats(aggregated.Income)...)
+		values = append(values, stats(aggregated.Expense)...)
+		values = append(values, amount(aggregated.Net, amountStyle))
+
+		if err := totals.append(values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// amountStyle returns style showing numbers with exponent decimal places and
+// thousands separated.
+func (b *workbook) amountStyle(exponent int32) (int, error) {
+	if style, ok := b.amountStyles[exponent]; ok {
+		return style, nil
+	}
+
+	format := "#,##0"
+	if exponent > 0 {
+		format += "." + strings.Repeat("0", int(exponent))
+	}
+
+	style, err := b.file.NewStyle(&excelize.Style{CustomNumFmt: &format})
+	if err != nil {
+		return 0, err
+	}
+	b.amountStyles[exponent] = style
+
+	return style, nil
+}
+
+// sheetName turns category into sheet name Excel accepts: without forbidden
+// characters, at most 31 characters long and unique regardless of case.
+func (b *workbook) sheetName(category string) string {
+	name := strings.Trim(sheetNameReplacer.Replace(category), "' ")
+	if name == "" {
+		name = "_"
+	}
+	name = truncateRunes(name, maxSheetName)
+
+	unique := name
+	for i := 2; b.names[strings.ToLower(unique)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		unique = truncateRunes(name, maxSheetName-utf8.RuneCountInString(suffix)) + suffix
+	}
+	b.names[strings.ToLower(unique)] = true
+
+	return unique
+}
+
+func truncateRunes(value string, limit int) string {
+	if utf8.RuneCountInString(value) <= limit {
+		return value
+	}
+
+	return string([]rune(value)[:limit])
+}
+
+// write finishes all sheets and writes the workbook to w.
+func (b *workbook) write(w io.Writer) error {
+	for _, s := range b.ordered {
+		if err := s.writer.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return b.file.Write(w)
+}
+
+// statsCollector computes statistics of streamed items for every currency,
+// the same way analytics queries do.
+type statsCollector struct {
+	currencies []string
+	amounts    map[string]*currencyAmounts
+}
+
+type currencyAmounts struct {
+	signed  []decimal.Decimal
+	income  []decimal.Decimal
+	expense []decimal.Decimal
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{amounts: make(map[string]*currencyAmounts)}
+}
+
+func (c *statsCollector) add(item model.Item) {
+	amounts, ok := c.amounts[item.Currency]
+	if !ok {
+		amounts = &currencyAmounts{}
+		c.amounts[item.Currency] = amounts
+		c.currencies = append(c.currencies, item.Currency)
+	}
+
+	if item.Type == "расход" {
+		amounts.signed = append(amounts.signed, item.Amount.Neg())
+		amounts.expense = append(amounts.expense, item.Amount)
+		return
+	}
+
+	amounts.signed = append(amounts.signed, item.Amount)
+	amounts.income = append(amounts.income, item.Amount)
+}
+
+// totals returns statistics of every currency, currencies sorted by code.
+func (c *statsCollector) totals() []totalsRow {
+	currencies := slices.Clone(c.currencies)
+	slices.Sort(currencies)
+
+	rows := make([]totalsRow, 0, len(currencies))
+	for _, currency := range currencies {
+		amounts := c.amounts[currency]
+		all := typeStats(amounts.signed)
+		aggregated := model.Aggregated{
+			Sum:           all.Sum,
+			Average:       all.Average,
+			Count:         all.Count,
+			Median:        all.Median,
+			Percentile_90: all.Percentile_90,
+			Income:        typeStats(amounts.income),
+			Expense:       typeStats(amounts.expense),
+		}
+		aggregated.Net = aggregated.Income.Sum.Sub(aggregated.Expense.Sum)
+
+		rows = append(rows, totalsRow{group: "all", currency: currency, aggregated: aggregated})
+	}
+
+	return rows
+}
+
+func typeStats(amounts []decimal.Decimal) model.TypeStats {
+	if len(amounts) == 0 {
+		return model.TypeStats{}
+	}
+
+	sorted := slices.Clone(amounts)
+	slices.SortFunc(sorted, func(a, b decimal.Decimal) int { return a.Cmp(b) })
+
+	sum := decimal.Sum(sorted[0], sorted[1:]...)
+	return model.TypeStats{
+		Sum:           sum,
+		Average:       sum.Div(decimal.NewFromInt(int64(len(sorted)))),
+		Count:         len(sorted),
+		Median:        percentile(sorted, decimal.RequireFromString("0.5")),
+		Percentile_90: percentile(sorted, decimal.RequireFromString("0.9")),
+	}
+}
+
+// percentile interpolates linearly between sorted values, as
+// percentile_cont and numeric_percentile in the database do.
+func percentile(sorted []decimal.Decimal, fraction decimal.Decimal) decimal.Decimal {
+	position := fraction.Mul(decimal.NewFromInt(int64(len(sorted) - 1)))
+	lower := position.Floor()
+	index := int(lower.IntPart())
+	if index+1 >= len(sorted) {
+		return sorted[index]
+	}
+
+	return sorted[index].Add(sorted[index+1].Sub(sorted[index]).Mul(position.Sub(lower)))
+}
@@ -1,11 +1,152 @@
 package validate
 
 import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/Komilov31/sales-tracker/internal/dto"
+	"github.com/Komilov31/sales-tracker/internal/model"
 	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+	"github.com/shopspring/decimal"
 )
 
 var Validator *validator.Validate
 
+// builtin validates fields other than decimals with standard rules decimal
+// comparisons override.
+var builtin = validator.New()
+
 func init() {
 	Validator = validator.New()
+	Validator.RegisterTagNameFunc(jsonName)
+	Validator.RegisterValidation("notblank", validators.NotBlank)
+	Validator.RegisterValidation("gt", decimalComparison("gt", decimal.Decimal.GreaterThan))
+	Validator.RegisterValidation("gte", decimalComparison("gte", decimal.Decimal.GreaterThanOrEqual))
+	Validator.RegisterStructValidation(amountPrecision, dto.CreateItem{}, dto.UpdateItem{})
+}
+
+// jsonName names fields in validation errors as they are named in payload.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// decimalComparison lets numeric tag such as gte be used with decimal
+// fields, which are compared with parameter exactly as decimals. Other fields
+// are validated by the standard rule.
+func decimalComparison(tag string, compare func(decimal.Decimal, decimal.Decimal) bool) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		value, ok := fl.Field().Interface().(decimal.Decimal)
+		if !ok {
+			return builtin.Var(fl.Field().Interface(), tag+"="+fl.Param()) == nil
+		}
+
+		return compare(value, decimal.RequireFromString(fl.Param()))
+	}
+}
+
+// amountPrecision reports amounts having more digits after the decimal point
+// than their currency allows. Updates which do not change currency are
+// checked by the database, as currency of the item is not known here.
+func amountPrecision(sl validator.StructLevel) {
+	switch item := sl.Current().Interface().(type) {
+	case dto.CreateItem:
+		if !fitsCurrency(item.Amount, item.Currency) {
+			sl.ReportError(item.Amount, "amount", "Amount", "currency_precision", item.Currency)
+		}
+	case dto.UpdateItem:
+		if item.Amount != nil && item.Currency != nil && !fitsCurrency(*item.Amount, *item.Currency) {
+			sl.ReportError(item.Amount, "amount", "Amount", "currency_precision", *item.Currency)
+		}
+	}
+}
+
+func fitsCurrency(amount decimal.Decimal, currency string) bool {
+	return amount.Equal(amount.Truncate(model.CurrencyExponent(currency)))
+}
+
+// Fields describes every rule broken according to err returned by Validator,
+// it is empty for other errors.
+func Fields(err error) []model.FieldError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	fields := make([]model.FieldError, len(validationErrors))
+	for i, fieldErr := range validationErrors {
+		// Namespace starts with name of the validated struct, which is not
+		// a part of payload.
+		_, field, _ := strings.Cut(fieldErr.Namespace(), ".")
+		fields[i] = model.FieldError{
+			Field:   field,
+			Rule:    fieldErr.Tag(),
+			Message: field + " " + ruleMessage(fieldErr),
+		}
+	}
+
+	return fields
+}
+
+// Message joins messages of fields into one.
+func Message(fields []model.FieldError) string {
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func ruleMessage(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+
+	switch fieldErr.Tag() {
+	case "required", "required_if", "required_unless":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "excluded_if", "excluded_unless":
+		return "must not be set"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(param, " ", ", ")
+	case "gt":
+		return "must be greater than " + param
+	case "gte":
+		return "must be at least " + param
+	case "min", "max":
+		bound := "at least "
+		if fieldErr.Tag() == "max" {
+			bound = "at most "
+		}
+		switch fieldErr.Kind() {
+		case reflect.String:
+			if fieldErr.Tag() == "min" && param == "1" {
+				return "must not be empty"
+			}
+			return "must have " + bound + param + " characters"
+		case reflect.Slice, reflect.Map:
+			return "must have " + bound + param + " elements"
+		}
+		return "must be " + bound + param
+	case "datetime":
+		return "must be a date in format " + param
+	case "iso4217":
+		return "must be an ISO 4217 currency code"
+	case "nefield":
+		return "must differ from " + strings.ToLower(param)
+	case "unique":
+		return "must not have duplicates"
+	case "currency_precision":
+		if param == "" {
+			return "has more decimal places than its currency allows"
+		}
+		return "has more decimal places than " + param + " allows"
+	}
+
+	return "breaks " + fieldErr.Tag() + " rule"
 }
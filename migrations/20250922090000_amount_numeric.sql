@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION currency_exponent(code CHAR(3))
+RETURNS INT
+LANGUAGE sql
+IMMUTABLE
+AS $$
+    SELECT CASE
+        WHEN code IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG',
+            'RWF', 'UGX', 'UYI', 'VND', 'VUV', 'XAF', 'XOF', 'XPF') THEN 0
+        WHEN code IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND') THEN 3
+        WHEN code IN ('CLF', 'UYW') THEN 4
+        ELSE 2
+    END;
+$$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION numeric_percentile(vals NUMERIC[], fraction NUMERIC)
+RETURNS NUMERIC
+LANGUAGE sql
+IMMUTABLE
+AS $$
+    WITH sorted AS (
+        SELECT array_agg(val ORDER BY val) AS arr, count(*) AS n
+        FROM unnest(vals) AS val
+        WHERE val IS NOT NULL
+    ), position AS (
+        SELECT arr, fraction * (n - 1) + 1 AS pos
+        FROM sorted
+        WHERE n > 0
+    )
+    SELECT arr[floor(pos)::int] + (arr[ceil(pos)::int] - arr[floor(pos)::int]) * (pos - floor(pos))
+    FROM position;
+$$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE items ALTER COLUMN amount TYPE NUMERIC(20, 4);
+ALTER TABLE items ADD CONSTRAINT items_amount_precision
+    CHECK (amount = round(amount, currency_exponent(currency)));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE items DROP CONSTRAINT IF EXISTS items_amount_precision;
+ALTER TABLE items ALTER COLUMN amount TYPE INT USING round(amount)::int;
+DROP FUNCTION IF EXISTS numeric_percentile(NUMERIC[], NUMERIC);
+DROP FUNCTION IF EXISTS currency_exponent(CHAR(3));
+-- +goose StatementEnd
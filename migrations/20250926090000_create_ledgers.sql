@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS ledgers(
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS ledger_members(
+    ledger_id INT NOT NULL REFERENCES ledgers(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (ledger_id, user_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_ledger_members_user ON ledger_members (user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE items ADD COLUMN IF NOT EXISTS ledger_id INT REFERENCES ledgers(id) ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- Items and users existing before ledgers share one common ledger.
+-- +goose StatementBegin
+INSERT INTO ledgers(name)
+SELECT 'Общая книга'
+WHERE EXISTS (SELECT 1 FROM items) OR EXISTS (SELECT 1 FROM users);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE items SET ledger_id = (SELECT MIN(id) FROM ledgers);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO ledger_members(ledger_id, user_id)
+SELECT (SELECT MIN(id) FROM ledgers), id FROM users
+WHERE EXISTS (SELECT 1 FROM ledgers);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE items ALTER COLUMN ledger_id SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_items_ledger_date ON items (ledger_id, date);
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE items DROP COLUMN IF EXISTS ledger_id;
+DROP TABLE IF EXISTS ledger_members;
+DROP TABLE IF EXISTS ledgers;
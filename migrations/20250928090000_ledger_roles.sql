@@ -0,0 +1,13 @@
+-- +goose Up
+-- Members added before roles keep full access.
+-- +goose StatementBegin
+ALTER TABLE ledger_members ADD COLUMN IF NOT EXISTS role VARCHAR(10) NOT NULL DEFAULT 'admin'
+    CHECK (role IN ('viewer', 'editor', 'admin'));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_members ALTER COLUMN role SET DEFAULT 'viewer';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE ledger_members DROP COLUMN IF EXISTS role;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- Expiry is compared with the current time, so timestamps of keys are kept
+-- with time zone.
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS api_keys(
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_api_keys_user ON api_keys (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS api_keys;
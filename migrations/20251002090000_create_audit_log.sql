@@ -0,0 +1,49 @@
+-- +goose Up
+-- Items may be deleted, so item_id and api_key_id are not foreign keys:
+-- history of item outlives it and revoked keys stay recorded.
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS audit_log(
+    id BIGSERIAL PRIMARY KEY,
+    ledger_id INT NOT NULL REFERENCES ledgers(id),
+    item_id INT NOT NULL,
+    user_id INT NOT NULL REFERENCES users(id),
+    api_key_id INT,
+    operation VARCHAR(10) NOT NULL CHECK (operation IN ('create', 'update', 'delete')),
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_audit_log_item ON audit_log (ledger_id, item_id, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_audit_log_ledger ON audit_log (ledger_id, id);
+-- +goose StatementEnd
+
+-- Audit log is append-only: entries can be neither changed nor removed.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE items ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- Only items in trash are indexed, for retention to find the expired ones.
+-- +goose StatementBegin
+CREATE INDEX idx_items_deleted ON items (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_operation_check;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_operation_check
+    CHECK (operation IN ('create', 'update', 'delete', 'restore'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- Items in trash were deleted by users, rolling back removes them for good.
+-- +goose StatementBegin
+DELETE FROM items WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE items DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Version grows with every change of item, clients send the version they
+-- have read to make sure they do not overwrite someone else's change.
+-- +goose StatementBegin
+ALTER TABLE items ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE items DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
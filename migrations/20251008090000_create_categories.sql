@@ -0,0 +1,90 @@
+-- +goose Up
+-- Categories of ledger form a tree. Type limits category and its
+-- subcategories to income or expense items, NULL fits both. Names are unique
+-- within ledger regardless of case.
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS categories(
+    id SERIAL PRIMARY KEY,
+    ledger_id INT NOT NULL REFERENCES ledgers(id) ON DELETE CASCADE,
+    parent_id INT REFERENCES categories(id),
+    name TEXT NOT NULL CHECK (btrim(name) <> ''),
+    type VARCHAR(10) CHECK (type IN ('доход', 'расход')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_categories_name ON categories (ledger_id, lower(name));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_categories_parent ON categories (parent_id);
+-- +goose StatementEnd
+
+-- Aliases are other names of category items may be created with, they are
+-- stored under the name of category.
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS category_aliases(
+    category_id INT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    ledger_id INT NOT NULL REFERENCES ledgers(id) ON DELETE CASCADE,
+    alias TEXT NOT NULL CHECK (btrim(alias) <> '')
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX idx_category_aliases_alias ON category_aliases (ledger_id, lower(alias));
+-- +goose StatementEnd
+
+-- Every category used by items becomes a top-level category. Spellings
+-- differing only in case and surrounding spaces are merged into the most
+-- used one.
+-- +goose StatementBegin
+INSERT INTO categories(ledger_id, name)
+SELECT DISTINCT ON (ledger_id, lower(btrim(category))) ledger_id, btrim(category)
+FROM items
+WHERE btrim(category) <> ''
+GROUP BY ledger_id, btrim(category)
+ORDER BY ledger_id, lower(btrim(category)), COUNT(*) DESC, btrim(category);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE items SET category = c.name
+FROM categories c
+WHERE c.ledger_id = items.ledger_id
+    AND lower(c.name) = lower(btrim(items.category))
+    AND items.category <> c.name;
+-- +goose StatementEnd
+
+-- category_root returns name of the top-level category category of ledger
+-- belongs to, or category itself if ledger has no such category.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION category_root(ledger INT, category TEXT)
+RETURNS TEXT
+LANGUAGE sql
+STABLE
+AS $$
+    WITH RECURSIVE ancestors AS (
+        SELECT id, parent_id, name FROM categories
+        WHERE ledger_id = ledger AND name = category
+        UNION ALL
+        SELECT c.id, c.parent_id, c.name
+        FROM categories c
+        JOIN ancestors a ON c.id = a.parent_id
+    )
+    SELECT COALESCE((SELECT name FROM ancestors WHERE parent_id IS NULL), category);
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- Merged spellings of categories are not restored.
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS category_root(INT, TEXT);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS category_aliases;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS categories;
+-- +goose StatementEnd
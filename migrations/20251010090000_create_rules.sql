@@ -0,0 +1,40 @@
+-- +goose Up
+-- Description and counterparty come from bank statements, rules choose
+-- category of items by them.
+-- +goose StatementBegin
+ALTER TABLE items ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS counterparty TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- Rule assigns category to items it matches. Empty patterns and NULL type
+-- and bounds match every item, rules of ledger are tried from the highest
+-- priority.
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS rules(
+    id SERIAL PRIMARY KEY,
+    ledger_id INT NOT NULL REFERENCES ledgers(id) ON DELETE CASCADE,
+    priority INT NOT NULL DEFAULT 0,
+    category TEXT NOT NULL CHECK (btrim(category) <> ''),
+    description TEXT NOT NULL DEFAULT '',
+    counterparty TEXT NOT NULL DEFAULT '',
+    regex BOOLEAN NOT NULL DEFAULT FALSE,
+    type VARCHAR(10) CHECK (type IN ('доход', 'расход')),
+    amount_min NUMERIC(20, 4),
+    amount_max NUMERIC(20, 4),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (amount_min <= amount_max)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_rules_ledger ON rules (ledger_id, priority DESC, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS rules;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE items DROP COLUMN IF EXISTS description, DROP COLUMN IF EXISTS counterparty;
+-- +goose StatementEnd
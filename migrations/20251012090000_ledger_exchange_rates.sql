@@ -0,0 +1,43 @@
+-- +goose Up
+-- Exchange rates belong to ledger, so that members of one ledger can not
+-- change converted analytics of another. Rates created before are copied into
+-- every ledger.
+-- +goose StatementBegin
+ALTER TABLE exchange_rates ADD COLUMN IF NOT EXISTS ledger_id INT REFERENCES ledgers(id) ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE exchange_rates DROP CONSTRAINT IF EXISTS exchange_rates_base_quote_date_key;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO exchange_rates(ledger_id, base, quote, date, rate, created_at)
+SELECT l.id, r.base, r.quote, r.date, r.rate, r.created_at
+FROM ledgers l
+CROSS JOIN exchange_rates r
+WHERE r.ledger_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DELETE FROM exchange_rates WHERE ledger_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE exchange_rates ALTER COLUMN ledger_id SET NOT NULL,
+    ADD CONSTRAINT exchange_rates_ledger_base_quote_date_key UNIQUE (ledger_id, base, quote, date);
+-- +goose StatementEnd
+
+-- +goose Down
+-- Rates of different ledgers for the same pair and date are merged into the
+-- oldest one.
+-- +goose StatementBegin
+DELETE FROM exchange_rates a
+USING exchange_rates b
+WHERE a.base = b.base AND a.quote = b.quote AND a.date = b.date AND a.id > b.id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE exchange_rates DROP CONSTRAINT IF EXISTS exchange_rates_ledger_base_quote_date_key,
+    DROP COLUMN IF EXISTS ledger_id,
+    ADD CONSTRAINT exchange_rates_base_quote_date_key UNIQUE (base, quote, date);
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- Administrators register new users. The oldest user, who has created the
+-- first account, becomes the administrator of existing installations.
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE users SET is_admin = TRUE WHERE id = (SELECT MIN(id) FROM users);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS is_admin;
+-- +goose StatementEnd
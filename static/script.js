@@ -1,8 +1,79 @@
 document.addEventListener('DOMContentLoaded', function() {
     const API_BASE = '/';
+    const ROLE_NAMES = { viewer: 'просмотр', editor: 'редактор', admin: 'администратор' };
 
-    // Load items on page load
-    loadItems();
+    // Cursors of adjacent pages returned by the last items request
+    let nextCursor = '';
+    let prevCursor = '';
+
+    // Show login form or the app depending on saved token
+    initSession();
+
+    // Login form
+    const loginForm = document.getElementById('login-form');
+    loginForm.addEventListener('submit', async function(e) {
+        e.preventDefault();
+        try {
+            await login();
+            loginForm.reset();
+        } catch (error) {
+            alert('Ошибка: ' + error.message);
+        }
+    });
+
+    // Registration of the first user, later ones are registered by existing users
+    document.getElementById('register').addEventListener('click', async function() {
+        if (!loginForm.reportValidity()) return;
+        const response = await fetch(API_BASE + 'auth/register', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(credentials())
+        });
+        if (!response.ok) {
+            const data = await response.json().catch(() => ({}));
+            alert('Ошибка регистрации: ' + (data.error || response.status));
+            return;
+        }
+        try {
+            await login();
+            loginForm.reset();
+        } catch (error) {
+            alert('Ошибка: ' + error.message);
+        }
+    });
+
+    document.getElementById('logout').addEventListener('click', function() {
+        localStorage.removeItem('token');
+        localStorage.removeItem('ledger');
+        showLogin();
+    });
+
+    // Ledger switching, every request below is made within selected ledger
+    document.getElementById('ledger').addEventListener('change', function() {
+        localStorage.setItem('ledger', this.value);
+        applyRole();
+        loadItems();
+    });
+
+    document.getElementById('create-ledger').addEventListener('click', async function() {
+        const name = prompt('Название книги:');
+        if (!name) return;
+
+        const response = await apiFetch(API_BASE + 'ledgers', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ name })
+        });
+        if (!response.ok) {
+            alert('Ошибка создания книги');
+            return;
+        }
+
+        const ledger = await response.json();
+        localStorage.setItem('ledger', ledger.id);
+        await loadLedgers();
+        loadItems();
+    });
 
     // Add item form
     const addForm = document.getElementById('add-form');
@@ -10,13 +81,14 @@ document.addEventListener('DOMContentLoaded', function() {
         e.preventDefault();
         const formData = {
             type: document.getElementById('type').value,
-            amount: parseInt(document.getElementById('amount').value),
+            amount: document.getElementById('amount').value,
             date: document.getElementById('date').value,
-            category: document.getElementById('category').value
+            category: document.getElementById('category').value,
+            currency: document.getElementById('currency').value
         };
 
         try {
-            const response = await fetch(API_BASE + 'items', {
+            const response = await apiFetch(API_BASE + 'items', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(formData)
@@ -40,30 +112,190 @@ document.addEventListener('DOMContentLoaded', function() {
         loadItems();
     });
 
+    // Pagination
+    document.getElementById('next-page').addEventListener('click', function() {
+        loadItems(nextCursor);
+    });
+    document.getElementById('prev-page').addEventListener('click', function() {
+        loadItems(prevCursor);
+    });
+
     // Analytics form
     const analyticsForm = document.getElementById('analytics-form');
     analyticsForm.addEventListener('submit', async function(e) {
         e.preventDefault();
         const from = document.getElementById('analytics-from').value;
         const to = document.getElementById('analytics-to').value;
-        await loadAnalytics(from, to);
+        const currency = document.getElementById('analytics-currency').value;
+        await loadAnalytics(from, to, currency);
     });
 
     // Export buttons
-    document.getElementById('export-items-csv').addEventListener('click', exportItemsCSV);
-    document.getElementById('export-analytics-csv').addEventListener('click', exportAnalyticsCSV);
+    document.getElementById('export-items-csv').addEventListener('click', () => exportItems('csv'));
+    document.getElementById('export-analytics-csv').addEventListener('click', () => exportAnalytics('csv'));
+    document.getElementById('export-items-xlsx').addEventListener('click', () => exportItems('xlsx'));
+    document.getElementById('export-analytics-xlsx').addEventListener('click', () => exportAnalytics('xlsx'));
+    document.getElementById('export-statement').addEventListener('click', exportStatement);
+
+    // Import form
+    const importForm = document.getElementById('import-form');
+    importForm.addEventListener('submit', async function(e) {
+        e.preventDefault();
+        const formData = new FormData();
+        formData.append('file', document.getElementById('import-file').files[0]);
 
-    async function loadItems() {
-        const sortBy = document.getElementById('sort-by').value;
         const params = new URLSearchParams();
-        if (sortBy) params.append('sort_by', sortBy);
+        if (document.getElementById('import-dry-run').checked) params.append('dry_run', 'true');
 
-        const response = await fetch(API_BASE + 'items?' + params.toString());
+        const response = await apiFetch(API_BASE + 'items/import?' + params.toString(), {
+            method: 'POST',
+            body: formData
+        });
+        const report = await response.json();
+        document.getElementById('import-report').textContent = formatImportReport(report);
+
+        if (response.ok && report.imported > 0) {
+            importForm.reset();
+            loadItems();
+        }
+    });
+
+    function credentials() {
+        return {
+            login: document.getElementById('login-name').value,
+            password: document.getElementById('login-password').value
+        };
+    }
+
+    async function login() {
+        const response = await fetch(API_BASE + 'auth/login', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(credentials())
+        });
+        if (!response.ok) throw new Error('неверный логин или пароль');
+
+        const token = await response.json();
+        localStorage.setItem('token', token.token);
+        await initSession();
+    }
+
+    async function initSession() {
+        if (!localStorage.getItem('token')) {
+            showLogin();
+            return;
+        }
+
+        const response = await apiFetch(API_BASE + 'auth/me');
+        if (!response.ok) return;
+
+        const user = await response.json();
+        document.getElementById('session-user').textContent = user.login;
+        await loadLedgers();
+        document.getElementById('login').hidden = true;
+        document.getElementById('session').hidden = false;
+        document.querySelector('main').hidden = false;
+        loadItems();
+    }
+
+    async function loadLedgers() {
+        const response = await apiFetch(API_BASE + 'ledgers');
+        if (!response.ok) return;
+
+        const ledgers = await response.json();
+        const select = document.getElementById('ledger');
+        select.innerHTML = '';
+        ledgers.forEach(ledger => {
+            const option = new Option(`${ledger.name} (${ROLE_NAMES[ledger.role] || ledger.role})`, ledger.id);
+            option.dataset.role = ledger.role;
+            select.add(option);
+        });
+
+        const saved = localStorage.getItem('ledger');
+        if (ledgers.some(ledger => String(ledger.id) === saved)) {
+            select.value = saved;
+        } else if (ledgers.length > 0) {
+            localStorage.setItem('ledger', ledgers[0].id);
+        }
+        applyRole();
+    }
+
+    // applyRole hides item form in ledgers where user may only read, the
+    // server rejects such changes anyway.
+    function applyRole() {
+        const option = document.getElementById('ledger').selectedOptions[0];
+        const role = option ? option.dataset.role : '';
+        document.getElementById('add-form').hidden = role === 'viewer';
+    }
+
+    function showLogin() {
+        document.getElementById('login').hidden = false;
+        document.getElementById('session').hidden = true;
+        document.querySelector('main').hidden = true;
+    }
+
+    // apiFetch sends request with saved token within selected ledger and
+    // returns to login form when token is missing, expired or revoked.
+    async function apiFetch(url, options = {}) {
+        const headers = new Headers(options.headers);
+        const token = localStorage.getItem('token');
+        if (token) headers.set('Authorization', 'Bearer ' + token);
+        const ledger = localStorage.getItem('ledger');
+        if (ledger) headers.set('X-Ledger-ID', ledger);
+
+        const response = await fetch(url, { ...options, headers });
+        if (response.status === 401) {
+            localStorage.removeItem('token');
+            showLogin();
+        }
+        return response;
+    }
+
+    function formatImportReport(report) {
+        if (report.error) return 'Ошибка: ' + report.error;
+
+        const lines = [
+            `Строк: ${report.total}, корректных: ${report.valid}, импортировано: ${report.imported}`
+        ];
+        report.errors.forEach(error => lines.push(`Строка ${error.line}: ${error.error}`));
+        return lines.join('\n');
+    }
+
+    function buildItemsParams() {
+        const params = new URLSearchParams();
+        const filters = {
+            sort_by: document.getElementById('sort-by').value,
+            type: document.getElementById('filter-type').value,
+            category_contains: document.getElementById('filter-category').value,
+            date_from: document.getElementById('filter-date-from').value,
+            date_to: document.getElementById('filter-date-to').value,
+            amount_min: document.getElementById('filter-amount-min').value,
+            amount_max: document.getElementById('filter-amount-max').value,
+            currency: document.getElementById('filter-currency').value
+        };
+
+        Object.entries(filters).forEach(([name, value]) => {
+            if (value) params.append(name, value);
+        });
+
+        return params;
+    }
+
+    async function loadItems(cursor) {
+        const params = buildItemsParams();
+        if (cursor) params.append('cursor', cursor);
+
+        const response = await apiFetch(API_BASE + 'items?' + params.toString());
         if (!response.ok) throw new Error('Ошибка загрузки записей');
 
-        const items = await response.json();
-        displayItems(items);
-        applyClientSideFilters(items);
+        const page = await response.json();
+        displayItems(page.items);
+
+        nextCursor = page.next_cursor || '';
+        prevCursor = page.prev_cursor || '';
+        document.getElementById('next-page').disabled = !nextCursor;
+        document.getElementById('prev-page').disabled = !prevCursor;
+        document.getElementById('items-total').textContent = 'Всего: ' + page.total;
     }
 
     function displayItems(items) {
@@ -78,65 +310,46 @@ document.addEventListener('DOMContentLoaded', function() {
                 <td>${item.amount}</td>
                 <td>${item.date}</td>
                 <td>${item.category}</td>
+                <td>${item.currency}</td>
                 <td>
-                    <button onclick="editItem(${item.id}, '${item.type}', ${item.amount}, '${item.date}', '${item.category}')">Редактировать</button>
-                    <button onclick="deleteItem(${item.id})">Удалить</button>
+                    <button onclick="editItem(${item.id}, ${item.version}, '${item.type}', '${item.amount}', '${item.date}', '${item.category}', '${item.currency}')">Редактировать</button>
+                    <button onclick="deleteItem(${item.id}, ${item.version})">Удалить</button>
                 </td>
             `;
         });
     }
 
-    function applyClientSideFilters(allItems) {
-        const filterType = document.getElementById('filter-type').value;
-        const filterCategory = document.getElementById('filter-category').value.toLowerCase();
-        const filterDateFrom = document.getElementById('filter-date-from').value;
-        const filterDateTo = document.getElementById('filter-date-to').value;
-
-        let filteredItems = allItems;
-
-        if (filterType) {
-            filteredItems = filteredItems.filter(item => item.type === filterType);
-        }
-
-        if (filterCategory) {
-            filteredItems = filteredItems.filter(item => item.category.toLowerCase().includes(filterCategory));
-        }
-
-        if (filterDateFrom) {
-            filteredItems = filteredItems.filter(item => item.date >= filterDateFrom);
-        }
-
-        if (filterDateTo) {
-            filteredItems = filteredItems.filter(item => item.date <= filterDateTo);
-        }
-
-        displayItems(filteredItems);
-    }
-
     // Edit item (simple prompt for now, can be improved with modal)
-    window.editItem = async function(id, type, amount, date, category) {
+    // Items are changed only in the version shown in the table, so that
+    // concurrent changes of other members are not silently overwritten
+    window.editItem = async function(id, version, type, amount, date, category, currency) {
         const newType = prompt('Новый тип:', type);
         const newAmount = prompt('Новая сумма:', amount);
         const newDate = prompt('Новая дата (YYYY-MM-DD):', date);
         const newCategory = prompt('Новая категория:', category);
+        const newCurrency = prompt('Новая валюта:', currency);
 
-        if (newType && newAmount && newDate && newCategory) {
+        if (newType && newAmount && newDate && newCategory && newCurrency) {
             const updateData = {
                 type: newType,
-                amount: parseInt(newAmount),
+                amount: newAmount.replace(',', '.'),
                 date: newDate,
-                category: newCategory
+                category: newCategory,
+                currency: newCurrency.toUpperCase()
             };
 
-            const response = await fetch(API_BASE + `items/${id}`, {
+            const response = await apiFetch(API_BASE + `items/${id}`, {
                 method: 'PUT',
-                headers: { 'Content-Type': 'application/json' },
+                headers: { 'Content-Type': 'application/json', 'If-Match': `"${version}"` },
                 body: JSON.stringify(updateData)
             });
 
             if (response.ok) {
                 loadItems();
                 alert('Запись обновлена!');
+            } else if (response.status === 412) {
+                loadItems();
+                alert('Запись уже изменил другой пользователь. Таблица обновлена, повторите изменение.');
             } else {
                 alert('Ошибка обновления');
             }
@@ -144,50 +357,113 @@ document.addEventListener('DOMContentLoaded', function() {
     };
 
     // Delete item
-    window.deleteItem = async function(id) {
+    window.deleteItem = async function(id, version) {
         if (confirm('Удалить запись?')) {
-            const response = await fetch(API_BASE + `items/${id}`, {
-                method: 'DELETE'
+            const response = await apiFetch(API_BASE + `items/${id}`, {
+                method: 'DELETE',
+                headers: { 'If-Match': `"${version}"` }
             });
 
             if (response.ok) {
                 loadItems();
-                alert('Запись удалена!');
+                if (confirm('Запись перемещена в корзину. Восстановить её?')) {
+                    restoreItem(id);
+                }
+            } else if (response.status === 412) {
+                loadItems();
+                alert('Запись уже изменил другой пользователь. Таблица обновлена, проверьте её перед удалением.');
             } else {
                 alert('Ошибка удаления');
             }
         }
     };
 
-    async function loadAnalytics(from, to) {
-        const params = new URLSearchParams();
+    // Trash keeps deleted items until retention purges them
+    document.getElementById('load-trash').addEventListener('click', loadTrash);
+
+    async function loadTrash() {
+        const response = await apiFetch(API_BASE + 'items/trash?limit=500');
+        if (!response.ok) {
+            alert('Ошибка загрузки корзины');
+            return;
+        }
+
+        const page = await response.json();
+        const tbody = document.querySelector('#trash-table tbody');
+        tbody.innerHTML = '';
+
+        if (page.items.length === 0) {
+            tbody.innerHTML = '<tr><td colspan="7">Корзина пуста</td></tr>';
+            return;
+        }
+
+        page.items.forEach(item => {
+            const row = tbody.insertRow();
+            row.innerHTML = `
+                <td>${item.id}</td>
+                <td>${item.type}</td>
+                <td>${item.amount} ${item.currency}</td>
+                <td>${item.date}</td>
+                <td>${item.category}</td>
+                <td>${new Date(item.deleted_at).toLocaleString()}</td>
+                <td><button onclick="restoreItem(${item.id})">Восстановить</button></td>
+            `;
+        });
+    }
+
+    window.restoreItem = async function(id) {
+        const response = await apiFetch(API_BASE + `items/${id}/restore`, {
+            method: 'POST'
+        });
+
+        if (response.ok) {
+            loadItems();
+            loadTrash();
+        } else {
+            alert('Ошибка восстановления');
+        }
+    };
+
+    async function loadAnalytics(from, to, currency) {
+        const params = new URLSearchParams({ view: 'summary' });
         if (from) params.append('from', from);
         if (to) params.append('to', to);
+        if (currency) params.append('currency', currency);
 
-        const response = await fetch(API_BASE + 'analytics?' + params.toString());
+        const response = await apiFetch(API_BASE + 'analytics?' + params.toString());
         if (!response.ok) throw new Error('Ошибка загрузки аналитики');
 
         const analytics = await response.json();
-        displayAnalytics(analytics);
-        drawChart(analytics);
+        displayAnalytics(analytics.summary);
+        drawChart(analytics.summary);
     }
 
-    function displayAnalytics(analytics) {
+    function displayAnalytics(agg) {
         const tbody = document.querySelector('#analytics-table tbody');
         tbody.innerHTML = '';
 
-        if (analytics.length === 0) {
+        if (agg.count === 0) {
             tbody.innerHTML = '<tr><td colspan="2">Нет данных</td></tr>';
             return;
         }
 
-        const agg = analytics[0].aggregated_data;
         const rows = [
             ['Сумма', agg.sum],
             ['Среднее', agg.average],
             ['Количество', agg.count],
             ['Медиана', agg.median],
             ['90-й перцентиль', agg.percentile_90],
+            ['Доходы: сумма', agg.income.sum],
+            ['Доходы: количество', agg.income.count],
+            ['Доходы: среднее', agg.income.average],
+            ['Доходы: медиана', agg.income.median],
+            ['Доходы: 90-й перцентиль', agg.income.percentile_90],
+            ['Расходы: сумма', agg.expense.sum],
+            ['Расходы: количество', agg.expense.count],
+            ['Расходы: среднее', agg.expense.average],
+            ['Расходы: медиана', agg.expense.median],
+            ['Расходы: 90-й перцентиль', agg.expense.percentile_90],
+            ['Баланс', agg.net],
         ];
 
         rows.forEach(([label, value]) => {
@@ -196,24 +472,24 @@ document.addEventListener('DOMContentLoaded', function() {
         });
     }
 
-    function drawChart(analytics) {
+    function drawChart(agg) {
         const canvas = document.getElementById('analytics-chart');
         const ctx = canvas.getContext('2d');
 
         // Simple bar chart for aggregated values
         ctx.clearRect(0, 0, canvas.width, canvas.height);
 
-        if (analytics.length === 0) return;
+        if (agg.count === 0) return;
 
         // Set canvas size to fit 5 bars
         canvas.width = 400;
         canvas.height = 200;
 
-        const agg = analytics[0].aggregated_data;
         // Bars for sum, average (scaled), median (scaled), count (scaled), percentile_90 (scaled)
+        // Amounts come as decimal strings, numbers are precise enough to draw them
         const scaleFactor = 10; // Scale smaller values for visibility
         const data = [
-            agg.sum,
+            Number(agg.sum),
             agg.average * scaleFactor,
             agg.median * scaleFactor,
             agg.count * scaleFactor,
@@ -249,36 +525,71 @@ document.addEventListener('DOMContentLoaded', function() {
         });
     }
 
-    async function exportItemsCSV() {
-        const sortBy = document.getElementById('sort-by').value;
-        const params = new URLSearchParams();
-        if (sortBy) params.append('sort_by', sortBy);
+    async function exportItems(format) {
+        const params = buildItemsParams();
+        params.append('format', format);
+        appendCSVOptions(params, format);
 
-        const response = await fetch(API_BASE + 'items/csv?' + params.toString());
+        const response = await apiFetch(API_BASE + 'items?' + params.toString());
         if (response.ok) {
             const blob = await response.blob();
-            downloadBlob(blob, 'items.csv');
+            downloadBlob(blob, 'items.' + format);
         } else {
             alert('Ошибка экспорта');
         }
     }
 
-    async function exportAnalyticsCSV() {
+    async function exportAnalytics(format) {
         const from = document.getElementById('analytics-from').value;
         const to = document.getElementById('analytics-to').value;
+        const currency = document.getElementById('analytics-currency').value;
         const params = new URLSearchParams();
         if (from) params.append('from', from);
         if (to) params.append('to', to);
+        if (currency) params.append('currency', currency);
 
-        const response = await fetch(API_BASE + 'analytics/csv?' + params.toString());
+        params.append('format', format);
+        appendCSVOptions(params, format);
+
+        const response = await apiFetch(API_BASE + 'analytics?' + params.toString());
         if (response.ok) {
             const blob = await response.blob();
-            downloadBlob(blob, 'analytics.csv');
+            downloadBlob(blob, 'analytics.' + format);
         } else {
             alert('Ошибка экспорта');
         }
     }
 
+    // appendCSVOptions adds dialect Russian Excel opens without import wizard.
+    function appendCSVOptions(params, format) {
+        if (format !== 'csv' || !document.getElementById('export-csv-excel-ru').checked) return;
+        params.append('delimiter', ';');
+        params.append('bom', 'true');
+        params.append('decimal_separator', ',');
+        params.append('date_format', 'ru');
+        params.append('lang', 'ru');
+    }
+
+    async function exportStatement() {
+        const month = document.getElementById('statement-month').value;
+        if (!month) {
+            alert('Выберите месяц');
+            return;
+        }
+        const params = new URLSearchParams({ month });
+        const currency = document.getElementById('analytics-currency').value;
+        if (currency) params.append('currency', currency);
+
+        const response = await apiFetch(API_BASE + 'reports/statement?' + params.toString());
+        if (response.ok) {
+            const blob = await response.blob();
+            downloadBlob(blob, 'statement_' + month + '.pdf');
+        } else {
+            const data = await response.json().catch(() => ({}));
+            alert('Ошибка экспорта: ' + (data.error || response.status));
+        }
+    }
+
     function downloadBlob(blob, filename) {
         const url = URL.createObjectURL(blob);
         const a = document.createElement('a');